@@ -0,0 +1,109 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package earth
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A Box is a geographic bounding box
+// defined by its north and south latitudes,
+// and its west and east longitudes
+// (in degrees).
+//
+// If West is greater than East,
+// the box crosses the antimeridian.
+type Box struct {
+	North float64
+	South float64
+	West  float64
+	East  float64
+}
+
+// ParseBox returns a box
+// from a string with the format "lat,lon,lat,lon",
+// with the coordinates of the north-west corner
+// and the south-east corner of the box.
+// For example "14,-94,-58,-26"
+// encloses South America.
+//
+// The order of the latitudes is not important,
+// but the first longitude is always the west border
+// and the second longitude the east border of the box.
+// If the west longitude is greater than the east longitude,
+// the box crosses the antimeridian,
+// for example "20,170,-25,-170"
+// encloses Fiji.
+func ParseBox(s string) (Box, error) {
+	cs := strings.Split(s, ",")
+	if len(cs) != 4 {
+		return Box{}, fmt.Errorf("invalid box value %q: expecting \"lat,lon,lat,lon\"", s)
+	}
+
+	var v [4]float64
+	for i, c := range cs {
+		c = strings.TrimSpace(c)
+		f, err := strconv.ParseFloat(c, 64)
+		if err != nil {
+			return Box{}, fmt.Errorf("invalid box value %q: %v", s, err)
+		}
+		if i%2 == 0 && (f < -90 || f > 90) {
+			return Box{}, fmt.Errorf("invalid box value %q: invalid latitude %.6f", s, f)
+		}
+		if i%2 == 1 && (f < -180 || f > 180) {
+			return Box{}, fmt.Errorf("invalid box value %q: invalid longitude %.6f", s, f)
+		}
+		v[i] = f
+	}
+
+	return Box{
+		North: max(v[0], v[2]),
+		South: min(v[0], v[2]),
+		West:  v[1],
+		East:  v[3],
+	}, nil
+}
+
+// Contains returns true if the given coordinates
+// are inside the box.
+// The borders of the box are inclusive.
+func (b Box) Contains(lat, lon float64) bool {
+	if lat > b.North || lat < b.South {
+		return false
+	}
+
+	if b.West <= b.East {
+		return lon >= b.West && lon <= b.East
+	}
+	return lon >= b.West || lon <= b.East
+}
+
+// Intersects returns true if two boxes
+// share at least a point.
+func (b Box) Intersects(o Box) bool {
+	if b.South > o.North || o.South > b.North {
+		return false
+	}
+
+	for _, bi := range b.lonRanges() {
+		for _, oi := range o.lonRanges() {
+			if bi[0] <= oi[1] && oi[0] <= bi[1] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// LonRanges returns the longitude ranges of the box,
+// splitting the box at the antimeridian.
+func (b Box) lonRanges() [][2]float64 {
+	if b.West <= b.East {
+		return [][2]float64{{b.West, b.East}}
+	}
+	return [][2]float64{{b.West, 180}, {-180, b.East}}
+}
@@ -0,0 +1,107 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package earth_test
+
+import (
+	"testing"
+
+	"github.com/js-arias/earth"
+)
+
+func TestParseBox(t *testing.T) {
+	want := earth.Box{North: 14, South: -58, West: -94, East: -26}
+	for _, s := range []string{
+		"14,-94,-58,-26",
+		"-58,-94,14,-26",
+		"14, -94, -58, -26",
+	} {
+		b, err := earth.ParseBox(s)
+		if err != nil {
+			t.Errorf("box %q: unexpected error: %v", s, err)
+			continue
+		}
+		if b != want {
+			t.Errorf("box %q: got %v, want %v", s, b, want)
+		}
+	}
+
+	// a box crossing the antimeridian
+	fiji := earth.Box{North: 20, South: -25, West: 170, East: -170}
+	b, err := earth.ParseBox("20,170,-25,-170")
+	if err != nil {
+		t.Fatalf("box %q: unexpected error: %v", "20,170,-25,-170", err)
+	}
+	if b != fiji {
+		t.Errorf("box %q: got %v, want %v", "20,170,-25,-170", b, fiji)
+	}
+	if !b.Contains(-18, 178) || !b.Contains(-18, -178) || b.Contains(-18, 0) {
+		t.Errorf("box %q: got %v, want a box from 170 to -170", "20,170,-25,-170", b)
+	}
+
+	for _, s := range []string{
+		"14,-94,-58",
+		"14,-94,-58,x",
+		"100,-94,-58,-26",
+		"14,-194,-58,-26",
+	} {
+		if _, err := earth.ParseBox(s); err == nil {
+			t.Errorf("box %q: expecting error", s)
+		}
+	}
+}
+
+func TestBoxContains(t *testing.T) {
+	sa := earth.Box{North: 14, South: -58, West: -94, East: -26}
+	pacific := earth.Box{North: 30, South: -30, West: 160, East: -160}
+
+	tests := map[string]struct {
+		b        earth.Box
+		lat, lon float64
+		want     bool
+	}{
+		"inside":             {sa, -34, -58, true},
+		"border":             {sa, 14, -94, true},
+		"north":              {sa, 20, -58, false},
+		"east":               {sa, -34, 18, false},
+		"antimeridian west":  {pacific, 0, 170, true},
+		"antimeridian east":  {pacific, 0, -170, true},
+		"antimeridian":       {pacific, 0, 180, true},
+		"antimeridian out":   {pacific, 0, 0, false},
+		"antimeridian south": {pacific, -40, 180, false},
+	}
+
+	for name, test := range tests {
+		if got := test.b.Contains(test.lat, test.lon); got != test.want {
+			t.Errorf("%s: got %v, want %v", name, got, test.want)
+		}
+	}
+}
+
+func TestBoxIntersects(t *testing.T) {
+	sa := earth.Box{North: 14, South: -58, West: -94, East: -26}
+	pacific := earth.Box{North: 30, South: -30, West: 160, East: -160}
+
+	tests := map[string]struct {
+		a, b earth.Box
+		want bool
+	}{
+		"same":         {sa, sa, true},
+		"overlap":      {sa, earth.Box{North: 0, South: -10, West: -30, East: 0}, true},
+		"north":        {sa, earth.Box{North: 40, South: 20, West: -90, East: -30}, false},
+		"east":         {sa, earth.Box{North: 0, South: -10, West: 0, East: 30}, false},
+		"antimeridian": {pacific, earth.Box{North: 10, South: -10, West: -170, East: -150}, true},
+		"both cross":   {pacific, earth.Box{North: 10, South: -10, West: 170, East: -170}, true},
+		"pacific sa":   {pacific, sa, false},
+	}
+
+	for name, test := range tests {
+		if got := test.a.Intersects(test.b); got != test.want {
+			t.Errorf("%s: got %v, want %v", name, got, test.want)
+		}
+		if got := test.b.Intersects(test.a); got != test.want {
+			t.Errorf("%s [reverse]: got %v, want %v", name, got, test.want)
+		}
+	}
+}
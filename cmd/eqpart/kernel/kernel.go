@@ -0,0 +1,158 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package kernel implements a command to draw
+// the density of a spherical normal
+// as an image map.
+package kernel
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/js-arias/blind"
+	"github.com/js-arias/command"
+	"github.com/js-arias/earth"
+	"github.com/js-arias/earth/stat/dist"
+	"github.com/js-arias/earth/vector"
+)
+
+var Command = &command.Command{
+	Usage: `kernel [-e|--equator <value>] [-c|--columns <value>]
+	[--center <lat,lon>] -o|--output <out-img-file> <lambda-value>`,
+	Short: "draw the density of a spherical normal",
+	Long: `
+Command kernel draws the density of a spherical normal, centered at a point,
+into an image file using a plate carrée (equirectangular) projection.
+
+The spherical normal is defined by the lambda parameter (the concentration).
+The argument of the command is the lambda value.
+
+By default, the spherical normal is calculated using a pixelation with 360
+pixels at the equator. Use the flag --equator, or -e, to change the size of
+the pixelation.
+
+By default, the spherical normal is centered at latitude 0, longitude 0. Use
+the flag --center to set a different center, using the format "lat,lon", for
+example "-26.8,-65.2". The pixel that contains the center is drawn in solid
+red (RGB = 255, 0, 0).
+
+The flag --output, or -o, is required, and indicates the name of the file of
+the output image. The image is encoded as a PNG file. Each point of the image
+is colored using the density at the great circle distance between the point
+and the center, scaled by the maximum density (i.e. the density at the
+center). By default the image will be 3600 pixels wide, use the flag
+--columns, or -c, to define a different number of image columns.
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var colsFlag int
+var equator int
+var centerFlag string
+var output string
+
+func setFlags(c *command.Command) {
+	c.Flags().IntVar(&colsFlag, "columns", 3600, "")
+	c.Flags().IntVar(&colsFlag, "c", 3600, "")
+	c.Flags().IntVar(&equator, "equator", 360, "")
+	c.Flags().IntVar(&equator, "e", 360, "")
+	c.Flags().StringVar(&centerFlag, "center", "0,0", "")
+	c.Flags().StringVar(&output, "output", "", "")
+	c.Flags().StringVar(&output, "o", "", "")
+}
+
+func run(c *command.Command, args []string) error {
+	if len(args) == 0 {
+		return c.UsageError("expecting lambda value")
+	}
+	if output == "" {
+		return c.UsageError("expecting output image file name, flag --output")
+	}
+
+	lambda, err := strconv.ParseFloat(args[0], 64)
+	if err != nil {
+		return fmt.Errorf("invalid lambda value: %v", err)
+	}
+
+	coord := strings.Split(centerFlag, ",")
+	if len(coord) != 2 {
+		return c.UsageError(fmt.Sprintf("flag --center: invalid value %q: expecting \"lat,lon\"", centerFlag))
+	}
+	pt, err := vector.ParsePoint(strings.TrimSpace(coord[0]), strings.TrimSpace(coord[1]))
+	if err != nil {
+		return c.UsageError(fmt.Sprintf("flag --center: %v", err))
+	}
+	center := earth.NewPoint(pt.Lat, pt.Lon)
+
+	if colsFlag%2 != 0 {
+		colsFlag++
+	}
+
+	pix := earth.NewPixelation(equator)
+	n := dist.NewNormal(lambda, pix)
+	img := renderKernel(n, center, colsFlag)
+	if err := writeImage(output, img); err != nil {
+		return err
+	}
+	return nil
+}
+
+// RenderKernel returns an image
+// with the density of a spherical normal
+// centered at a point
+// in a plate carrée projection
+// with the given number of columns.
+func renderKernel(n dist.Normal, center earth.Point, cols int) image.Image {
+	pix := n.Pix()
+	return &kernelImg{
+		n:      n,
+		center: center,
+		cPix:   pix.Pixel(center.Latitude(), center.Longitude()).ID(),
+		cols:   cols,
+	}
+}
+
+type kernelImg struct {
+	n      dist.Normal
+	center earth.Point
+	cPix   int
+	cols   int
+}
+
+func (k *kernelImg) ColorModel() color.Model { return color.RGBAModel }
+func (k *kernelImg) Bounds() image.Rectangle { return image.Rect(0, 0, k.cols, k.cols/2) }
+func (k *kernelImg) At(x, y int) color.Color {
+	lat, lon := earth.PlateCarreeLatLon(x, y, k.cols, k.cols/2)
+	if k.n.Pix().Pixel(lat, lon).ID() == k.cPix {
+		return color.RGBA{255, 0, 0, 255}
+	}
+
+	d := earth.Distance(k.center, earth.NewPoint(lat, lon))
+	return blind.Sequential(blind.Iridescent, k.n.ScaledProb(d))
+}
+
+func writeImage(name string, img image.Image) (err error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		e := f.Close()
+		if e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	if err := png.Encode(f, img); err != nil {
+		return fmt.Errorf("when encoding image file %q: %v", name, err)
+	}
+	return nil
+}
@@ -80,12 +80,6 @@ func run(c *command.Command, args []string) error {
 	}
 
 	if boxMask != nil || mask != nil {
-		var maskX, maskY float64
-		if mask != nil {
-			maskX = float64(360) / float64(mask.Bounds().Dx())
-			maskY = float64(180) / float64(mask.Bounds().Dy())
-		}
-
 		sum := 0
 		for id := 0; id < pix.Len(); id++ {
 			px := pix.ID(id).Point()
@@ -95,8 +89,7 @@ func run(c *command.Command, args []string) error {
 				}
 			}
 			if mask != nil {
-				x := int((px.Longitude() + 180) / maskX)
-				y := int((90 - px.Latitude()) / maskY)
+				x, y := earth.PlateCarreeXY(px.Latitude(), px.Longitude(), mask.Bounds().Dx(), mask.Bounds().Dy())
 				r, _, _, a := mask.At(x, y).RGBA()
 				if (a>>8) < 200 || (r>>8) < 200 {
 					continue
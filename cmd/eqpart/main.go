@@ -8,9 +8,12 @@ package main
 import (
 	"github.com/js-arias/command"
 	"github.com/js-arias/earth/cmd/eqpart/ids"
+	"github.com/js-arias/earth/cmd/eqpart/kernel"
 	"github.com/js-arias/earth/cmd/eqpart/lencmd"
 	"github.com/js-arias/earth/cmd/eqpart/mapcmd"
 	"github.com/js-arias/earth/cmd/eqpart/pixel"
+	"github.com/js-arias/earth/cmd/eqpart/quality"
+	"github.com/js-arias/earth/cmd/eqpart/thin"
 	"github.com/js-arias/earth/cmd/eqpart/variance"
 )
 
@@ -21,9 +24,12 @@ var app = &command.Command{
 
 func init() {
 	app.Add(ids.Command)
+	app.Add(kernel.Command)
 	app.Add(lencmd.Command)
 	app.Add(mapcmd.Command)
 	app.Add(pixel.Command)
+	app.Add(quality.Command)
+	app.Add(thin.Command)
 	app.Add(variance.Command)
 }
 
@@ -12,8 +12,8 @@ import (
 	"fmt"
 	"image"
 	"image/color"
-	_ "image/jpeg"
-	"image/png"
+	"image/jpeg"
+	_ "image/png"
 	"io"
 	"math/rand"
 	"os"
@@ -23,6 +23,7 @@ import (
 	"github.com/js-arias/blind"
 	"github.com/js-arias/command"
 	"github.com/js-arias/earth"
+	"github.com/js-arias/earth/cmd/internal/imgfmt"
 	"github.com/js-arias/earth/vector"
 )
 
@@ -30,7 +31,8 @@ var Command = &command.Command{
 	Usage: `map [-e|--equator <value>] [-c|--columns <value>]
 	[--box <lat,lon,lat,lon>] [--mask <image>]
 	[--points] [--pixels] [--random <value>]
-	[--bg <image>] -o|--output <out-img-file>`,
+	[--bg <image>] [--wireframe] [--format <format>] [--quality <value>]
+	-o|--output <out-img-file>`,
 	Short: "draw a map of a pixelation",
 	Long: `
 Package map draws the pixels of pixelation based on an equal area partitioning
@@ -49,9 +51,19 @@ image columns.
 If the flag --bg is defined, the read image file will be used as the background
 image, so the pixel colors will be taken from that image.
 
+If the flag --wireframe is defined, only the boundaries of the pixels will be
+drawn (in black), and the inside of each pixel will be white, so the equal
+area partition is visible. A point of the image is drawn as a boundary if the
+point to its right, or the point below it, is in a different pixel, so the
+width of the lines is always one image pixel. If the flag --bg is also
+defined, the inside of each pixel will be taken from the background image, so
+the boundaries will be drawn over the background image.
+
 If the flag --box is defined, only pixels inside the box will be draw. The box
-is defined using the format "lat,lon,lat,lon", for example "14,-94,-58,-26"
-will enclose South America.
+is defined using the format "lat,lon,lat,lon", with the north-west and
+south-east corners of the box, for example "14,-94,-58,-26" will enclose South
+America. If the west longitude is greater than the east longitude, the box
+crosses the antimeridian, for example "20,170,-25,-170" will enclose Fiji.
 
 If the flag --mask is defined, the read image file will be used as a mask, so
 only pixels that are white in the mask will be draw. This flag can be combined
@@ -65,8 +77,15 @@ values will be interpreted as pixel IDs (one ID per line). The points will be
 drawn in solid red (RGB = 255, 0, 0) so, hopefully, they will be easy
 identified in the resulting image.
 
-If the flag --random is defined, the indicated number of random pixels will be
-added. The pixels will be in solid red (RGB = 255, 0, 0).
+If the flag --random is defined, the indicated number of distinct random
+pixels will be added. The pixels will be in solid red (RGB = 255, 0, 0).
+
+By default, the image is encoded as a PNG file. Use the flag --format to define
+the image format, valid formats are "png" and "jpeg". If the flag is not
+defined, the format will be inferred from the extension of the output file.
+Use the flag --quality to set the quality of JPEG images (from 1 to 100,
+default 75). Note that JPEG is a lossy format, and its artifacts make it
+inappropriate for maps of categorical values or masks.
 	`,
 	SetFlags: setFlags,
 	Run:      run,
@@ -79,12 +98,16 @@ var boxFlag string
 var bgFile string
 var maskFile string
 var output string
+var formatFlag string
+var qualityFlag int
 var points bool
 var pixFlag bool
+var wireFlag bool
 
 func setFlags(c *command.Command) {
 	c.Flags().BoolVar(&points, "points", false, "")
 	c.Flags().BoolVar(&pixFlag, "pixels", false, "")
+	c.Flags().BoolVar(&wireFlag, "wireframe", false, "")
 	c.Flags().IntVar(&colsFlag, "columns", 3600, "")
 	c.Flags().IntVar(&colsFlag, "c", 3600, "")
 	c.Flags().IntVar(&equator, "equator", 360, "")
@@ -93,6 +116,8 @@ func setFlags(c *command.Command) {
 	c.Flags().StringVar(&bgFile, "bg", "", "")
 	c.Flags().StringVar(&boxFlag, "box", "", "")
 	c.Flags().StringVar(&maskFile, "mask", "", "")
+	c.Flags().IntVar(&qualityFlag, "quality", jpeg.DefaultQuality, "")
+	c.Flags().StringVar(&formatFlag, "format", "", "")
 	c.Flags().StringVar(&output, "output", "", "")
 	c.Flags().StringVar(&output, "o", "", "")
 }
@@ -101,18 +126,25 @@ func run(c *command.Command, args []string) error {
 	if output == "" {
 		return c.UsageError("expecting output image file name, flag --output")
 	}
+	format, err := imgfmt.Format(output, formatFlag)
+	if err != nil {
+		return c.UsageError(err.Error())
+	}
+	if err := imgfmt.CheckQuality(qualityFlag); err != nil {
+		return c.UsageError(fmt.Sprintf("flag --quality: %v", err))
+	}
 
 	if colsFlag%2 != 0 {
 		colsFlag++
 	}
 
-	var boxMask *box
+	var boxMask *earth.Box
 	if boxFlag != "" {
-		var err error
-		boxMask, err = getBox()
+		b, err := earth.ParseBox(boxFlag)
 		if err != nil {
-			return err
+			return fmt.Errorf("flag --box: %v", err)
 		}
+		boxMask = &b
 	}
 
 	var maskImage image.Image
@@ -134,7 +166,13 @@ func run(c *command.Command, args []string) error {
 		img = makeBgImage(pix, bg, maskImage, boxMask)
 	} else {
 		img = makeRndImage(pix, maskImage, boxMask)
+		if wireFlag {
+			for id := range img.color {
+				img.color[id] = color.RGBA{255, 255, 255, 255}
+			}
+		}
 	}
+	img.wire = wireFlag
 
 	if pixFlag {
 		ids, err := inPixels(c.Stdin(), pix.Len())
@@ -156,73 +194,84 @@ func run(c *command.Command, args []string) error {
 		}
 	}
 	if randFlag > 0 {
-		for i := 0; i < randFlag; i++ {
-			id := pix.Random().ID()
+		for _, id := range pix.Sample(randFlag, nil) {
 			img.set(id, color.RGBA{255, 0, 0, 255})
 		}
 	}
 
-	if err := writeImage(output, img); err != nil {
+	if err := writeImage(output, img, format); err != nil {
 		return err
 	}
 	return nil
 }
 
 type mapImg struct {
-	step  float64
 	color map[int]color.RGBA
 	pix   *earth.Pixelation
+
+	// if true,
+	// draw the pixel boundaries
+	wire bool
 }
 
 func (m *mapImg) ColorModel() color.Model { return color.RGBAModel }
 func (m *mapImg) Bounds() image.Rectangle { return image.Rect(0, 0, colsFlag, colsFlag/2) }
 func (m *mapImg) At(x, y int) color.Color {
-	lat := 90 - float64(y)*m.step
-	lon := float64(x)*m.step - 180
+	lat, lon := earth.PlateCarreeLatLon(x, y, colsFlag, colsFlag/2)
 
 	pos := m.pix.Pixel(lat, lon).ID()
 	c, ok := m.color[pos]
 	if !ok {
 		return color.RGBA{0, 0, 0, 0}
 	}
+	if m.wire && m.isBoundary(x, y, pos) {
+		return color.RGBA{0, 0, 0, 255}
+	}
 	return c
 }
 
+// IsBoundary returns true if an image point
+// is at the boundary of a pixel,
+// i.e. the image point to its right,
+// or the one below it,
+// is in a different pixel.
+func (m *mapImg) isBoundary(x, y, pos int) bool {
+	lat, lon := earth.PlateCarreeLatLon((x+1)%colsFlag, y, colsFlag, colsFlag/2)
+	if m.pix.Pixel(lat, lon).ID() != pos {
+		return true
+	}
+	if y+1 >= colsFlag/2 {
+		return false
+	}
+	lat, lon = earth.PlateCarreeLatLon(x, y+1, colsFlag, colsFlag/2)
+	return m.pix.Pixel(lat, lon).ID() != pos
+}
+
 func (m *mapImg) set(px int, c color.RGBA) {
 	m.color[px] = c
 }
 
-func makeBgImage(pix *earth.Pixelation, bg, mask image.Image, boxMask *box) *mapImg {
+func makeBgImage(pix *earth.Pixelation, bg, mask image.Image, boxMask *earth.Box) *mapImg {
 	img := &mapImg{
-		step:  360 / float64(colsFlag),
 		color: make(map[int]color.RGBA, pix.Len()),
 		pix:   pix,
 	}
 
-	stepX := float64(360) / float64(bg.Bounds().Dx())
-	stepY := float64(180) / float64(bg.Bounds().Dy())
-	var maskX, maskY float64
-	if mask != nil {
-		maskX = float64(360) / float64(mask.Bounds().Dx())
-		maskY = float64(180) / float64(mask.Bounds().Dy())
-	}
 	for id := 0; id < pix.Len(); id++ {
 		px := pix.ID(id).Point()
 		if boxMask != nil {
-			if !boxMask.isInside(px.Latitude(), px.Longitude()) {
+			if !boxMask.Contains(px.Latitude(), px.Longitude()) {
 				continue
 			}
 		}
 		if mask != nil {
-			x := int((px.Longitude() + 180) / maskX)
-			y := int((90 - px.Latitude()) / maskY)
+			x, y := earth.PlateCarreeXY(px.Latitude(), px.Longitude(), mask.Bounds().Dx(), mask.Bounds().Dy())
 			r, _, _, a := mask.At(x, y).RGBA()
 			if (a>>8) < 200 || (r>>8) < 200 {
 				continue
 			}
 		}
-		x := int((px.Longitude() + 180) / stepX)
-		y := int((90 - px.Latitude()) / stepY)
+		x, y := earth.PlateCarreeXY(px.Latitude(), px.Longitude(), bg.Bounds().Dx(), bg.Bounds().Dy())
 
 		r, g, b, a := bg.At(x, y).RGBA()
 		c := color.RGBA{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8), uint8(a >> 8)}
@@ -232,29 +281,22 @@ func makeBgImage(pix *earth.Pixelation, bg, mask image.Image, boxMask *box) *map
 	return img
 }
 
-func makeRndImage(pix *earth.Pixelation, mask image.Image, boxMask *box) *mapImg {
+func makeRndImage(pix *earth.Pixelation, mask image.Image, boxMask *earth.Box) *mapImg {
 	img := &mapImg{
-		step:  360 / float64(colsFlag),
 		color: make(map[int]color.RGBA, pix.Len()),
 		pix:   pix,
 	}
 
-	var maskX, maskY float64
-	if mask != nil {
-		maskX = float64(360) / float64(mask.Bounds().Dx())
-		maskY = float64(180) / float64(mask.Bounds().Dy())
-	}
 	for id := 0; id < pix.Len(); id++ {
 		px := pix.ID(id).Point()
 		if boxMask != nil {
-			if !boxMask.isInside(px.Latitude(), px.Longitude()) {
+			if !boxMask.Contains(px.Latitude(), px.Longitude()) {
 				continue
 			}
 		}
 
 		if mask != nil {
-			x := int((px.Longitude() + 180) / maskX)
-			y := int((90 - px.Latitude()) / maskY)
+			x, y := earth.PlateCarreeXY(px.Latitude(), px.Longitude(), mask.Bounds().Dx(), mask.Bounds().Dy())
 			r, _, _, a := mask.At(x, y).RGBA()
 			if (a>>8) < 200 || (r>>8) < 200 {
 				continue
@@ -284,7 +326,7 @@ func readImage(name string) (image.Image, error) {
 	return img, nil
 }
 
-func writeImage(name string, img *mapImg) (err error) {
+func writeImage(name string, img *mapImg, format string) (err error) {
 	f, err := os.Create(name)
 	if err != nil {
 		return err
@@ -296,7 +338,7 @@ func writeImage(name string, img *mapImg) (err error) {
 		}
 	}()
 
-	if err := png.Encode(f, img); err != nil {
+	if err := imgfmt.Encode(f, img, format, qualityFlag); err != nil {
 		return fmt.Errorf("when encoding image file %q: %v", name, err)
 	}
 	return nil
@@ -382,73 +424,3 @@ func readPixID(s string, max int) (int, error) {
 	}
 	return v, nil
 }
-
-type box struct {
-	p1 earth.Point
-	p2 earth.Point
-}
-
-func getBox() (*box, error) {
-	cs := strings.Split(boxFlag, ",")
-	if len(cs) != 4 {
-		return nil, fmt.Errorf("invalid --box value %q", boxFlag)
-	}
-
-	p1, err := parsePoint(cs[0], cs[1])
-	if err != nil {
-		return nil, err
-	}
-	p2, err := parsePoint(cs[2], cs[3])
-	if err != nil {
-		return nil, err
-	}
-	if p1.Latitude() < p2.Latitude() {
-		p1, p2 = earth.NewPoint(p2.Latitude(), p1.Longitude()), earth.NewPoint(p1.Latitude(), p2.Longitude())
-	}
-	if p1.Longitude() > p2.Longitude() {
-		p1, p2 = earth.NewPoint(p1.Latitude(), p2.Longitude()), earth.NewPoint(p2.Latitude(), p1.Longitude())
-	}
-
-	return &box{
-		p1: p1,
-		p2: p2,
-	}, nil
-}
-
-func (b *box) isInside(lat, lon float64) bool {
-	if lat > b.p1.Latitude() {
-		return false
-	}
-	if lat < b.p2.Latitude() {
-		return false
-	}
-
-	if lon < b.p1.Longitude() {
-		return false
-	}
-	if lon > b.p2.Longitude() {
-		return false
-	}
-
-	return true
-}
-
-func parsePoint(c1, c2 string) (earth.Point, error) {
-	lat, err := strconv.ParseFloat(c1, 64)
-	if err != nil {
-		return earth.Point{}, fmt.Errorf("invalid latitude: %v: read %q", err, c1)
-	}
-	if lat < -90 || lat > 90 {
-		return earth.Point{}, fmt.Errorf("invalid latitude: %.6f", lat)
-	}
-
-	lon, err := strconv.ParseFloat(c2, 64)
-	if err != nil {
-		return earth.Point{}, fmt.Errorf("invalid longitude: %v: read %q", err, c2)
-	}
-	if lon < -180 || lon > 180 {
-		return earth.Point{}, fmt.Errorf("invalid longitude: %.6f", lon)
-	}
-
-	return earth.NewPoint(lat, lon), nil
-}
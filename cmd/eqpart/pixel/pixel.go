@@ -20,7 +20,8 @@ import (
 )
 
 var Command = &command.Command{
-	Usage: "pixel [-e|--equator <value>] [--id] [<value>...]",
+	Usage: `pixel [-e|--equator <value>] [--id] [--precision <value>]
+	[<value>...]`,
 	Short: "get pixel location",
 	Long: `
 Command pixel retrieves a pixel location in a pixelation based on an equal
@@ -40,6 +41,10 @@ geographic coordinates of the central point of the pixel will be retrieved.
 
 By default the pixelation will be of 360 pixels at the equator. Use the flag
 --equator, or -e, to define a different pixelation.
+
+By default the coordinates are printed with 6 decimal places. Use the flag
+--precision to define a different number of decimal places (between 0 and
+15).
 	`,
 	SetFlags: setFlags,
 	Run:      run,
@@ -47,14 +52,24 @@ By default the pixelation will be of 360 pixels at the equator. Use the flag
 
 var equator int
 var idFlag bool
+var precision int
 
 func setFlags(c *command.Command) {
 	c.Flags().BoolVar(&idFlag, "id", false, "")
 	c.Flags().IntVar(&equator, "equator", 360, "")
 	c.Flags().IntVar(&equator, "e", 360, "")
+	c.Flags().IntVar(&precision, "precision", 6, "")
 }
 
+// MaxPrecision is the maximum number of decimal places
+// of the coordinates.
+const maxPrecision = 15
+
 func run(c *command.Command, args []string) error {
+	if precision < 0 || precision > maxPrecision {
+		return c.UsageError(fmt.Sprintf("invalid value for --precision flag: %d", precision))
+	}
+
 	pix := earth.NewPixelation(equator)
 	if idFlag {
 		var ids []int
@@ -78,7 +93,7 @@ func run(c *command.Command, args []string) error {
 		fmt.Fprintf(c.Stdout(), "pixel\tlat\tlon\n")
 		for _, id := range ids {
 			pt := pix.ID(id).Point()
-			fmt.Fprintf(c.Stdout(), "%d\t%.6f\t%.6f\n", id, pt.Latitude(), pt.Longitude())
+			fmt.Fprintf(c.Stdout(), "%d\t%.*f\t%.*f\n", id, precision, pt.Latitude(), precision, pt.Longitude())
 		}
 		return nil
 	}
@@ -106,7 +121,7 @@ func run(c *command.Command, args []string) error {
 	fmt.Fprintf(c.Stdout(), "lat\tlon\tpixel\n")
 	for _, pt := range pts {
 		id := pix.Pixel(pt.Lat, pt.Lon).ID()
-		fmt.Fprintf(c.Stdout(), "%.6f\t%.6f\t%d\n", pt.Lat, pt.Lon, id)
+		fmt.Fprintf(c.Stdout(), "%.*f\t%.*f\t%d\n", precision, pt.Lat, precision, pt.Lon, id)
 	}
 
 	return nil
@@ -0,0 +1,75 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package quality implements a command to report
+// how close is a pixelation
+// to an equal area partitioning.
+package quality
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/earth"
+)
+
+var Command = &command.Command{
+	Usage: "quality [-e|--equator <value>]",
+	Short: "report the pixel area error of a pixelation",
+	Long: `
+Command quality prints a report of how close a pixelation is to an equal area
+partitioning of the sphere.
+
+The report includes the number of pixels in the pixelation, the ideal number
+of pixels (i.e., the number of square pixels with the size of a pixel at the
+equator that cover the sphere), and the deviation of the number of pixels from
+the ideal. It also prints the minimum, maximum, and mean pixel area (in square
+kilometers) across the rings of the pixelation, and the ratio between the
+maximum and minimum area.
+
+By default the pixelation will be of 360 pixels at the equator. Use the flag
+--equator, or -e, to define a different pixelation.
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var equator int
+
+func setFlags(c *command.Command) {
+	c.Flags().IntVar(&equator, "equator", 360, "")
+	c.Flags().IntVar(&equator, "e", 360, "")
+}
+
+func run(c *command.Command, args []string) error {
+	pix := earth.NewPixelation(equator)
+
+	r := float64(pix.Equator()) / (2 * math.Pi)
+	ideal := 4 * math.Pi * r * r
+	dev := (float64(pix.Len()) - ideal) / ideal
+
+	minArea := math.MaxFloat64
+	maxArea := 0.0
+	for i := 0; i < pix.Rings(); i++ {
+		a := pix.PixelArea(i)
+		minArea = math.Min(minArea, a)
+		maxArea = math.Max(maxArea, a)
+	}
+	mean := 4 * math.Pi / float64(pix.Len())
+
+	// area in square kilometers
+	km := float64(earth.Radius) / 1000
+	km2 := km * km
+
+	fmt.Fprintf(c.Stdout(), "equator: %d\n", pix.Equator())
+	fmt.Fprintf(c.Stdout(), "pixels: %d\n", pix.Len())
+	fmt.Fprintf(c.Stdout(), "ideal pixels: %.2f\n", ideal)
+	fmt.Fprintf(c.Stdout(), "deviation: %.4f%%\n", dev*100)
+	fmt.Fprintf(c.Stdout(), "min pixel area: %.3f km2\n", minArea*km2)
+	fmt.Fprintf(c.Stdout(), "max pixel area: %.3f km2\n", maxArea*km2)
+	fmt.Fprintf(c.Stdout(), "mean pixel area: %.3f km2\n", mean*km2)
+	fmt.Fprintf(c.Stdout(), "max/min ratio: %.6f\n", maxArea/minArea)
+	return nil
+}
@@ -0,0 +1,177 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package thin implements a command to thin a set of points
+// to a single point per pixel
+// in a pixelation based on an equal area partitioning.
+package thin
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/earth"
+	"github.com/js-arias/earth/vector"
+)
+
+var Command = &command.Command{
+	Usage: "thin [-e|--equator <value>] [--center] [<point-file>...]",
+	Short: "keep a single point per pixel",
+	Long: `
+Command thin reads a set of geographic points and keeps at most one point per
+pixel in a pixelation based on an equal area partitioning of a sphere.
+
+One or more input files can be given as arguments. If no file is given, the
+points will be read from the standard input. Points are read one per line,
+first the latitude and then the longitude, separated by one or more spaces.
+Lines starting with '#' will be ignored.
+
+By default, the first point found in a pixel will be kept. If the flag
+--center is defined, the point closest to the center of the pixel will be
+kept.
+
+The kept points will be printed in the standard output, in the same order as
+in the input, with the ID of the pixel. The number of dropped points will be
+reported in the standard error.
+
+By default the pixelation will be of 360 pixels at the equator. Use the flag
+--equator, or -e, to define a different pixelation.
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var equator int
+var centerFlag bool
+
+func setFlags(c *command.Command) {
+	c.Flags().BoolVar(&centerFlag, "center", false, "")
+	c.Flags().IntVar(&equator, "equator", 360, "")
+	c.Flags().IntVar(&equator, "e", 360, "")
+}
+
+func run(c *command.Command, args []string) error {
+	if len(args) == 0 {
+		args = append(args, "-")
+	}
+
+	var pts []vector.Point
+	for _, a := range args {
+		p, err := readPoints(c.Stdin(), a)
+		if err != nil {
+			return err
+		}
+		pts = append(pts, p...)
+	}
+
+	pix := earth.NewPixelation(equator)
+	kept := thin(pix, pts)
+
+	fmt.Fprintf(c.Stdout(), "lat\tlon\tpixel\n")
+	for _, i := range kept {
+		pt := pts[i]
+		id := pix.Pixel(pt.Lat, pt.Lon).ID()
+		fmt.Fprintf(c.Stdout(), "%.6f\t%.6f\t%d\n", pt.Lat, pt.Lon, id)
+	}
+	fmt.Fprintf(c.Stderr(), "points: %d, kept: %d, dropped: %d\n", len(pts), len(kept), len(pts)-len(kept))
+	return nil
+}
+
+// Thin returns the indexes of the kept points
+// in input order.
+func thin(pix *earth.Pixelation, pts []vector.Point) []int {
+	// the index of the point kept
+	// for each pixel
+	rep := make(map[int]int)
+	for i, pt := range pts {
+		px := pix.Pixel(pt.Lat, pt.Lon)
+		j, ok := rep[px.ID()]
+		if !ok {
+			rep[px.ID()] = i
+			continue
+		}
+		if !centerFlag {
+			continue
+		}
+
+		c := px.Point()
+		old := earth.NewPoint(pts[j].Lat, pts[j].Lon)
+		p := earth.NewPoint(pt.Lat, pt.Lon)
+		if earth.Distance(c, p) < earth.Distance(c, old) {
+			rep[px.ID()] = i
+		}
+	}
+
+	keep := make([]bool, len(pts))
+	for _, i := range rep {
+		keep[i] = true
+	}
+	kept := make([]int, 0, len(rep))
+	for i, k := range keep {
+		if k {
+			kept = append(kept, i)
+		}
+	}
+	return kept
+}
+
+func readPoints(r io.Reader, name string) ([]vector.Point, error) {
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	} else {
+		name = "stdin"
+	}
+
+	pts, err := inLatLon(r)
+	if err != nil {
+		return nil, fmt.Errorf("while reading from %q: %v", name, err)
+	}
+	return pts, nil
+}
+
+func inLatLon(in io.Reader) ([]vector.Point, error) {
+	var pts []vector.Point
+
+	r := bufio.NewReader(in)
+	for i := 1; ; i++ {
+		ln, err := r.ReadString('\n')
+		if ln == "" && err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("at line %d: %v", i, err)
+		}
+
+		if ln == "" {
+			continue
+		}
+		if ln[0] == '#' {
+			continue
+		}
+		ln = strings.TrimSpace(ln)
+		if ln == "" {
+			continue
+		}
+		v := strings.Fields(ln)
+		if len(v) < 2 {
+			return nil, fmt.Errorf("at line %d: invalid value %q: expecting \"lat lon\"", i, ln)
+		}
+		pt, err := vector.ParsePoint(v[0], v[1])
+		if err != nil {
+			return nil, fmt.Errorf("at line %d: %v", i, err)
+		}
+		pts = append(pts, pt)
+	}
+	return pts, nil
+}
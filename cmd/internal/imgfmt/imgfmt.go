@@ -0,0 +1,76 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package imgfmt implements the selection
+// and encoding of the image formats
+// used by the map commands.
+package imgfmt
+
+import (
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// Format returns the format of an output image
+// ("png" or "jpeg").
+// If format is empty,
+// the format will be inferred
+// from the extension of the file name,
+// and by default it will be "png".
+func Format(name, format string) (string, error) {
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(name)) {
+		case ".jpg", ".jpeg":
+			return "jpeg", nil
+		}
+		return "png", nil
+	}
+
+	switch strings.ToLower(format) {
+	case "png":
+		return "png", nil
+	case "jpeg", "jpg":
+		return "jpeg", nil
+	}
+	return "", fmt.Errorf("unknown image format %q", format)
+}
+
+// Prefix returns the file name
+// without the extension of an image file
+// (".png", ".jpg", or ".jpeg").
+// Other extensions are preserved.
+func Prefix(name string) string {
+	ext := filepath.Ext(name)
+	switch strings.ToLower(ext) {
+	case ".png", ".jpg", ".jpeg":
+		return strings.TrimSuffix(name, ext)
+	}
+	return name
+}
+
+// CheckQuality returns an error
+// if a JPEG quality value
+// is outside the valid range
+// (from 1 to 100).
+func CheckQuality(quality int) error {
+	if quality < 1 || quality > 100 {
+		return fmt.Errorf("invalid JPEG quality %d, want a value from 1 to 100", quality)
+	}
+	return nil
+}
+
+// Encode writes an image
+// in the indicated format.
+// The quality is only used for JPEG images.
+func Encode(w io.Writer, img image.Image, format string, quality int) error {
+	if format == "jpeg" {
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
+	}
+	return png.Encode(w, img)
+}
@@ -0,0 +1,66 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package imgfmt_test
+
+import (
+	"testing"
+
+	"github.com/js-arias/earth/cmd/internal/imgfmt"
+)
+
+func TestFormat(t *testing.T) {
+	tests := map[string]struct {
+		name   string
+		format string
+		want   string
+		err    bool
+	}{
+		"png":           {"map.png", "", "png", false},
+		"jpeg":          {"map.JPG", "", "jpeg", false},
+		"no extension":  {"map", "", "png", false},
+		"other":         {"map.tif", "", "png", false},
+		"flag":          {"map.png", "jpg", "jpeg", false},
+		"unknown":       {"map.png", "gif", "", true},
+		"flag png":      {"map.jpeg", "PNG", "png", false},
+		"flag and name": {"map", "jpeg", "jpeg", false},
+	}
+	for name, test := range tests {
+		got, err := imgfmt.Format(test.name, test.format)
+		if (err != nil) != test.err {
+			t.Errorf("%s: got error %v, want error %v", name, err, test.err)
+		}
+		if got != test.want {
+			t.Errorf("%s: got %q, want %q", name, got, test.want)
+		}
+	}
+}
+
+func TestPrefix(t *testing.T) {
+	tests := map[string]string{
+		"map.png":      "map",
+		"dir/map.JPEG": "dir/map",
+		"map.jpg":      "map",
+		"map":          "map",
+		"map.v2":       "map.v2",
+	}
+	for name, want := range tests {
+		if got := imgfmt.Prefix(name); got != want {
+			t.Errorf("%s: got %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestCheckQuality(t *testing.T) {
+	for _, q := range []int{1, 75, 100} {
+		if err := imgfmt.CheckQuality(q); err != nil {
+			t.Errorf("quality %d: unexpected error: %v", q, err)
+		}
+	}
+	for _, q := range []int{-1, 0, 101} {
+		if err := imgfmt.CheckQuality(q); err == nil {
+			t.Errorf("quality %d: expecting error", q)
+		}
+	}
+}
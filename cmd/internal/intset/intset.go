@@ -0,0 +1,47 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package intset implements a set of integers
+// (for example plate IDs, or pixel values)
+// that can be used as a repeatable command flag.
+package intset
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// A Set is a set of integers.
+// It implements the flag.Value interface,
+// so each time the flag is used
+// a value is added to the set.
+type Set map[int]bool
+
+// String returns the values of the set
+// sorted and separated by commas.
+func (s Set) String() string {
+	vs := make([]int, 0, len(s))
+	for v := range s {
+		vs = append(vs, v)
+	}
+	slices.Sort(vs)
+
+	str := make([]string, 0, len(vs))
+	for _, v := range vs {
+		str = append(str, strconv.Itoa(v))
+	}
+	return strings.Join(str, ",")
+}
+
+// Set adds a value to the set.
+func (s Set) Set(v string) error {
+	n, err := strconv.Atoi(strings.TrimSpace(v))
+	if err != nil {
+		return fmt.Errorf("invalid integer value %q: %v", v, err)
+	}
+	s[n] = true
+	return nil
+}
@@ -0,0 +1,34 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package intset_test
+
+import (
+	"flag"
+	"io"
+	"testing"
+
+	"github.com/js-arias/earth/cmd/internal/intset"
+)
+
+func TestSet(t *testing.T) {
+	s := intset.Set{}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.Var(s, "id", "")
+
+	if err := fs.Parse([]string{"--id", "801", "--id", "201", "--id", "801"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(s) != 2 || !s[201] || !s[801] {
+		t.Errorf("got %v, want %v", s, "201,801")
+	}
+	if got := s.String(); got != "201,801" {
+		t.Errorf("string: got %q, want %q", got, "201,801")
+	}
+
+	if err := fs.Parse([]string{"--id", "plate"}); err == nil {
+		t.Errorf("expecting error for a non integer value")
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package checkrot implements a command to check
+// that the plates of a pixelation
+// have a rotation in a rotation model.
+package checkrot
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/earth/model"
+	"github.com/js-arias/earth/rotation"
+)
+
+var Command = &command.Command{
+	Usage: "check-rotations --pix <pix-file> --rot <rotation-file>",
+	Short: "check plates without rotations",
+	Long: `
+Command check-rotations reads a pixelated plates file and a rotation model, and
+reports the plates of the pixelation that are not defined in the rotation
+model (so they will stay at their present day location), and the plates of
+the rotation model without pixels in the pixelation.
+
+The flag --pix is required and indicates the file with the pixelated plates.
+
+The flag --rot is required and indicates the file with the rotation model.
+
+The output is written in the standard output. If there are plates of the
+pixelation without rotations, the command will exit with an error.
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var pixFile string
+var rotFile string
+
+func setFlags(c *command.Command) {
+	c.Flags().StringVar(&pixFile, "pix", "", "")
+	c.Flags().StringVar(&rotFile, "rot", "", "")
+}
+
+func run(c *command.Command, args []string) error {
+	if pixFile == "" {
+		return c.UsageError("flag --pix undefined")
+	}
+	if rotFile == "" {
+		return c.UsageError("flag --rot undefined")
+	}
+
+	pp, err := readPixPlate(pixFile)
+	if err != nil {
+		return err
+	}
+	rot, err := readRotation(rotFile)
+	if err != nil {
+		return err
+	}
+
+	pixPlates := pp.Plates()
+	rotPlates := rot.Plates()
+
+	var noRot []int
+	for _, p := range pixPlates {
+		if _, ok := slices.BinarySearch(rotPlates, p); !ok {
+			noRot = append(noRot, p)
+		}
+	}
+	var noPix []int
+	for _, p := range rotPlates {
+		if _, ok := slices.BinarySearch(pixPlates, p); !ok {
+			noPix = append(noPix, p)
+		}
+	}
+
+	printPlates(c.Stdout(), "plates without rotation", noRot)
+	printPlates(c.Stdout(), "rotations without pixels", noPix)
+
+	if len(noRot) > 0 {
+		return fmt.Errorf("%d plates without rotation", len(noRot))
+	}
+	return nil
+}
+
+func printPlates(w io.Writer, title string, plates []int) {
+	fmt.Fprintf(w, "# %s: %d\n", title, len(plates))
+	for _, p := range plates {
+		fmt.Fprintf(w, "%d\n", p)
+	}
+}
+
+func readPixPlate(name string) (*model.PixPlate, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	pp, err := model.ReadPixPlate(f, nil)
+	if err != nil {
+		return nil, fmt.Errorf("when reading file %q: %v", name, err)
+	}
+	return pp, nil
+}
+
+func readRotation(name string) (rotation.Rotation, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return rotation.Rotation{}, err
+	}
+	defer f.Close()
+
+	rot, err := rotation.Read(f)
+	if err != nil {
+		return rotation.Rotation{}, fmt.Errorf("when reading file %q: %v", name, err)
+	}
+	return rot, nil
+}
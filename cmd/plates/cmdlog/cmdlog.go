@@ -0,0 +1,67 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package cmdlog implements the logger
+// used by the plates commands
+// to report the steps of a command.
+//
+// By default,
+// the logger writes human-readable messages,
+// one per line,
+// and only for events that are relevant for the user
+// (i.e. at the info level or above).
+// In JSON mode,
+// each event is written as a JSON object,
+// one per line,
+// including the events at the debug level
+// (e.g. files read and written).
+package cmdlog
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// New returns a new logger
+// that writes on w
+// using the given format.
+// Valid formats are "text"
+// (or an empty string)
+// and "json".
+func New(w io.Writer, format string) (*slog.Logger, error) {
+	switch strings.ToLower(format) {
+	case "", "text":
+		return slog.New(&textHandler{mu: &sync.Mutex{}, w: w}), nil
+	case "json":
+		h := slog.NewJSONHandler(w, &slog.HandlerOptions{Level: slog.LevelDebug})
+		return slog.New(h), nil
+	}
+	return nil, fmt.Errorf("unknown log format %q", format)
+}
+
+// A textHandler is a slog handler
+// that only writes the message of a record.
+type textHandler struct {
+	mu *sync.Mutex
+	w  io.Writer
+}
+
+func (h *textHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= slog.LevelInfo
+}
+
+func (h *textHandler) Handle(_ context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	_, err := fmt.Fprintln(h.w, r.Message)
+	return err
+}
+
+func (h *textHandler) WithAttrs(_ []slog.Attr) slog.Handler { return h }
+func (h *textHandler) WithGroup(_ string) slog.Handler      { return h }
@@ -0,0 +1,67 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package cmdlog_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/js-arias/earth/cmd/plates/cmdlog"
+)
+
+func TestText(t *testing.T) {
+	var buf bytes.Buffer
+	log, err := cmdlog.New(&buf, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	log.Debug("read", "file", "model.tab")
+	log.Info("plate 801: pixels without rotation at 100.000000 Ma: 3", "event", "no-rotation", "plate", 801)
+
+	want := "plate 801: pixels without rotation at 100.000000 Ma: 3\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	var buf bytes.Buffer
+	log, err := cmdlog.New(&buf, "json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	log.Debug("read", "file", "model.tab")
+	log.Info("plate 801: pixels without rotation at 100.000000 Ma: 3", "event", "no-rotation", "plate", 801)
+
+	var events []map[string]any
+	sc := bufio.NewScanner(&buf)
+	for sc.Scan() {
+		var e map[string]any
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			t.Fatalf("invalid JSON %q: %v", sc.Text(), err)
+		}
+		events = append(events, e)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want %d", len(events), 2)
+	}
+	if events[0]["msg"] != "read" || events[0]["file"] != "model.tab" {
+		t.Errorf("event 0: got %v", events[0])
+	}
+	if events[1]["event"] != "no-rotation" || events[1]["plate"] != 801.0 {
+		t.Errorf("event 1: got %v", events[1])
+	}
+}
+
+func TestUnknownFormat(t *testing.T) {
+	if _, err := cmdlog.New(&bytes.Buffer{}, "xml"); err == nil {
+		t.Errorf("format xml: expecting error")
+	}
+}
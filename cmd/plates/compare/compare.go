@@ -0,0 +1,195 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package compare implements a command to compare
+// the pixels occupied by two plate motion models.
+package compare
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/earth"
+	"github.com/js-arias/earth/model"
+)
+
+var Command = &command.Command{
+	Usage: "compare [--timepix] <model-file> <model-file>",
+	Short: "compare the occupied pixels of two models",
+	Long: `
+Command compare reads two plate motion models and prints, for each time stage
+of the first model, the Jaccard similarity (i.e., the intersection over the
+union) of the pixels occupied by any plate in both models.
+
+The arguments of the command are the names of the files with the models to be
+compared. Both models must have the same pixelation.
+
+If the flag --timepix is defined, the files will be read as time pixelations,
+and the occupied pixels will be the pixels with a non-zero value.
+
+If a time stage of the first model is not defined in the second model, the
+closest stage of the second model (i.e., the oldest stage younger than the
+stage of the first model) will be used. Stages younger than the youngest stage
+of the second model will be ignored.
+
+The output is a tab-delimited table with the following columns:
+
+	- age:          the age of the stage in the first model (in million
+	                years)
+	- compared:     the age of the stage in the second model (in million
+	                years)
+	- intersection: the number of pixels occupied in both models
+	- union:        the number of pixels occupied in any model
+	- jaccard:      the Jaccard similarity index
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var timePixFlag bool
+
+func setFlags(c *command.Command) {
+	c.Flags().BoolVar(&timePixFlag, "timepix", false, "")
+}
+
+// MillionYears is used to transform ages
+// an integer in years
+// to a float in million years.
+const millionYears = 1_000_000
+
+// An occupier is a model
+// in which pixels can be occupied
+// at a time stage.
+type occupier interface {
+	ClosestStageAge(age int64) int64
+	FirstStage() (int64, bool)
+	Stages() []int64
+	occupied(age int64) map[int]bool
+}
+
+func run(c *command.Command, args []string) error {
+	if len(args) < 2 {
+		return c.UsageError("expecting two model files")
+	}
+
+	var m1, m2 occupier
+	var err error
+	if timePixFlag {
+		var tp *model.TimePix
+		tp, err = readTimePix(args[0], nil)
+		if err != nil {
+			return err
+		}
+		m1 = timePix{tp}
+		tp, err = readTimePix(args[1], tp.Pixelation())
+		if err != nil {
+			return err
+		}
+		m2 = timePix{tp}
+	} else {
+		var rec *model.Recons
+		rec, err = readRecons(args[0], nil)
+		if err != nil {
+			return err
+		}
+		m1 = recons{rec}
+		rec, err = readRecons(args[1], rec.Pixelation())
+		if err != nil {
+			return err
+		}
+		m2 = recons{rec}
+	}
+
+	first, ok := m2.FirstStage()
+	if !ok {
+		return fmt.Errorf("model %q: no stages defined", args[1])
+	}
+
+	fmt.Fprintf(c.Stdout(), "age\tcompared\tintersection\tunion\tjaccard\n")
+	for _, a := range m1.Stages() {
+		if a < first {
+			continue
+		}
+		b := m2.ClosestStageAge(a)
+
+		o1 := m1.occupied(a)
+		o2 := m2.occupied(b)
+
+		var inter int
+		union := len(o2)
+		for px := range o1 {
+			if o2[px] {
+				inter++
+				continue
+			}
+			union++
+		}
+		var j float64
+		if union > 0 {
+			j = float64(inter) / float64(union)
+		}
+		fmt.Fprintf(c.Stdout(), "%.6f\t%.6f\t%d\t%d\t%.6f\n", float64(a)/millionYears, float64(b)/millionYears, inter, union, j)
+	}
+	return nil
+}
+
+type recons struct {
+	*model.Recons
+}
+
+func (r recons) occupied(age int64) map[int]bool {
+	occ := make(map[int]bool)
+	for _, p := range r.Plates() {
+		for _, ids := range r.PixStage(p, age) {
+			for _, id := range ids {
+				occ[id] = true
+			}
+		}
+	}
+	return occ
+}
+
+type timePix struct {
+	*model.TimePix
+}
+
+func (tp timePix) occupied(age int64) map[int]bool {
+	occ := make(map[int]bool)
+	for id, v := range tp.Stage(age) {
+		if v == 0 {
+			continue
+		}
+		occ[id] = true
+	}
+	return occ
+}
+
+func readRecons(name string, pix *earth.Pixelation) (*model.Recons, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	rec, err := model.ReadReconsTSV(f, pix)
+	if err != nil {
+		return nil, fmt.Errorf("when reading file %q: %v", name, err)
+	}
+	return rec, nil
+}
+
+func readTimePix(name string, pix *earth.Pixelation) (*model.TimePix, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	tp, err := model.ReadTimePix(f, pix)
+	if err != nil {
+		return nil, fmt.Errorf("when reading file %q: %v", name, err)
+	}
+	return tp, nil
+}
@@ -0,0 +1,92 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package compare_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/js-arias/earth/cmd/plates/compare"
+)
+
+// writeFile writes a model file
+// in a temporary directory
+// and returns the name of the file.
+func writeFile(t testing.TB, name, data string) string {
+	t.Helper()
+
+	name = filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(name, []byte(data), 0o644); err != nil {
+		t.Fatalf("unable to write %q: %v", name, err)
+	}
+	return name
+}
+
+func TestCompareRecons(t *testing.T) {
+	m1 := writeFile(t, "m1.tab", `equator	plate	pixel	age	stage-pixel
+360	1	100	0	100
+360	1	101	0	101
+360	1	100	50000000	150
+360	1	100	100000000	200
+360	1	101	100000000	201
+360	2	102	100000000	202
+`)
+	m2 := writeFile(t, "m2.tab", `equator	plate	pixel	age	stage-pixel
+360	1	100	10000000	100
+360	1	100	80000000	201
+360	1	101	80000000	202
+360	2	102	80000000	203
+360	2	103	80000000	204
+`)
+
+	var stdout bytes.Buffer
+	compare.Command.SetStdout(&stdout)
+	if err := compare.Command.Execute([]string{m1, m2}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the stage at 0 is younger than all stages of the second model,
+	// the stage at 50 is compared with the stage at 10
+	// (no shared pixels),
+	// and the stage at 100 is compared with the stage at 80
+	// (pixels 201 and 202 are shared,
+	// from pixels 200 to 204).
+	want := `age	compared	intersection	union	jaccard
+50.000000	10.000000	0	2	0.000000
+100.000000	80.000000	2	5	0.400000
+`
+	if got := stdout.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestCompareTimePix(t *testing.T) {
+	m1 := writeFile(t, "m1.tab", `equator	age	stage-pixel	value
+360	0	100	1
+360	0	101	2
+360	0	102	0
+`)
+	m2 := writeFile(t, "m2.tab", `equator	age	stage-pixel	value
+360	0	101	1
+360	0	102	3
+360	0	103	1
+`)
+
+	var stdout bytes.Buffer
+	compare.Command.SetStdout(&stdout)
+	if err := compare.Command.Execute([]string{"--timepix", m1, m2}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// pixels with value 0 are not occupied
+	want := `age	compared	intersection	union	jaccard
+0.000000	0.000000	1	4	0.250000
+`
+	if got := stdout.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
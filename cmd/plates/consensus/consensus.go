@@ -0,0 +1,164 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package consensus implements a command to draw a map
+// of the agreement between several plate motion models.
+package consensus
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+
+	"github.com/js-arias/blind"
+	"github.com/js-arias/command"
+	"github.com/js-arias/earth"
+	"github.com/js-arias/earth/model"
+)
+
+var Command = &command.Command{
+	Usage: `consensus [--at <age>] [-c|--columns <value>]
+	-o|--output <out-image-file> <model-file> <model-file>...`,
+	Short: "draw a map of the agreement between plate motion models",
+	Long: `
+Command consensus reads two or more plate motion models and draws a map, as a
+PNG image using a plate carrée projection, in which each pixel is colored by
+the number of models that place any plate in that pixel at a given age.
+
+The arguments of the command are the names of the files that contain the
+plate motion models. All the models must have the same pixelation (i.e. the
+same number of pixels at the equator).
+
+By default the map is drawn at the present time. Use the flag --at to define
+a different age (in million years). For each model, the age will be set to
+the closest time stage of the model (i.e. the oldest time stage younger than
+the age, or the youngest time stage, if the age is younger than all time
+stages of the model).
+
+The colors are taken from the Iridescent sequential color scheme of Paul Tol
+(<https://personal.sron.nl/~pault/>): the color of a pixel occupied by k of n
+models is the color at k/n of the scheme, so pixels occupied by a single model
+have the lighter colors, and pixels occupied by all models have the darker
+color. Pixels not occupied by any model are drawn in gray (153,153,153).
+
+The flag --output, or -o, is required and sets the name of the output image. By
+default the image will be 3600 pixels wide, use the flag --columns, or -c, to
+define a different number of image columns.
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var colsFlag int
+var atFlag float64
+var output string
+
+func setFlags(c *command.Command) {
+	c.Flags().IntVar(&colsFlag, "columns", 3600, "")
+	c.Flags().IntVar(&colsFlag, "c", 3600, "")
+	c.Flags().Float64Var(&atFlag, "at", 0, "")
+	c.Flags().StringVar(&output, "output", "", "")
+	c.Flags().StringVar(&output, "o", "", "")
+}
+
+// MillionYears is used to transform ages
+// (a float in million years)
+// to an integer in years.
+const millionYears = 1_000_000
+
+func run(c *command.Command, args []string) error {
+	if len(args) < 2 {
+		return c.UsageError("expecting two or more plate motion model files")
+	}
+	if output == "" {
+		return c.UsageError("undefined output image flag --output")
+	}
+	if atFlag < 0 {
+		return c.UsageError("invalid value for --at flag")
+	}
+
+	var pix *earth.Pixelation
+	recs := make([]*model.Recons, 0, len(args))
+	for _, a := range args {
+		rec, err := readRecons(a, pix)
+		if err != nil {
+			return err
+		}
+		pix = rec.Pixelation()
+		recs = append(recs, rec)
+	}
+
+	count, err := model.Consensus(int64(atFlag*millionYears), recs...)
+	if err != nil {
+		return err
+	}
+
+	if colsFlag%2 != 0 {
+		colsFlag++
+	}
+	img := countImg{
+		pix:    pix,
+		count:  count,
+		models: len(recs),
+	}
+	if err := writeImage(output, img); err != nil {
+		return err
+	}
+	return nil
+}
+
+func readRecons(name string, pix *earth.Pixelation) (*model.Recons, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	rec, err := model.ReadReconsTSV(f, pix)
+	if err != nil {
+		return nil, fmt.Errorf("when reading file %q: %v", name, err)
+	}
+	return rec, nil
+}
+
+// A countImg is an image
+// of the number of models
+// that occupy each pixel.
+type countImg struct {
+	pix    *earth.Pixelation
+	count  map[int]int
+	models int
+}
+
+func (ci countImg) ColorModel() color.Model { return color.RGBAModel }
+func (ci countImg) Bounds() image.Rectangle { return image.Rect(0, 0, colsFlag, colsFlag/2) }
+func (ci countImg) At(x, y int) color.Color {
+	lat, lon := earth.PlateCarreeLatLon(x, y, colsFlag, colsFlag/2)
+
+	n := ci.count[ci.pix.Pixel(lat, lon).ID()]
+	if n == 0 {
+		return color.RGBA{153, 153, 153, 255}
+	}
+	return blind.Sequential(blind.Iridescent, float64(n)/float64(ci.models))
+}
+
+func writeImage(name string, img image.Image) (err error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		e := f.Close()
+		if e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	if err := png.Encode(f, img); err != nil {
+		return fmt.Errorf("when encoding image file %q: %v", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,451 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package density implements a command to estimate
+// the density of a set of reconstructed points
+// at different time stages.
+package density
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/earth"
+	"github.com/js-arias/earth/cmd/plates/cmdlog"
+	"github.com/js-arias/earth/model"
+	"github.com/js-arias/earth/rotation"
+	"github.com/js-arias/earth/stat/dist"
+)
+
+var Command = &command.Command{
+	Usage: `density --rot <rotation-file> --lambda <value>
+	[-e|--equator <value>] [--pix <pix-file>] [--step <age>]
+	[--png <prefix>] [-c|--columns <value>] [--log <format>]
+	-o|--output <file> <point-file>`,
+	Short: "estimate the density of reconstructed points",
+	Long: `
+Command density reads a set of dated geographic points (for example, fossil
+occurrences), rotates each point to its location at its age, and estimates
+the density of the reconstructed points at each time stage, using a kernel
+density estimation with a spherical normal as the kernel.
+
+The argument of the command is the name of the file that contains the points.
+It is a tab-delimited text file with the following columns:
+
+	- age        the age of the point in years
+	- latitude   the geographic latitude of the point at present time
+	- longitude  the geographic longitude of the point at present time
+	- plate      (optional) the ID of the plate of the point
+
+If the plate column is not defined, the flag --pix must be used to define a
+pixelated plates file, and the plate of each point will be the plate of the
+pixel of the point at present time that exists at the age of the point (if
+more than one plate is found, the plate in which the pixel is older will be
+used, and in case of ties, the one with the lowest ID).
+
+The flag --rot is required and indicates the file containing a rotation model.
+Rotation model files are the standard files for rotations used in tectonic
+modelling software such as GPlates. Points of plates without a rotation at
+the age of the point will be skipped, and the number of skipped points will
+be reported on the standard error.
+
+The flag --lambda is required and sets the concentration parameter of the
+spherical normal kernel, in 1/radian^2 units. Larger values produce narrower
+kernels.
+
+Points are grouped into time stages. By default each stage is 5 million years
+long, use the flag --step to define a different stage size (in million
+years). Each point is assigned to the stage of its age (i.e. the youngest age
+of the stage). Note that each point is rotated to its own age, not to the age
+of the stage.
+
+The density is estimated over a pixelation based on an equal area
+partitioning of a sphere. If a pixelated plates file is given with --pix, its
+pixelation will be used. Otherwise, by default the pixelation will be of 360
+pixels at the equator, use the flag --equator, or -e, to define a different
+pixelation.
+
+The flag --output, or -o, is required, and sets the name of the file in which
+the density will be written. The density is written as a time pixelation of
+float values, in which the sum of the density of each time stage is equal to
+the number of points in the stage.
+
+If the flag --png is defined, an image for each time stage will be written,
+using the value of the flag as the prefix of the image names. The images use
+a plate carrée projection, and the density is scaled in gray levels, from
+black (no density) to white (the maximum density of the stage). By default
+the images will be 3600 pixels wide, use the flag --columns, or -c, to define
+a different number of image columns.
+
+Use the flag --log to set the format of the messages printed on the standard
+error. By default, the messages are human readable ("text"). If the format is
+"json", each step of the command (files read and written, and skipped points)
+will be printed as a JSON object, one per line.
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var equator int
+var colsFlag int
+var lambdaFlag float64
+var stepFlag float64
+var logFlag string
+var output string
+var pixFile string
+var pngFlag string
+var rotFile string
+
+func setFlags(c *command.Command) {
+	c.Flags().Float64Var(&lambdaFlag, "lambda", 0, "")
+	c.Flags().Float64Var(&stepFlag, "step", 5, "")
+	c.Flags().IntVar(&colsFlag, "columns", 3600, "")
+	c.Flags().IntVar(&colsFlag, "c", 3600, "")
+	c.Flags().IntVar(&equator, "equator", 360, "")
+	c.Flags().IntVar(&equator, "e", 360, "")
+	c.Flags().StringVar(&output, "output", "", "")
+	c.Flags().StringVar(&output, "o", "", "")
+	c.Flags().StringVar(&logFlag, "log", "text", "")
+	c.Flags().StringVar(&pixFile, "pix", "", "")
+	c.Flags().StringVar(&pngFlag, "png", "", "")
+	c.Flags().StringVar(&rotFile, "rot", "", "")
+}
+
+// MillionYears is used to transform ages
+// (a float in million years)
+// to an integer in years.
+const millionYears = 1_000_000
+
+func run(c *command.Command, args []string) error {
+	if len(args) < 1 {
+		return c.UsageError("expecting point file")
+	}
+	if rotFile == "" {
+		return c.UsageError("undefined value for --rot flag")
+	}
+	if lambdaFlag <= 0 {
+		return c.UsageError("undefined value for --lambda flag")
+	}
+	if output == "" {
+		return c.UsageError("undefined value for --output flag")
+	}
+	step := int64(stepFlag * millionYears)
+	if step <= 0 {
+		return c.UsageError("invalid value for --step flag")
+	}
+	log, err := cmdlog.New(c.Stderr(), logFlag)
+	if err != nil {
+		return c.UsageError(fmt.Sprintf("flag --log: %v", err))
+	}
+
+	rot, err := readRotation(rotFile)
+	if err != nil {
+		return err
+	}
+	log.Debug("read rotation model", "event", "read", "file", rotFile, "plates", len(rot.Plates()))
+
+	var pp *model.PixPlate
+	var pix *earth.Pixelation
+	if pixFile != "" {
+		pp, err = readPixPlate(pixFile)
+		if err != nil {
+			return err
+		}
+		pix = pp.Pixelation()
+		log.Debug("read pixelated plates", "event", "read", "file", pixFile, "plates", len(pp.Plates()))
+	} else {
+		pix, err = earth.NewPixelationErr(equator)
+		if err != nil {
+			return c.UsageError(err.Error())
+		}
+	}
+
+	pts, noPlate, err := readPoints(args[0], pp)
+	if err != nil {
+		return err
+	}
+	log.Debug("read points", "event", "read", "file", args[0], "points", len(pts))
+	if noPlate > 0 {
+		msg := fmt.Sprintf("points without plate: %d", noPlate)
+		log.Info(msg, "event", "no-plate", "points", noPlate)
+	}
+
+	// reconstructed pixels
+	// at each time stage
+	stages := make(map[int64][]earth.Pixel)
+	skipped := make(map[int64]int)
+	for _, p := range pts {
+		st := p.age / step * step
+		np, ok := rot.RotatePoint(p.plate, p.lat, p.lon, p.age)
+		if !ok {
+			skipped[st]++
+			continue
+		}
+		stages[st] = append(stages[st], pix.Pixel(np.Latitude(), np.Longitude()))
+	}
+
+	n := dist.NewNormal(lambdaFlag, pix)
+	tf := model.NewTimeFloat(pix)
+	for a, obs := range stages {
+		for id, v := range dist.KDE(pix, n, obs) {
+			if v == 0 {
+				continue
+			}
+			tf.Set(a, id, v)
+		}
+	}
+
+	ages := make([]int64, 0, len(skipped))
+	for a := range skipped {
+		ages = append(ages, a)
+	}
+	slices.Sort(ages)
+	for _, a := range ages {
+		msg := fmt.Sprintf("points without rotation at stage %.6f Ma: %d", float64(a)/millionYears, skipped[a])
+		log.Info(msg, "event", "no-rotation", "age", a, "points", skipped[a])
+	}
+
+	if err := writeTimeFloat(output, tf); err != nil {
+		return err
+	}
+	log.Debug("write density", "event", "write", "file", output, "stages", len(tf.Stages()))
+	if pngFlag == "" {
+		return nil
+	}
+
+	if colsFlag%2 != 0 {
+		colsFlag++
+	}
+	for _, a := range tf.Stages() {
+		name := fmt.Sprintf("%s-%d.png", pngFlag, a/millionYears)
+		if err := writeImage(name, newStageImg(tf, a)); err != nil {
+			return err
+		}
+		log.Debug("write image", "event", "write", "file", name, "age", a)
+	}
+	return nil
+}
+
+func readRotation(name string) (rotation.Rotation, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return rotation.Rotation{}, err
+	}
+	defer f.Close()
+
+	rot, err := rotation.Read(f)
+	if err != nil {
+		return rotation.Rotation{}, fmt.Errorf("when reading file %q: %v", name, err)
+	}
+	return rot, nil
+}
+
+func readPixPlate(name string) (*model.PixPlate, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	pp, err := model.ReadPixPlate(f, nil)
+	if err != nil {
+		return nil, fmt.Errorf("when reading file %q: %v", name, err)
+	}
+	return pp, nil
+}
+
+// A point is a dated geographic point.
+type point struct {
+	age      int64
+	lat, lon float64
+	plate    int
+}
+
+var pointHead = []string{
+	"age",
+	"latitude",
+	"longitude",
+}
+
+// ReadPoints returns the points of a file,
+// and the number of points without a plate.
+func readPoints(name string, pp *model.PixPlate) ([]point, int, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+
+	pts, noPlate, err := parsePoints(f, pp)
+	if err != nil {
+		return nil, 0, fmt.Errorf("on file %q: %v", name, err)
+	}
+	return pts, noPlate, nil
+}
+
+func parsePoints(r io.Reader, pp *model.PixPlate) ([]point, int, error) {
+	tab := csv.NewReader(r)
+	tab.Comma = '\t'
+	tab.Comment = '#'
+
+	head, err := tab.Read()
+	if err != nil {
+		return nil, 0, fmt.Errorf("header: %v", err)
+	}
+	fields := make(map[string]int, len(head))
+	for i, h := range head {
+		h = strings.ToLower(h)
+		fields[h] = i
+	}
+	for _, h := range pointHead {
+		if _, ok := fields[h]; !ok {
+			return nil, 0, fmt.Errorf("expecting field %q", h)
+		}
+	}
+	_, hasPlate := fields["plate"]
+	if !hasPlate && pp == nil {
+		return nil, 0, fmt.Errorf("expecting field %q, or a pixelated plates file (flag --pix)", "plate")
+	}
+
+	var pts []point
+	var noPlate int
+	for {
+		row, err := tab.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		ln, _ := tab.FieldPos(0)
+		if err != nil {
+			return nil, 0, fmt.Errorf("on row %d: %v", ln, err)
+		}
+
+		f := "age"
+		age, err := strconv.ParseInt(row[fields[f]], 10, 64)
+		if err != nil {
+			return nil, 0, fmt.Errorf("on row %d: field %q: %v", ln, f, err)
+		}
+		if age < 0 {
+			return nil, 0, fmt.Errorf("on row %d: field %q: invalid age %d", ln, f, age)
+		}
+
+		f = "latitude"
+		lat, err := strconv.ParseFloat(row[fields[f]], 64)
+		if err != nil {
+			return nil, 0, fmt.Errorf("on row %d: field %q: %v", ln, f, err)
+		}
+		if lat < -90 || lat > 90 {
+			return nil, 0, fmt.Errorf("on row %d: field %q: invalid latitude value %.6f", ln, f, lat)
+		}
+
+		f = "longitude"
+		lon, err := strconv.ParseFloat(row[fields[f]], 64)
+		if err != nil {
+			return nil, 0, fmt.Errorf("on row %d: field %q: %v", ln, f, err)
+		}
+		if lon < -180 || lon > 180 {
+			return nil, 0, fmt.Errorf("on row %d: field %q: invalid longitude value %.6f", ln, f, lon)
+		}
+
+		p := point{
+			age:   age,
+			lat:   lat,
+			lon:   lon,
+			plate: -1,
+		}
+		if hasPlate {
+			f = "plate"
+			p.plate, err = strconv.Atoi(row[fields[f]])
+			if err != nil {
+				return nil, 0, fmt.Errorf("on row %d: field %q: %v", ln, f, err)
+			}
+		} else {
+			id := pp.Pixelation().Pixel(lat, lon).ID()
+			pl, ok := pp.PlateOf(id, age)
+			if !ok {
+				noPlate++
+				continue
+			}
+			p.plate = pl
+		}
+		pts = append(pts, p)
+	}
+	return pts, noPlate, nil
+}
+
+func writeTimeFloat(name string, tf *model.TimeFloat) (err error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		e := f.Close()
+		if e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	if err := tf.TSV(f); err != nil {
+		return fmt.Errorf("while writing to %q: %v", name, err)
+	}
+	return nil
+}
+
+// A stageImg is an image
+// of the density at a time stage.
+type stageImg struct {
+	pix    *earth.Pixelation
+	values map[int]float64
+	max    float64
+}
+
+func newStageImg(tf *model.TimeFloat, age int64) stageImg {
+	s := stageImg{
+		pix:    tf.Pixelation(),
+		values: tf.Stage(age),
+	}
+	for _, v := range s.values {
+		s.max = max(s.max, v)
+	}
+	return s
+}
+
+func (s stageImg) ColorModel() color.Model { return color.GrayModel }
+func (s stageImg) Bounds() image.Rectangle { return image.Rect(0, 0, colsFlag, colsFlag/2) }
+func (s stageImg) At(x, y int) color.Color {
+	lat, lon := earth.PlateCarreeLatLon(x, y, colsFlag, colsFlag/2)
+
+	v := s.values[s.pix.Pixel(lat, lon).ID()]
+	if s.max == 0 {
+		return color.Gray{0}
+	}
+	return color.Gray{uint8(255 * v / s.max)}
+}
+
+func writeImage(name string, img image.Image) (err error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		e := f.Close()
+		if e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	if err := png.Encode(f, img); err != nil {
+		return fmt.Errorf("when encoding image file %q: %v", name, err)
+	}
+	return nil
+}
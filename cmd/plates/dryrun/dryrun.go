@@ -0,0 +1,64 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package dryrun implements the report
+// used by the plates commands
+// that edit a time pixelation
+// when they are run with the --dry-run flag.
+package dryrun
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/js-arias/earth/model"
+)
+
+// MillionYears is used to transform ages
+// an integer in years
+// to a float in million years.
+const millionYears = 1_000_000
+
+// Report prints a summary
+// of the changes to a time pixelation:
+// the number of time stages
+// and pixels changed,
+// and a table with the number of pixels
+// of each value transition
+// at each time stage.
+func Report(w io.Writer, changes []model.PixChange) {
+	type transition struct {
+		age      int64
+		old, new int
+	}
+	count := make(map[transition]int)
+	stages := make(map[int64]bool)
+	for _, c := range changes {
+		count[transition{age: c.Age, old: c.Old, new: c.New}]++
+		stages[c.Age] = true
+	}
+
+	trans := make([]transition, 0, len(count))
+	for t := range count {
+		trans = append(trans, t)
+	}
+	slices.SortFunc(trans, func(a, b transition) int {
+		if c := cmp.Compare(a.age, b.age); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(a.old, b.old); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.new, b.new)
+	})
+
+	fmt.Fprintf(w, "# stages: %d\n", len(stages))
+	fmt.Fprintf(w, "# pixels: %d\n", len(changes))
+	fmt.Fprintf(w, "age\told\tnew\tpixels\n")
+	for _, t := range trans {
+		fmt.Fprintf(w, "%.6f\t%d\t%d\t%d\n", float64(t.age)/millionYears, t.old, t.new, count[t])
+	}
+}
@@ -0,0 +1,224 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package interpolate implements a command to add
+// time stages between the stages
+// of a plate motion model.
+package interpolate
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/earth"
+	"github.com/js-arias/earth/cmd/plates/cmdlog"
+	"github.com/js-arias/earth/model"
+	"github.com/js-arias/earth/rotation"
+)
+
+var Command = &command.Command{
+	Usage: `interpolate --pix <pix-file> --rot <rotation-file>
+	--step <age> [--log <format>] [-o|--output <file>] <model-file>`,
+	Short: "add time stages between the stages of a plate motion model",
+	Long: `
+Command interpolate reads a plate motion model and adds new time stages
+between each pair of consecutive time stages of the model.
+
+The argument of the command is the name of the file that contains the model.
+
+The flag --pix is required and sets the file with pixelated plates. The
+resolution (i.e. the number of pixels in the equator) of the pixelation must
+be equal to the plate motion model.
+
+The flag --rot is required and indicates the file containing a rotation model.
+Rotation model files are the standard files for rotations used in tectonic
+modelling software such as GPlates.
+
+The flag --step is required and sets the spacing of the new time stages (in
+million years). New time stages are added every step after each time stage of
+the model, up to, but not including, the next time stage. For example, with
+time stages at 80 and 100 million years, and a step of 5, the time stages at
+85, 90, and 95 million years will be added. Ages older than the oldest time
+stage of the model are not added.
+
+Note that the pixel locations at the new time stages are not interpolated from
+the pixel locations of the stored time stages. Instead, as in the command
+rotate, the pixels of each plate at present time are rotated to the new age,
+using the rotation model (that interpolates the rotations of the plate between
+the ages defined in the rotation file). Then, the pixel locations at the new
+time stages are consistent with the rotation model, even if the stored time
+stages were built with a different rotation model, or edited by hand.
+
+If a plate has pixels alive at a new time stage, but the rotation model does
+not define a rotation for the plate at that age, the pixels will not be
+rotated, and the number of pixels without rotation will be reported on the
+standard error.
+
+By default, the input file will be replaced by the new model. Use the flag
+--output, or -o, to define a different output file.
+
+Use the flag --log to set the format of the messages printed on the standard
+error. By default, the messages are human readable ("text"). If the format is
+"json", each step of the command (files read and written, added time stages,
+and pixels without rotation) will be printed as a JSON object, one per line.
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var stepFlag float64
+var output string
+var pixFile string
+var rotFile string
+var logFlag string
+
+func setFlags(c *command.Command) {
+	c.Flags().Float64Var(&stepFlag, "step", 0, "")
+	c.Flags().StringVar(&output, "output", "", "")
+	c.Flags().StringVar(&output, "o", "", "")
+	c.Flags().StringVar(&pixFile, "pix", "", "")
+	c.Flags().StringVar(&rotFile, "rot", "", "")
+	c.Flags().StringVar(&logFlag, "log", "text", "")
+}
+
+// MillionYears is used to transform ages
+// (a float in million years)
+// to an integer in years.
+const millionYears = 1_000_000
+
+func run(c *command.Command, args []string) error {
+	if len(args) < 1 {
+		return c.UsageError("expecting plate motion model file")
+	}
+	if pixFile == "" {
+		return c.UsageError("undefined value for --pix flag")
+	}
+	if rotFile == "" {
+		return c.UsageError("undefined value for --rot flag")
+	}
+	step := int64(stepFlag * millionYears)
+	if step <= 0 {
+		return c.UsageError("flag --step must be set")
+	}
+	if output == "" {
+		output = args[0]
+	}
+	log, err := cmdlog.New(c.Stderr(), logFlag)
+	if err != nil {
+		return c.UsageError(fmt.Sprintf("flag --log: %v", err))
+	}
+
+	pp, err := readPixPlate(pixFile)
+	if err != nil {
+		return err
+	}
+	log.Debug("read pixelated plates", "event", "read", "file", pixFile, "plates", len(pp.Plates()))
+	rot, err := readRotation(rotFile)
+	if err != nil {
+		return err
+	}
+	log.Debug("read rotation model", "event", "read", "file", rotFile, "plates", len(rot.Plates()))
+	rec, err := readRecons(args[0], pp.Pixelation())
+	if err != nil {
+		return err
+	}
+	log.Debug("read plate motion model", "event", "read", "file", args[0], "stages", len(rec.Stages()))
+
+	ages := intermediateAges(rec.Stages(), step)
+	if len(ages) == 0 {
+		log.Info("no time stages to add", "event", "no-stages")
+	}
+	log.Debug("time stages to add", "event", "stages", "stages", len(ages))
+
+	for _, p := range pp.Plates() {
+		for _, a := range ages {
+			n := rec.AddRotation(pp, rot, p, a)
+			if n == 0 {
+				continue
+			}
+			msg := fmt.Sprintf("plate %d: pixels without rotation at %.6f Ma: %d", p, float64(a)/millionYears, n)
+			log.Info(msg, "event", "no-rotation", "plate", p, "age", a, "pixels", n)
+		}
+	}
+
+	if err := writeRecons(output, rec); err != nil {
+		return err
+	}
+	log.Debug("write plate motion model", "event", "write", "file", output, "stages", len(rec.Stages()))
+	return nil
+}
+
+// IntermediateAges returns the ages,
+// every step,
+// between each pair of consecutive time stages.
+func intermediateAges(stages []int64, step int64) []int64 {
+	var ages []int64
+	for i := 1; i < len(stages); i++ {
+		for a := stages[i-1] + step; a < stages[i]; a += step {
+			ages = append(ages, a)
+		}
+	}
+	return ages
+}
+
+func readPixPlate(name string) (*model.PixPlate, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	pp, err := model.ReadPixPlate(f, nil)
+	if err != nil {
+		return nil, fmt.Errorf("when reading file %q: %v", name, err)
+	}
+	return pp, nil
+}
+
+func readRotation(name string) (rotation.Rotation, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return rotation.Rotation{}, err
+	}
+	defer f.Close()
+
+	rot, err := rotation.Read(f)
+	if err != nil {
+		return rotation.Rotation{}, fmt.Errorf("when reading file %q: %v", name, err)
+	}
+	return rot, nil
+}
+
+func readRecons(name string, pix *earth.Pixelation) (*model.Recons, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	rec, err := model.ReadReconsTSV(f, pix)
+	if err != nil {
+		return nil, fmt.Errorf("when reading file %q: %v", name, err)
+	}
+	return rec, nil
+}
+
+func writeRecons(name string, rec *model.Recons) (err error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		e := f.Close()
+		if e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	if err := rec.TSV(f); err != nil {
+		return err
+	}
+	return nil
+}
@@ -7,12 +7,20 @@ package main
 
 import (
 	"github.com/js-arias/command"
+	"github.com/js-arias/earth/cmd/plates/checkrot"
+	"github.com/js-arias/earth/cmd/plates/compare"
+	"github.com/js-arias/earth/cmd/plates/consensus"
+	"github.com/js-arias/earth/cmd/plates/density"
+	"github.com/js-arias/earth/cmd/plates/interpolate"
 	"github.com/js-arias/earth/cmd/plates/mapcmd"
+	"github.com/js-arias/earth/cmd/plates/paleolat"
 	"github.com/js-arias/earth/cmd/plates/pixels"
+	"github.com/js-arias/earth/cmd/plates/reconstruct"
 	"github.com/js-arias/earth/cmd/plates/rotate"
 	"github.com/js-arias/earth/cmd/plates/rotmod"
 	"github.com/js-arias/earth/cmd/plates/stages"
 	"github.com/js-arias/earth/cmd/plates/timepix"
+	"github.com/js-arias/earth/cmd/plates/whichplate"
 )
 
 var app = &command.Command{
@@ -21,12 +29,20 @@ var app = &command.Command{
 }
 
 func init() {
+	app.Add(checkrot.Command)
+	app.Add(compare.Command)
+	app.Add(consensus.Command)
+	app.Add(density.Command)
+	app.Add(interpolate.Command)
 	app.Add(pixels.Command)
 	app.Add(mapcmd.Command)
+	app.Add(paleolat.Command)
+	app.Add(reconstruct.Command)
 	app.Add(rotate.Command)
 	app.Add(rotmod.Command)
 	app.Add(stages.Command)
 	app.Add(timepix.Command)
+	app.Add(whichplate.Command)
 }
 
 func main() {
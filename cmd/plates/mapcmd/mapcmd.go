@@ -10,18 +10,24 @@ import (
 	"fmt"
 	"image"
 	"image/color"
-	"image/png"
-	"math/rand"
+	"image/jpeg"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
 
-	"github.com/js-arias/blind"
 	"github.com/js-arias/command"
 	"github.com/js-arias/earth"
+	"github.com/js-arias/earth/cmd/internal/imgfmt"
+	"github.com/js-arias/earth/cmd/internal/intset"
 	"github.com/js-arias/earth/model"
+	"github.com/js-arias/earth/pixkey"
 )
 
 var Command = &command.Command{
-	Usage: `map [-c|--columns <value>] [--at <age>]
+	Usage: `map [-c|--columns <value>] [--at <ages>]
+	[--format <format>] [--quality <value>]
+	[--exclude-plate <id>]... [--pix <pix-file>]
 	-o|--output <out-image-file> <model-file>`,
 	Short: "draw a map from a plate motion model",
 	Long: `
@@ -33,25 +39,63 @@ motion model.
 
 The flag --output, or -o, is required and sets the name of the output image. If
 multiple stages are used, the time stage will append to the name of the image.
-In the image all pixels of a given plate will have the same color (selected at
-random). By default the image will be 3600 pixels wide, use the flag --columns,
-or -c, to define a different number of image columns.
+In the image all pixels of a given plate will have the same color (derived from
+the plate ID, so it will be the same in different images). By default the
+image will be 3600 pixels wide, use the flag --columns, or -c, to define a
+different number of image columns.
 
 By default all time stages will be produced. Use the flag --at to define a
-particular time stage to be draw (in million years).
+particular time stage to be draw (in million years). The flag accepts a
+comma-separated list of ages (e.g. --at 0,50,100), or a range in the form
+<from>:<to>:<step> (e.g. --at 0:100:10, for all ages from 0 to 100 million
+years, every 10 million years), to draw a set of time stages. Ages that are
+not a time stage of the model will be set to the closest time stage (i.e. the
+oldest time stage younger than the age, or the youngest time stage, if the age
+is younger than all time stages), and each time stage will be drawn only
+once. If the time stage used is more than 5 million years apart from an age,
+a warning will be printed in the standard error.
+
+Use the flag --exclude-plate to omit the pixels of a plate, given by its plate
+ID. The flag can be repeated to exclude several plates. Excluded plates are
+omitted entirely (i.e. they are not only hidden): if a pixel is shared with
+another plate at a time stage, the pixel will be drawn with the other plate.
+
+A plate motion model can store locations of pixels at time stages in which the
+pixels do not exist. Use the flag --pix to set a pixelated plates file, and
+then only the pixels that exist at a time stage (i.e. the time stage is
+between the oldest and youngest ages of the pixel in the pixelated plates
+file) will be drawn, so plates will appear and vanish at their formation and
+breakup ages. Pixels absent from the pixelated plates file will not be
+drawn.
+
+By default, images are encoded as PNG files. Use the flag --format to define
+the image format, valid formats are "png" and "jpeg". If the flag is not
+defined, the format will be inferred from the extension of the output name
+(the extension will be removed before adding the time stage). Use the flag
+--quality to set the quality of JPEG images (from 1 to 100, default 75). Note
+that JPEG is a lossy format, and its artifacts make it inappropriate for maps
+of categorical values or masks.
 	`,
 	SetFlags: setFlags,
 	Run:      run,
 }
 
 var colsFlag int
-var atFlag float64
+var atFlag string
 var output string
+var formatFlag string
+var qualityFlag int
+var excludeFlag = intset.Set{}
+var pixFile string
 
 func setFlags(c *command.Command) {
 	c.Flags().IntVar(&colsFlag, "columns", 3600, "")
 	c.Flags().IntVar(&colsFlag, "c", 3600, "")
-	c.Flags().Float64Var(&atFlag, "at", -1, "")
+	c.Flags().StringVar(&atFlag, "at", "", "")
+	c.Flags().IntVar(&qualityFlag, "quality", jpeg.DefaultQuality, "")
+	c.Flags().StringVar(&formatFlag, "format", "", "")
+	c.Flags().Var(excludeFlag, "exclude-plate", "")
+	c.Flags().StringVar(&pixFile, "pix", "", "")
 	c.Flags().StringVar(&output, "output", "", "")
 	c.Flags().StringVar(&output, "o", "", "")
 }
@@ -61,6 +105,13 @@ func setFlags(c *command.Command) {
 // to an integer in years.
 const millionYears = 1_000_000
 
+// MaxStageGap is the maximum difference
+// (in years)
+// between a requested age
+// and the time stage used,
+// before printing a warning.
+const maxStageGap = 5 * millionYears
+
 func run(c *command.Command, args []string) error {
 	if len(args) == 0 {
 		return c.UsageError("expecting plate motion model file")
@@ -68,29 +119,117 @@ func run(c *command.Command, args []string) error {
 	if output == "" {
 		return c.UsageError("undefined output image flag --output")
 	}
+	format, err := imgfmt.Format(output, formatFlag)
+	if err != nil {
+		return c.UsageError(err.Error())
+	}
+	prefix := imgfmt.Prefix(output)
+	if err := imgfmt.CheckQuality(qualityFlag); err != nil {
+		return c.UsageError(fmt.Sprintf("flag --quality: %v", err))
+	}
+	at, err := parseAges(atFlag)
+	if err != nil {
+		return c.UsageError(err.Error())
+	}
 
 	rec, err := readRecons(args[0])
 	if err != nil {
 		return err
 	}
-	var ages []int64
-	if atFlag >= 0 {
-		ages = []int64{int64(atFlag * millionYears)}
-	} else {
-		ages = rec.Stages()
+	ages := rec.Stages()
+	if len(at) > 0 && len(ages) > 0 {
+		first := ages[0]
+		ages = make([]int64, 0, len(at))
+		for _, a := range at {
+			if d := rec.StageDistance(a); d > maxStageGap {
+				fmt.Fprintf(c.Stderr(), "warning: closest time stage to %.6f Ma is %.6f million years apart\n", float64(a)/millionYears, float64(d)/millionYears)
+			}
+			// ages younger than the first stage
+			// are set to the first stage
+			ages = append(ages, rec.ClosestStageAge(max(a, first)))
+		}
+		slices.Sort(ages)
+		ages = slices.Compact(ages)
+	}
+
+	var pp *model.PixPlate
+	if pixFile != "" {
+		pp, err = readPixPlate(pixFile, rec.Pixelation())
+		if err != nil {
+			return err
+		}
 	}
 
 	pc := makePlatePalette(rec)
 
 	for _, a := range ages {
-		name := fmt.Sprintf("%s-%d.png", output, a/millionYears)
-		if err := writeImage(name, makeStage(rec, a, pc)); err != nil {
+		name := fmt.Sprintf("%s-%d.%s", prefix, a/millionYears, format)
+		if err := writeImage(name, makeStage(rec, pp, a, pc), format); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+// ParseAges returns the ages
+// (in years)
+// defined in the --at flag.
+func parseAges(s string) ([]int64, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	if strings.Contains(s, ":") {
+		rg := strings.Split(s, ":")
+		if len(rg) != 3 {
+			return nil, fmt.Errorf("flag --at: invalid range %q: expecting <from>:<to>:<step>", s)
+		}
+		var v [3]int64
+		for i, r := range rg {
+			a, err := parseAge(r)
+			if err != nil {
+				return nil, fmt.Errorf("flag --at: invalid range %q: %v", s, err)
+			}
+			v[i] = a
+		}
+		from, to, step := v[0], v[1], v[2]
+		if step <= 0 {
+			return nil, fmt.Errorf("flag --at: invalid range %q: step must be greater than 0", s)
+		}
+		if from > to {
+			from, to = to, from
+		}
+		var ages []int64
+		for a := from; a <= to; a += step {
+			ages = append(ages, a)
+		}
+		return ages, nil
+	}
+
+	var ages []int64
+	for _, v := range strings.Split(s, ",") {
+		a, err := parseAge(v)
+		if err != nil {
+			return nil, fmt.Errorf("flag --at: %v", err)
+		}
+		ages = append(ages, a)
+	}
+	return ages, nil
+}
+
+// ParseAge returns an age in years
+// from a string in million years.
+func parseAge(s string) (int64, error) {
+	v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return 0, err
+	}
+	if v < 0 {
+		return 0, fmt.Errorf("invalid age %q", s)
+	}
+	return int64(v * millionYears), nil
+}
+
 func readRecons(name string) (*model.Recons, error) {
 	f, err := os.Open(name)
 	if err != nil {
@@ -104,9 +243,22 @@ func readRecons(name string) (*model.Recons, error) {
 	return rec, nil
 }
 
+func readPixPlate(name string, pix *earth.Pixelation) (*model.PixPlate, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	pp, err := model.ReadPixPlate(f, pix)
+	if err != nil {
+		return nil, fmt.Errorf("when reading file %q: %v", name, err)
+	}
+	return pp, nil
+}
+
 // A stageModel stores the pixelation of a reconstruction.
 type stageModel struct {
-	step   float64
 	color  map[int]color.RGBA
 	pix    *earth.Pixelation
 	plates map[int]int
@@ -115,8 +267,7 @@ type stageModel struct {
 func (s stageModel) ColorModel() color.Model { return color.RGBAModel }
 func (s stageModel) Bounds() image.Rectangle { return image.Rect(0, 0, colsFlag, colsFlag/2) }
 func (s stageModel) At(x, y int) color.Color {
-	lat := 90 - float64(y)*s.step
-	lon := float64(x)*s.step - 180
+	lat, lon := earth.PlateCarreeLatLon(x, y, colsFlag, colsFlag/2)
 
 	pix := s.pix.Pixel(lat, lon).ID()
 	p, ok := s.plates[pix]
@@ -126,11 +277,19 @@ func (s stageModel) At(x, y int) color.Color {
 	return s.color[p]
 }
 
-func makeStage(rec *model.Recons, age int64, pc map[int]color.RGBA) stageModel {
+func makeStage(rec *model.Recons, pp *model.PixPlate, age int64, pc map[int]color.RGBA) stageModel {
 	plates := make(map[int]int)
 
 	for _, p := range rec.Plates() {
-		sp := rec.PixStage(p, age)
+		if excludeFlag[p] {
+			continue
+		}
+		var sp map[int][]int
+		if pp != nil {
+			sp = rec.PixStageAlive(pp, p, age)
+		} else {
+			sp = rec.PixStage(p, age)
+		}
 		for _, ids := range sp {
 			for _, id := range ids {
 				plates[id] = p
@@ -139,7 +298,6 @@ func makeStage(rec *model.Recons, age int64, pc map[int]color.RGBA) stageModel {
 	}
 
 	return stageModel{
-		step:   360 / float64(colsFlag),
 		color:  pc,
 		pix:    rec.Pixelation(),
 		plates: plates,
@@ -150,16 +308,12 @@ func makePlatePalette(rec *model.Recons) map[int]color.RGBA {
 	plates := rec.Plates()
 	pc := make(map[int]color.RGBA, len(plates))
 	for _, plate := range plates {
-		pc[plate] = randColor()
+		pc[plate] = pixkey.ColorFor(plate)
 	}
 	return pc
 }
 
-func randColor() color.RGBA {
-	return blind.Sequential(blind.Iridescent, rand.Float64())
-}
-
-func writeImage(name string, sm stageModel) (err error) {
+func writeImage(name string, sm stageModel, format string) (err error) {
 	f, err := os.Create(name)
 	if err != nil {
 		return err
@@ -171,7 +325,7 @@ func writeImage(name string, sm stageModel) (err error) {
 		}
 	}()
 
-	if err := png.Encode(f, sm); err != nil {
+	if err := imgfmt.Encode(f, sm, format, qualityFlag); err != nil {
 		return fmt.Errorf("when encoding image file %q: %v", name, err)
 	}
 	return nil
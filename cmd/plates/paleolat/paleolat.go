@@ -0,0 +1,323 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package paleolat implements a command to print
+// the paleolatitude of a set of points
+// through time.
+package paleolat
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/earth/cmd/plates/cmdlog"
+	"github.com/js-arias/earth/rotation"
+)
+
+var Command = &command.Command{
+	Usage: `paleolat --rot <rotation-file> --from <age>
+	[--to <age>] [--step <age>] [--log <format>] [-o|--output <file>]
+	<point-file>`,
+	Short: "print the paleolatitude of points through time",
+	Long: `
+Command paleolat reads a set of geographic points (for example, sampling
+sites) and prints the latitude of each point at different ages, using a
+rotation model.
+
+The argument of the command is the name of the file that contains the points.
+It is a tab-delimited text file with the following columns:
+
+	- name       the name of the point
+	- latitude   the geographic latitude of the point at present time
+	- longitude  the geographic longitude of the point at present time
+	- plate      the ID of the plate of the point
+
+The flag --rot is required and indicates the file containing a rotation model.
+Rotation model files are the standard files for rotations used in tectonic
+modelling software such as GPlates.
+
+The flag --from is required and sets the oldest age (in million years) of the
+time range. By default the youngest age is the present (0), use the flag --to
+to define a different youngest age. By default the paleolatitude will be
+calculated every million years, use the flag --step to define a different
+interval (in million years).
+
+The output is a tab-delimited table with the following columns:
+
+	- name      the name of the point
+	- age       the age, in million years
+	- paleolat  the latitude of the point at that age
+
+By default the output is printed in the standard output, use the flag
+--output, or -o, to define an output file.
+
+If the plate of a point has no rotation at an age, that age will be skipped
+for that point, and the number of skipped ages of each point will be reported
+in the standard error.
+
+Use the flag --log to set the format of the messages printed on the standard
+error. By default, the messages are human readable ("text"). If the format is
+"json", each step of the command (files read and written, and skipped ages)
+will be printed as a JSON object, one per line.
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var fromFlag float64
+var toFlag float64
+var stepFlag float64
+var output string
+var rotFile string
+var logFlag string
+
+func setFlags(c *command.Command) {
+	c.Flags().Float64Var(&fromFlag, "from", -1, "")
+	c.Flags().Float64Var(&toFlag, "to", 0, "")
+	c.Flags().Float64Var(&stepFlag, "step", 1, "")
+	c.Flags().StringVar(&output, "output", "", "")
+	c.Flags().StringVar(&output, "o", "", "")
+	c.Flags().StringVar(&rotFile, "rot", "", "")
+	c.Flags().StringVar(&logFlag, "log", "text", "")
+}
+
+// MillionYears is used to transform ages
+// (a float in million years)
+// to an integer in years.
+const millionYears = 1_000_000
+
+func run(c *command.Command, args []string) (err error) {
+	if len(args) < 1 {
+		return c.UsageError("expecting point file")
+	}
+	if rotFile == "" {
+		return c.UsageError("undefined value for --rot flag")
+	}
+	if fromFlag < 0 {
+		return c.UsageError("undefined value for --from flag")
+	}
+	if toFlag < 0 || toFlag > fromFlag {
+		return c.UsageError("invalid value for --to flag")
+	}
+	step := int64(stepFlag * millionYears)
+	if step <= 0 {
+		return c.UsageError("invalid value for --step flag")
+	}
+	log, err := cmdlog.New(c.Stderr(), logFlag)
+	if err != nil {
+		return c.UsageError(fmt.Sprintf("flag --log: %v", err))
+	}
+
+	rot, err := readRotation(rotFile)
+	if err != nil {
+		return err
+	}
+	log.Debug("read rotation model", "event", "read", "file", rotFile, "plates", len(rot.Plates()))
+	pts, err := readPoints(args[0])
+	if err != nil {
+		return err
+	}
+	log.Debug("read points", "event", "read", "file", args[0], "points", len(pts))
+
+	var ages []int64
+	from := int64(fromFlag * millionYears)
+	for a := int64(toFlag * millionYears); a <= from; a += step {
+		ages = append(ages, a)
+	}
+
+	w := c.Stdout()
+	if output != "" {
+		var f *os.File
+		f, err = os.Create(output)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			e := f.Close()
+			if e != nil && err == nil {
+				err = e
+			}
+		}()
+		w = f
+	}
+
+	skipped, err := writePaleoLat(w, rot, pts, ages)
+	if err != nil {
+		if output == "" {
+			output = "stdout"
+		}
+		return fmt.Errorf("when writing on %q: %v", output, err)
+	}
+	log.Debug("write paleolatitudes", "event", "write", "file", output, "ages", len(ages))
+	for _, p := range pts {
+		if skipped[p.name] == 0 {
+			continue
+		}
+		msg := fmt.Sprintf("%s: plate %d: ages without rotation: %d", p.name, p.plate, skipped[p.name])
+		log.Info(msg, "event", "no-rotation", "point", p.name, "plate", p.plate, "ages", skipped[p.name])
+	}
+	return nil
+}
+
+// WritePaleoLat writes the paleolatitude of the points
+// at each age,
+// and returns the number of ages without rotation
+// for each point.
+func writePaleoLat(w io.Writer, rot rotation.Rotation, pts []point, ages []int64) (map[string]int, error) {
+	bw := bufio.NewWriter(w)
+	tab := csv.NewWriter(bw)
+	tab.Comma = '\t'
+	tab.UseCRLF = true
+
+	if err := tab.Write([]string{"name", "age", "paleolat"}); err != nil {
+		return nil, err
+	}
+
+	skipped := make(map[string]int)
+	for _, p := range pts {
+		for _, a := range ages {
+			np, ok := rot.RotatePoint(p.plate, p.lat, p.lon, a)
+			if !ok {
+				skipped[p.name]++
+				continue
+			}
+			row := []string{
+				p.name,
+				strconv.FormatFloat(float64(a)/millionYears, 'f', -1, 64),
+				strconv.FormatFloat(np.Latitude(), 'f', 6, 64),
+			}
+			if err := tab.Write(row); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	tab.Flush()
+	if err := tab.Error(); err != nil {
+		return nil, err
+	}
+	if err := bw.Flush(); err != nil {
+		return nil, err
+	}
+	return skipped, nil
+}
+
+func readRotation(name string) (rotation.Rotation, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return rotation.Rotation{}, err
+	}
+	defer f.Close()
+
+	rot, err := rotation.Read(f)
+	if err != nil {
+		return rotation.Rotation{}, fmt.Errorf("when reading file %q: %v", name, err)
+	}
+	return rot, nil
+}
+
+type point struct {
+	name     string
+	lat, lon float64
+	plate    int
+}
+
+var pointHead = []string{
+	"name",
+	"latitude",
+	"longitude",
+	"plate",
+}
+
+func readPoints(name string) ([]point, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	pts, err := parsePoints(f)
+	if err != nil {
+		return nil, fmt.Errorf("on file %q: %v", name, err)
+	}
+	return pts, nil
+}
+
+func parsePoints(r io.Reader) ([]point, error) {
+	tab := csv.NewReader(r)
+	tab.Comma = '\t'
+	tab.Comment = '#'
+
+	head, err := tab.Read()
+	if err != nil {
+		return nil, fmt.Errorf("header: %v", err)
+	}
+	fields := make(map[string]int, len(head))
+	for i, h := range head {
+		h = strings.ToLower(h)
+		fields[h] = i
+	}
+	for _, h := range pointHead {
+		if _, ok := fields[h]; !ok {
+			return nil, fmt.Errorf("expecting field %q", h)
+		}
+	}
+
+	var pts []point
+	for {
+		row, err := tab.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		ln, _ := tab.FieldPos(0)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %v", ln, err)
+		}
+
+		f := "name"
+		name := strings.TrimSpace(row[fields[f]])
+		if name == "" {
+			return nil, fmt.Errorf("row %d: field %q: empty name", ln, f)
+		}
+
+		f = "latitude"
+		lat, err := strconv.ParseFloat(row[fields[f]], 64)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: field %q: %v", ln, f, err)
+		}
+		if lat < -90 || lat > 90 {
+			return nil, fmt.Errorf("row %d: field %q: invalid latitude value %.6f", ln, f, lat)
+		}
+
+		f = "longitude"
+		lon, err := strconv.ParseFloat(row[fields[f]], 64)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: field %q: %v", ln, f, err)
+		}
+		if lon < -180 || lon > 180 {
+			return nil, fmt.Errorf("row %d: field %q: invalid longitude value %.6f", ln, f, lon)
+		}
+
+		f = "plate"
+		plate, err := strconv.Atoi(row[fields[f]])
+		if err != nil {
+			return nil, fmt.Errorf("row %d: field %q: %v", ln, f, err)
+		}
+
+		pts = append(pts, point{
+			name:  name,
+			lat:   lat,
+			lon:   lon,
+			plate: plate,
+		})
+	}
+	return pts, nil
+}
@@ -0,0 +1,197 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package paleolat_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/js-arias/earth"
+	"github.com/js-arias/earth/cmd/plates/paleolat"
+	"github.com/js-arias/earth/rotation"
+)
+
+// makeInput writes a rotation file
+// and a point file
+// with a point without rotation,
+// and returns the name of the files.
+func makeInput(t testing.TB) (rot, pts string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	rot = filepath.Join(dir, "model.rot")
+	r := "1 0.0 90.0 0.0 0.0 0\n1 10.0 90.0 0.0 90.0 0\n"
+	if err := os.WriteFile(rot, []byte(r), 0o644); err != nil {
+		t.Fatalf("unable to write rotation file: %v", err)
+	}
+
+	pts = filepath.Join(dir, "points.tab")
+	p := "name\tlatitude\tlongitude\tplate\nsite\t10\t20\t1\nlost\t-10\t-20\t2\n"
+	if err := os.WriteFile(pts, []byte(p), 0o644); err != nil {
+		t.Fatalf("unable to write point file: %v", err)
+	}
+	return rot, pts
+}
+
+func TestPaleoLat(t *testing.T) {
+	dir := t.TempDir()
+
+	// a rotation of 90 degrees
+	// around an Euler pole at the equator
+	rotFile := filepath.Join(dir, "model.rot")
+	r := "1 0.0 90.0 0.0 0.0 0\n1 10.0 0.0 0.0 90.0 0\n"
+	if err := os.WriteFile(rotFile, []byte(r), 0o644); err != nil {
+		t.Fatalf("unable to write rotation file: %v", err)
+	}
+	pts := filepath.Join(dir, "points.tab")
+	p := "name\tlatitude\tlongitude\tplate\nsite\t10\t20\t1\nequator\t0\t90\t1\n"
+	if err := os.WriteFile(pts, []byte(p), 0o644); err != nil {
+		t.Fatalf("unable to write point file: %v", err)
+	}
+	out := filepath.Join(dir, "out.tab")
+
+	var stdout, stderr bytes.Buffer
+	paleolat.Command.SetStdout(&stdout)
+	paleolat.Command.SetStderr(&stderr)
+	args := []string{"--rot", rotFile, "--from", "10", "--step", "5", "-o", out, pts}
+	if err := paleolat.Command.Execute(args); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	f, err := os.Open(rotFile)
+	if err != nil {
+		t.Fatalf("unable to open rotation file: %v", err)
+	}
+	defer f.Close()
+	rot, err := rotation.Read(f)
+	if err != nil {
+		t.Fatalf("unable to read rotation file: %v", err)
+	}
+
+	of, err := os.Open(out)
+	if err != nil {
+		t.Fatalf("unable to open output: %v", err)
+	}
+	defer of.Close()
+	tab := csv.NewReader(of)
+	tab.Comma = '\t'
+	rows, err := tab.ReadAll()
+	if err != nil {
+		t.Fatalf("unable to read output: %v", err)
+	}
+
+	coords := map[string][2]float64{
+		"site":    {10, 20},
+		"equator": {0, 90},
+	}
+	if len(rows) != 7 {
+		t.Fatalf("got %d rows, want %d", len(rows), 7)
+	}
+	for _, row := range rows[1:] {
+		c := coords[row[0]]
+		ma, _ := strconv.ParseFloat(row[1], 64)
+		age := int64(ma * 1_000_000)
+
+		r3rot, ok := rot.Rotation(1, age)
+		if !ok {
+			t.Fatalf("%s at %s Ma: undefined rotation", row[0], row[1])
+		}
+		v := rotation.Rotate(r3rot, c[0], c[1])
+		want := strconv.FormatFloat(earth.ToDegree(math.Asin(v.Z)), 'f', 6, 64)
+		if row[2] != want {
+			t.Errorf("%s at %s Ma: got %s, want %s", row[0], row[1], row[2], want)
+		}
+
+		// a point at the equator
+		// at 90 degrees from the Euler pole
+		// is moved to a pole
+		if row[0] == "equator" && age == 10_000_000 {
+			if lat, _ := strconv.ParseFloat(row[2], 64); math.Abs(lat) != 90 {
+				t.Errorf("%s at %s Ma: got %s, want a pole", row[0], row[1], row[2])
+			}
+		}
+	}
+}
+
+func TestLogText(t *testing.T) {
+	rot, pts := makeInput(t)
+	out := filepath.Join(t.TempDir(), "out.tab")
+
+	var stdout, stderr bytes.Buffer
+	paleolat.Command.SetStdout(&stdout)
+	paleolat.Command.SetStderr(&stderr)
+	args := []string{"--rot", rot, "--from", "10", "--step", "5", "-o", out, pts}
+	if err := paleolat.Command.Execute(args); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if stdout.Len() != 0 {
+		t.Errorf("stdout: got %q, want empty output", stdout.String())
+	}
+	want := "lost: plate 2: ages without rotation: 3\n"
+	if got := stderr.String(); got != want {
+		t.Errorf("stderr: got %q, want %q", got, want)
+	}
+}
+
+func TestLogJSON(t *testing.T) {
+	rot, pts := makeInput(t)
+	out := filepath.Join(t.TempDir(), "out.tab")
+
+	var stdout, stderr bytes.Buffer
+	paleolat.Command.SetStdout(&stdout)
+	paleolat.Command.SetStderr(&stderr)
+	args := []string{"--log", "json", "--rot", rot, "--from", "10", "--step", "5", "-o", out, pts}
+	if err := paleolat.Command.Execute(args); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if stdout.Len() != 0 {
+		t.Errorf("stdout: got %q, want empty output", stdout.String())
+	}
+
+	var events []string
+	s := bufio.NewScanner(&stderr)
+	for s.Scan() {
+		var e map[string]any
+		if err := json.Unmarshal(s.Bytes(), &e); err != nil {
+			t.Fatalf("stderr: invalid JSON line %q: %v", s.Text(), err)
+		}
+		ev, _ := e["event"].(string)
+		events = append(events, ev)
+		if ev == "no-rotation" {
+			if e["point"] != "lost" || e["plate"] != 2.0 || e["ages"] != 3.0 {
+				t.Errorf("no-rotation: got %v", e)
+			}
+		}
+	}
+	want := "read,read,write,no-rotation"
+	if got := strings.Join(events, ","); got != want {
+		t.Errorf("events: got %q, want %q", got, want)
+	}
+}
+
+func TestLogUnknownFormat(t *testing.T) {
+	rot, pts := makeInput(t)
+
+	var stdout, stderr bytes.Buffer
+	paleolat.Command.SetStdout(&stdout)
+	paleolat.Command.SetStderr(&stderr)
+	args := []string{"--log", "xml", "--rot", rot, "--from", "10", pts}
+	if err := paleolat.Command.Execute(args); err == nil {
+		t.Errorf("expecting error for an unknown log format")
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("stdout: got %q, want empty output", stdout.String())
+	}
+}
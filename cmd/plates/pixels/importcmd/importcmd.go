@@ -10,19 +10,27 @@ package importcmd
 import (
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
 	"runtime"
+	"slices"
+	"strings"
 	"sync"
 
 	"github.com/js-arias/command"
 	"github.com/js-arias/earth"
+	"github.com/js-arias/earth/cmd/internal/intset"
+	"github.com/js-arias/earth/cmd/plates/cmdlog"
 	"github.com/js-arias/earth/model"
 	"github.com/js-arias/earth/vector"
 )
 
 var Command = &command.Command{
 	Usage: `import [-e|--equator <value>] [--at <age>]
-	[--cpu <value>] [-o|--output <file>] [<gpml-file>...]`,
+	[--cpu <value>] [--progress] [--type <feature-type>]...
+	[--box <lat,lon,lat,lon>] [--exclude-plate <id>]...
+	[--list-types] [--log <format>]
+	[-o|--output <file>] [<gpml-file>...]`,
 	Short: "import GPML files",
 	Long: `
 Import reads one or more GPML encoded GPlates files and imports them
@@ -43,6 +51,47 @@ or -e flag.
 By default, all features will be pixelated. Use the --at flag to import only
 features that existed at the specified time (in million years).
 
+Use the --type flag to import only features of a given type. The flag can be
+repeated to import features of different types. Valid types are:
+
+	- basin
+	- plate boundary
+	- coastline
+	- continental boundary
+	- craton
+	- continental fragment
+	- generic
+	- hotspot
+	- island arc
+	- large igneous province
+	- paleo-boundary
+	- passive continental boundary
+	- suture
+	- terrane
+
+For example, to import only coastlines and cratons, use:
+
+	--type coastline --type craton
+
+Use the --exclude-plate flag to skip all the features of a plate, given by its
+plate ID. The flag can be repeated to exclude several plates. Features of
+excluded plates are not imported at all, so they will be absent from the
+output file.
+
+Use the --box flag to import only the features inside a geographic box. The
+box is defined using the format "lat,lon,lat,lon", with the north-west and
+south-east corners of the box, for example "14,-94,-58,-26" will enclose South
+America. If the west longitude is greater than the east longitude, the box
+crosses the antimeridian, for example "20,170,-25,-170" will enclose Fiji.
+Features completely outside the box will be skipped, and only the pixels
+inside the box will be imported.
+
+Use the --list-types flag to print the number of features of each type found
+in the input files, as well as the total number of features, without
+importing them. Counts from all input files are aggregated, and types without
+features are not printed. Other flags are ignored when --list-types is
+defined. The output is printed on the standard output.
+
 The resulting pixelation will be written to the standard output. Use the
 --output or -o flag to specify an output file.
 
@@ -57,6 +106,14 @@ The output file is a tab-delimited value file with the following columns:
 
 By default, the import process will utilize all available CPU processors
 concurrently. Use the --cpu flag to set the number of used processors.
+
+If the flag --progress is defined, the number of processed features will be
+printed periodically on the standard error.
+
+Use the flag --log to set the format of the messages printed on the standard
+error. By default, the messages are human readable ("text"). If the format is
+"json", each step of the command (progress reports, and files written) will be
+printed as a JSON object, one per line.
 	`,
 	SetFlags: setFlags,
 	Run:      run,
@@ -66,6 +123,12 @@ var output string
 var atFlag float64
 var equator int
 var cpu int
+var progressFlag bool
+var boxFlag string
+var typeFlag = typeSet{}
+var excludeFlag = intset.Set{}
+var listTypes bool
+var logFlag string
 
 func setFlags(c *command.Command) {
 	c.Flags().StringVar(&output, "output", "", "")
@@ -74,6 +137,12 @@ func setFlags(c *command.Command) {
 	c.Flags().IntVar(&equator, "e", 360, "")
 	c.Flags().IntVar(&cpu, "cpu", runtime.NumCPU(), "")
 	c.Flags().Float64Var(&atFlag, "at", 0, "")
+	c.Flags().BoolVar(&progressFlag, "progress", false, "")
+	c.Flags().StringVar(&boxFlag, "box", "", "")
+	c.Flags().Var(typeFlag, "type", "")
+	c.Flags().Var(excludeFlag, "exclude-plate", "")
+	c.Flags().BoolVar(&listTypes, "list-types", false, "")
+	c.Flags().StringVar(&logFlag, "log", "text", "")
 }
 
 // MillionYears is used to transform age
@@ -82,13 +151,35 @@ func setFlags(c *command.Command) {
 const millionYears = 1_000_000
 
 func run(c *command.Command, args []string) (err error) {
+	log, err := cmdlog.New(c.Stderr(), logFlag)
+	if err != nil {
+		return c.UsageError(fmt.Sprintf("flag --log: %v", err))
+	}
+	if listTypes {
+		return countTypes(c.Stdin(), c.Stdout(), args)
+	}
+
+	var box *earth.Box
+	if boxFlag != "" {
+		b, err := earth.ParseBox(boxFlag)
+		if err != nil {
+			return c.UsageError(fmt.Sprintf("flag --box: %v", err))
+		}
+		box = &b
+	}
+
 	features := make(chan vector.Feature)
 	errChan := make(chan error)
 
-	go read(c.Stdin(), args, features, errChan)
+	go read(c.Stdin(), args, box, features, errChan)
 
 	pp := model.NewPixPlate(earth.NewPixelation(equator))
 
+	var pr *progress
+	if progressFlag {
+		pr = &progress{log: log}
+	}
+
 	done := make(chan struct{})
 	var wg sync.WaitGroup
 	for i := 0; i < cpu; i++ {
@@ -96,8 +187,12 @@ func run(c *command.Command, args []string) (err error) {
 		go func() {
 			defer wg.Done()
 			for f := range features {
-				pix := f.Pixels(pp.Pixelation())
-				pp.AddPixels(f.Plate, f.Name, pix, f.Begin, f.End)
+				if box != nil {
+					addInBox(pp, f, box)
+				} else {
+					pp.AddFeature(f)
+				}
+				pr.add()
 			}
 		}()
 	}
@@ -111,14 +206,94 @@ func run(c *command.Command, args []string) (err error) {
 		return err
 	case <-done:
 	}
+	pr.end()
 
 	if err := write(c.Stdout(), output, pp); err != nil {
 		return err
 	}
+	log.Debug("write pixelated plates", "event", "write", "file", output, "plates", len(pp.Plates()))
 	return nil
 }
 
-func read(r io.Reader, args []string, fc chan vector.Feature, ec chan error) {
+// A typeSet is a set of feature types
+// that can be used as a repeatable flag.
+type typeSet map[vector.Type]bool
+
+func (ts typeSet) String() string {
+	tps := make([]string, 0, len(ts))
+	for tp := range ts {
+		tps = append(tps, string(tp))
+	}
+	slices.Sort(tps)
+	return strings.Join(tps, ",")
+}
+
+func (ts typeSet) Set(s string) error {
+	tp, err := vector.ParseType(s)
+	if err != nil {
+		return err
+	}
+	ts[tp] = true
+	return nil
+}
+
+// ReportEvery is the number of processed features
+// between progress reports.
+const reportEvery = 100
+
+// A progress is a goroutine-safe reporter
+// of the number of processed features.
+// A nil progress does nothing.
+type progress struct {
+	mu  sync.Mutex
+	log *slog.Logger
+	n   int
+}
+
+func (p *progress) add() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.n++
+	if p.n%reportEvery == 0 {
+		msg := fmt.Sprintf("features processed: %d", p.n)
+		p.log.Info(msg, "event", "progress", "features", p.n)
+	}
+}
+
+func (p *progress) end() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	msg := fmt.Sprintf("features processed: %d [done]", p.n)
+	p.log.Info(msg, "event", "done", "features", p.n)
+}
+
+// AddInBox adds the pixels of a feature
+// that are inside a box.
+func addInBox(pp *model.PixPlate, f vector.Feature, box *earth.Box) {
+	pix := pp.Pixelation()
+	var in []int
+	for _, id := range f.Pixels(pix) {
+		pt := pix.ID(id).Point()
+		if !box.Contains(pt.Latitude(), pt.Longitude()) {
+			continue
+		}
+		in = append(in, id)
+	}
+	if len(in) == 0 {
+		return
+	}
+	pp.AddPixels(f.Plate, f.Name, in, f.Begin, f.End)
+}
+
+func read(r io.Reader, args []string, box *earth.Box, fc chan vector.Feature, ec chan error) {
 	at := int64(millionYears * atFlag)
 
 	if len(args) == 0 {
@@ -137,7 +312,16 @@ func read(r io.Reader, args []string, fc chan vector.Feature, ec chan error) {
 				return
 			}
 			for _, f := range fs {
-				if at != 0 && (f.Begin < at || f.End > at) {
+				if at != 0 && !f.AliveAt(at) {
+					continue
+				}
+				if len(typeFlag) > 0 && !typeFlag[f.Type] {
+					continue
+				}
+				if excludeFlag[f.Plate] {
+					continue
+				}
+				if box != nil && !inBox(f, box) {
 					continue
 				}
 
@@ -154,6 +338,49 @@ func read(r io.Reader, args []string, fc chan vector.Feature, ec chan error) {
 	close(fc)
 }
 
+// CountTypes prints the number of features
+// of each type
+// in the input files.
+func countTypes(r io.Reader, w io.Writer, args []string) error {
+	if len(args) == 0 {
+		args = append(args, "-")
+	}
+
+	count := make(map[vector.Type]int)
+	var total int
+	for _, a := range args {
+		fs, err := readFeatures(r, a)
+		if err != nil {
+			return err
+		}
+		for _, f := range fs {
+			count[f.Type]++
+		}
+		total += len(fs)
+	}
+
+	for _, tp := range vector.Types() {
+		if count[tp] == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%d\n", tp, count[tp])
+	}
+	fmt.Fprintf(w, "total\t%d\n", total)
+	return nil
+}
+
+// InBox returns true if the bounds of a feature
+// intersect a box.
+func inBox(f vector.Feature, box *earth.Box) bool {
+	if f.Point != nil && box.Contains(f.Point.Lat, f.Point.Lon) {
+		return true
+	}
+	if len(f.Polygon) == 0 {
+		return false
+	}
+	return box.Intersects(f.Polygon.Bounds())
+}
+
 func readFeatures(r io.Reader, name string) ([]vector.Feature, error) {
 	if name != "-" {
 		f, err := os.Open(name)
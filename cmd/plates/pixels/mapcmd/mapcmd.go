@@ -10,19 +10,25 @@ import (
 	"fmt"
 	"image"
 	"image/color"
-	"image/png"
+	"image/jpeg"
 	"io"
-	"math/rand"
 	"os"
+	"slices"
 
-	"github.com/js-arias/blind"
 	"github.com/js-arias/command"
 	"github.com/js-arias/earth"
+	"github.com/js-arias/earth/cmd/internal/imgfmt"
+	"github.com/js-arias/earth/cmd/internal/intset"
 	"github.com/js-arias/earth/model"
+	"github.com/js-arias/earth/pixkey"
+	"github.com/js-arias/earth/vector"
 )
 
 var Command = &command.Command{
 	Usage: `map [-c|--columns <value>] [--mask]
+	[--bg-color <r,g,b>] [--fg-color <r,g,b>]
+	[--format <format>] [--quality <value>]
+	[--geojson <file>] [--exclude-plate <id>]...
 	-o|--output <out-img-file> [<pix-file>...]`,
 	Short: "draw a map from a file with pixelated plates",
 	Long: `
@@ -31,12 +37,42 @@ the plates drawn using a plate carrée (equirectangular) projection.
 
 The --output or -o flag is required and specifies the name of the output image
 file. In the generated image, all pixels associated with a plate will have the
-same color, randomly selected. If the --mask flag is provided, the output will
-be a mask-like image. By default, the image will have a width of 3600 pixels.
-Use the --column or -c flag to specify a different number of image columns.	
+same color, derived from the plate ID. If the --mask flag is provided, the
+output will be a mask-like image. By default, the image will have a width of
+3600 pixels. Use the --column or -c flag to specify a different number of
+image columns.
+
+By default, pixels without a plate are drawn in gray (153,153,153), or in
+black in a mask. Use the flag --bg-color to define a different color for
+pixels without a plate. In a mask, pixels with a plate are drawn in white, use
+the flag --fg-color to define a different color. Colors are defined as RGB
+values separated by commas (as in pixel key files), for example "54,75,154",
+and each value must be between 0 and 255.
 	
+Use the flag --exclude-plate to omit the pixels of a plate, given by its plate
+ID. The flag can be repeated to exclude several plates. Excluded plates are
+omitted entirely (i.e. they are not only hidden): if a pixel is shared with
+another plate, the pixel will be drawn with the other plate, and excluded
+plates are not included in the GeoJSON output.
+
 One or more input files can be given as arguments. If no files are given, the
 input will be read from the standard input.
+
+By default, the image is encoded as a PNG file. Use the flag --format to define
+the image format, valid formats are "png" and "jpeg". If the flag is not
+defined, the format will be inferred from the extension of the output file.
+Use the flag --quality to set the quality of JPEG images (from 1 to 100,
+default 75). Note that JPEG is a lossy format, and its artifacts make it
+inappropriate for maps of categorical values or masks.
+
+Use the flag --geojson to write the pixelated plates as a GeoJSON feature
+collection in the indicated file. Each plate is encoded as a feature with a
+MultiPolygon geometry made of the cells of its pixels, with the plate ID and
+the number of pixels as properties. If this flag is defined, the flag --output
+is optional. The geometry is at the pixel resolution: each polygon is the cell
+outline of a run of consecutive pixels of the same ring of the pixelation, and
+the boundaries between cells are not dissolved. Cells that cross the
+antimeridian are split at the antimeridian.
 	`,
 	SetFlags: setFlags,
 	Run:      run,
@@ -45,19 +81,43 @@ input will be read from the standard input.
 var maskFlag bool
 var colsFlag int
 var output string
+var formatFlag string
+var qualityFlag int
+var geojsonFlag string
+var bgColorFlag string
+var fgColorFlag string
+var excludeFlag = intset.Set{}
 
 func setFlags(c *command.Command) {
 	c.Flags().BoolVar(&maskFlag, "mask", false, "")
 	c.Flags().IntVar(&colsFlag, "columns", 3600, "")
 	c.Flags().IntVar(&colsFlag, "c", 3600, "")
+	c.Flags().IntVar(&qualityFlag, "quality", jpeg.DefaultQuality, "")
+	c.Flags().StringVar(&bgColorFlag, "bg-color", "", "")
+	c.Flags().StringVar(&fgColorFlag, "fg-color", "", "")
+	c.Flags().StringVar(&formatFlag, "format", "", "")
+	c.Flags().StringVar(&geojsonFlag, "geojson", "", "")
+	c.Flags().Var(excludeFlag, "exclude-plate", "")
 	c.Flags().StringVar(&output, "output", "", "")
 	c.Flags().StringVar(&output, "o", "", "")
 }
 
 func run(c *command.Command, args []string) error {
-	if output == "" {
+	if output == "" && geojsonFlag == "" {
 		return c.UsageError("expecting output image file name, flag --output")
 	}
+	format, err := imgfmt.Format(output, formatFlag)
+	if err != nil {
+		return c.UsageError(err.Error())
+	}
+	if err := imgfmt.CheckQuality(qualityFlag); err != nil {
+		return c.UsageError(fmt.Sprintf("flag --quality: %v", err))
+	}
+
+	bg, fg, err := mapColors()
+	if err != nil {
+		return c.UsageError(err.Error())
+	}
 
 	if colsFlag%2 != 0 {
 		colsFlag++
@@ -79,10 +139,11 @@ func run(c *command.Command, args []string) error {
 		}
 		if img == nil {
 			img = &mapImg{
-				step:  360 / float64(colsFlag),
 				color: make(map[int]color.RGBA),
 				pix:   pp.Pixelation(),
 				pp:    make(map[int]pixel),
+				bg:    bg,
+				fg:    fg,
 			}
 		}
 		img.addPixels(pp)
@@ -92,8 +153,15 @@ func run(c *command.Command, args []string) error {
 		return nil
 	}
 
-	if err := writeImage(output, img); err != nil {
-		return err
+	if output != "" {
+		if err := writeImage(output, img, format); err != nil {
+			return err
+		}
+	}
+	if geojsonFlag != "" {
+		if err := writeGeoJSON(geojsonFlag, img); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -118,10 +186,12 @@ func readPixPlate(r io.Reader, name string, pix *earth.Pixelation) (*model.PixPl
 }
 
 type mapImg struct {
-	step  float64
 	color map[int]color.RGBA
 	pix   *earth.Pixelation
 	pp    map[int]pixel
+
+	bg color.RGBA // color of pixels without a plate
+	fg color.RGBA // color of pixels with a plate in a mask
 }
 
 type pixel struct {
@@ -133,31 +203,36 @@ func (m *mapImg) ColorModel() color.Model { return color.RGBAModel }
 func (m *mapImg) Bounds() image.Rectangle { return image.Rect(0, 0, colsFlag, colsFlag/2) }
 
 func (m *mapImg) At(x, y int) color.Color {
-	lat := 90 - float64(y)*m.step
-	lon := float64(x)*m.step - 180
+	lat, lon := earth.PlateCarreeLatLon(x, y, colsFlag, colsFlag/2)
 
 	pos := m.pix.Pixel(lat, lon).ID()
 	pp, ok := m.pp[pos]
 	if !ok {
-		if maskFlag {
-			return color.RGBA{0, 0, 0, 255}
-		}
-		return color.RGBA{153, 153, 153, 255}
+		return m.bg
 	}
 	if maskFlag {
-		return color.RGBA{255, 255, 255, 255}
+		return m.fg
 	}
 	if c, ok := m.color[pp.plate]; ok {
 		return c
 	}
 
-	c := randColor()
+	c := pixkey.ColorFor(pp.plate)
 	m.color[pp.plate] = c
 	return c
 }
 
+// AddPixels sets the plate of each pixel.
+// If a pixel is assigned to more than one plate,
+// it uses the same rule as model.PixPlate.PlateOf:
+// the plate in which the pixel is older,
+// and in case of ties,
+// the plate with the lowest ID.
 func (m *mapImg) addPixels(pp *model.PixPlate) {
 	for _, plate := range pp.Plates() {
+		if excludeFlag[plate] {
+			continue
+		}
 		for _, id := range pp.Pixels(plate) {
 			px := pp.Pixel(plate, id)
 			op, ok := m.pp[id]
@@ -171,6 +246,9 @@ func (m *mapImg) addPixels(pp *model.PixPlate) {
 			if px.Begin < op.age {
 				continue
 			}
+			if px.Begin == op.age && plate > op.plate {
+				continue
+			}
 			m.pp[id] = pixel{
 				age:   px.Begin,
 				plate: plate,
@@ -179,11 +257,85 @@ func (m *mapImg) addPixels(pp *model.PixPlate) {
 	}
 }
 
-func randColor() color.RGBA {
-	return blind.Sequential(blind.Iridescent, rand.Float64())
+// MapColors returns the background
+// and the mask foreground colors.
+func mapColors() (bg, fg color.RGBA, err error) {
+	bg = color.RGBA{153, 153, 153, 255}
+	fg = color.RGBA{255, 255, 255, 255}
+	if maskFlag {
+		bg = color.RGBA{0, 0, 0, 255}
+	}
+
+	if bgColorFlag != "" {
+		bg, err = pixkey.ParseColor(bgColorFlag)
+		if err != nil {
+			return bg, fg, fmt.Errorf("flag --bg-color: %v", err)
+		}
+	}
+	if fgColorFlag != "" {
+		fg, err = pixkey.ParseColor(fgColorFlag)
+		if err != nil {
+			return bg, fg, fmt.Errorf("flag --fg-color: %v", err)
+		}
+	}
+	return bg, fg, nil
+}
+
+// PlatePixels returns the sorted pixels
+// assigned to each plate.
+func (m *mapImg) platePixels() map[int][]int {
+	plates := make(map[int][]int)
+	for id, px := range m.pp {
+		plates[px.plate] = append(plates[px.plate], id)
+	}
+	for _, ids := range plates {
+		slices.Sort(ids)
+	}
+	return plates
+}
+
+type geoProperties struct {
+	Plate  int `json:"plate"`
+	Pixels int `json:"pixels"`
+}
+
+func writeGeoJSON(name string, img *mapImg) (err error) {
+	plates := img.platePixels()
+	ids := make([]int, 0, len(plates))
+	for p := range plates {
+		ids = append(ids, p)
+	}
+	slices.Sort(ids)
+
+	fs := make([]vector.PixelFeature, 0, len(ids))
+	for _, p := range ids {
+		fs = append(fs, vector.PixelFeature{
+			Pixels: plates[p],
+			Properties: geoProperties{
+				Plate:  p,
+				Pixels: len(plates[p]),
+			},
+		})
+	}
+
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		e := f.Close()
+		if e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	if err := vector.EncodePixelGeoJSON(f, img.pix, fs); err != nil {
+		return fmt.Errorf("when writing on file %q: %v", name, err)
+	}
+	return nil
 }
 
-func writeImage(name string, img *mapImg) (err error) {
+func writeImage(name string, img *mapImg, format string) (err error) {
 	f, err := os.Create(name)
 	if err != nil {
 		return err
@@ -195,7 +347,7 @@ func writeImage(name string, img *mapImg) (err error) {
 		}
 	}()
 
-	if err := png.Encode(f, img); err != nil {
+	if err := imgfmt.Encode(f, img, format, qualityFlag); err != nil {
 		return fmt.Errorf("when encoding image file %q: %v", name, err)
 	}
 	return nil
@@ -0,0 +1,183 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package reconstruct implements a command to rotate
+// the features of a GPML file
+// to their location at a given time.
+package reconstruct
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/earth/cmd/plates/cmdlog"
+	"github.com/js-arias/earth/rotation"
+	"github.com/js-arias/earth/vector"
+)
+
+var Command = &command.Command{
+	Usage: `reconstruct --rot <rotation-file> --at <age>
+	[--log <format>] [-o|--output <file>] [<gpml-file>...]`,
+	Short: "reconstruct vector features at a given time",
+	Long: `
+Command reconstruct reads one or more GPML encoded GPlates files, and rotates
+the vector features (i.e., the points and polygons, instead of pixels) to
+their location at a given time. The reconstructed features are written as a
+GeoJSON feature collection.
+
+One or more input files can be given as arguments. If no files are specified,
+the input will be read from the standard input.
+
+The flag --rot is required and indicates the file containing a rotation model.
+Rotation model files are the standard files for rotations used in tectonic
+modelling software such as GPlates.
+
+The flag --at is required and sets the time of the reconstruction, in million
+years. Only features that exist at that time will be reconstructed. Features
+of plates without a rotation at the given time will be skipped, and the
+number of skipped features will be reported on the standard error.
+
+The resulting GeoJSON will be written to the standard output. Use the
+--output or -o flag to specify an output file.
+
+Use the flag --log to set the format of the messages printed on the standard
+error. By default, the messages are human readable ("text"). If the format is
+"json", each step of the command (files read and written, and features without
+rotation) will be printed as a JSON object, one per line.
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var atFlag float64
+var output string
+var rotFile string
+var logFlag string
+
+func setFlags(c *command.Command) {
+	c.Flags().Float64Var(&atFlag, "at", -1, "")
+	c.Flags().StringVar(&output, "output", "", "")
+	c.Flags().StringVar(&output, "o", "", "")
+	c.Flags().StringVar(&rotFile, "rot", "", "")
+	c.Flags().StringVar(&logFlag, "log", "text", "")
+}
+
+// MillionYears is used to transform ages
+// (a float in million years)
+// to an integer in years.
+const millionYears = 1_000_000
+
+func run(c *command.Command, args []string) error {
+	if rotFile == "" {
+		return c.UsageError("undefined value for --rot flag")
+	}
+	if atFlag < 0 {
+		return c.UsageError("undefined value for --at flag")
+	}
+	age := int64(atFlag * millionYears)
+	log, err := cmdlog.New(c.Stderr(), logFlag)
+	if err != nil {
+		return c.UsageError(fmt.Sprintf("flag --log: %v", err))
+	}
+
+	rot, err := readRotation(rotFile)
+	if err != nil {
+		return err
+	}
+	log.Debug("read rotation model", "event", "read", "file", rotFile, "plates", len(rot.Plates()))
+
+	if len(args) == 0 {
+		args = append(args, "-")
+	}
+
+	var rec []vector.Feature
+	var skipped int
+	for _, a := range args {
+		fs, err := readFeatures(c.Stdin(), a)
+		if err != nil {
+			return err
+		}
+		log.Debug("read features", "event", "read", "file", a, "features", len(fs))
+		for _, f := range fs {
+			if !f.AliveAt(age) {
+				continue
+			}
+			nf, ok := f.Reconstruct(rot, age)
+			if !ok {
+				skipped++
+				continue
+			}
+			rec = append(rec, nf)
+		}
+	}
+	if skipped > 0 {
+		msg := fmt.Sprintf("features without rotation at %.6f Ma: %d", float64(age)/millionYears, skipped)
+		log.Info(msg, "event", "no-rotation", "age", age, "features", skipped)
+	}
+
+	if err := write(c.Stdout(), output, rec); err != nil {
+		return err
+	}
+	log.Debug("write reconstructed features", "event", "write", "file", output, "features", len(rec))
+	return nil
+}
+
+func readRotation(name string) (rotation.Rotation, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return rotation.Rotation{}, err
+	}
+	defer f.Close()
+
+	rot, err := rotation.Read(f)
+	if err != nil {
+		return rotation.Rotation{}, fmt.Errorf("when reading file %q: %v", name, err)
+	}
+	return rot, nil
+}
+
+func readFeatures(r io.Reader, name string) ([]vector.Feature, error) {
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	} else {
+		name = "stdin"
+	}
+
+	fs, err := vector.DecodeGPML(r)
+	if err != nil {
+		return nil, fmt.Errorf("while reading from %q: %v", name, err)
+	}
+
+	return fs, nil
+}
+
+func write(w io.Writer, name string, fs []vector.Feature) (err error) {
+	if name != "" {
+		f, err := os.Create(name)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			e := f.Close()
+			if e != nil && err == nil {
+				err = e
+			}
+		}()
+		w = f
+	} else {
+		name = "stdout"
+	}
+
+	if err := vector.EncodeGeoJSON(w, fs); err != nil {
+		return fmt.Errorf("when writing on file %q: %v", name, err)
+	}
+	return nil
+}
@@ -16,12 +16,14 @@ import (
 
 	"github.com/js-arias/command"
 	"github.com/js-arias/earth"
+	"github.com/js-arias/earth/cmd/plates/cmdlog"
 	"github.com/js-arias/earth/model"
 	"github.com/js-arias/earth/rotation"
 )
 
 var Command = &command.Command{
 	Usage: `rotate [--from <age>] [--to <age>] [--step <age>]
+	[--fixed <plate>] [--progress] [--strict] [--log <format>]
 	--pix <pix-file> --rot <rotation-file>
 	<model-file> [<age>...]`,
 	Short: "rotate pixels of a plate motion model",
@@ -48,6 +50,30 @@ the model to its paleo-location, given the rotation model. If no stages are
 defined, the flags --from, --to, and --step, can be used to define the oldest
 stage (--from), the most recent stage (--to, default is 0), and the size of
 each time interval (--step, default is 5).
+
+By default, pixel locations are in the absolute reference frame of the
+rotation model (usually a mantle or paleomagnetic frame). Use the flag --fixed
+to set the ID of a plate that will be kept fixed (e.g. 701 for an
+Africa-fixed frame). In that case, the locations of the pixels of each plate
+will be rotated using the rotation relative to the fixed plate, so the fixed
+plate will stay at its present location at all time stages, and the other
+plates will move relative to it. If the rotation of the fixed plate is
+undefined at a time stage, no pixel will be rotated at that stage. All
+time stages of a plate motion model should use the same reference frame.
+
+If the flag --progress is defined, the number of processed plates will be
+printed on the standard error.
+
+If a plate has pixels alive at a time stage, but the rotation model does not
+define a rotation for the plate at that age, the pixels will not be rotated,
+and the number of pixels without rotation will be reported on the standard
+error. If the flag --strict is defined, the command will fail if any plate
+has pixels without rotation, and the model will not be written.
+
+Use the flag --log to set the format of the messages printed on the standard
+error. By default, the messages are human readable ("text"). If the format is
+"json", each step of the command (files read and written, stages processed,
+and pixels without rotation) will be printed as a JSON object, one per line.
 	`,
 	SetFlags: setFlags,
 	Run:      run,
@@ -58,6 +84,10 @@ var toFlag float64
 var stepFlag float64
 var pixFile string
 var rotFile string
+var progressFlag bool
+var strictFlag bool
+var fixedFlag int
+var logFlag string
 
 func setFlags(c *command.Command) {
 	c.Flags().Float64Var(&fromFlag, "from", 0, "")
@@ -65,6 +95,10 @@ func setFlags(c *command.Command) {
 	c.Flags().Float64Var(&stepFlag, "step", 5, "")
 	c.Flags().StringVar(&pixFile, "pix", "", "")
 	c.Flags().StringVar(&rotFile, "rot", "", "")
+	c.Flags().BoolVar(&progressFlag, "progress", false, "")
+	c.Flags().BoolVar(&strictFlag, "strict", false, "")
+	c.Flags().IntVar(&fixedFlag, "fixed", -1, "")
+	c.Flags().StringVar(&logFlag, "log", "text", "")
 }
 
 // MillionYears is used to transform ages
@@ -82,6 +116,10 @@ func run(c *command.Command, args []string) error {
 	if rotFile == "" {
 		return c.UsageError("undefined value for --rot flag")
 	}
+	log, err := cmdlog.New(c.Stderr(), logFlag)
+	if err != nil {
+		return c.UsageError(fmt.Sprintf("flag --log: %v", err))
+	}
 
 	modFile := args[0]
 
@@ -110,24 +148,49 @@ func run(c *command.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	log.Debug("read pixelated plates", "event", "read", "file", pixFile, "plates", len(pp.Plates()))
 	rot, err := readRotation(rotFile)
 	if err != nil {
 		return err
 	}
+	log.Debug("read rotation model", "event", "read", "file", rotFile, "plates", len(rot.Plates()))
 	rec, err := readRecons(modFile, pp.Pixelation())
 	if err != nil {
 		return err
 	}
+	log.Debug("read plate motion model", "event", "read", "file", modFile, "stages", len(rec.Stages()))
 
-	for _, p := range pp.Plates() {
+	var missing int
+	plates := pp.Plates()
+	for i, p := range plates {
 		for _, a := range ages {
-			makeRotation(rec, pp, rot, p, a)
+			var n int
+			if fixedFlag >= 0 {
+				n = rec.AddRelativeRotation(pp, rot, p, fixedFlag, a)
+			} else {
+				n = rec.AddRotation(pp, rot, p, a)
+			}
+			if n == 0 {
+				continue
+			}
+			msg := fmt.Sprintf("plate %d: pixels without rotation at %.6f Ma: %d", p, float64(a)/millionYears, n)
+			log.Info(msg, "event", "no-rotation", "plate", p, "age", a, "pixels", n)
+			missing += n
+		}
+		if progressFlag {
+			msg := fmt.Sprintf("plate %d done [%d of %d plates, %d stages]", p, i+1, len(plates), len(ages))
+			log.Info(msg, "event", "progress", "plate", p, "done", i+1, "plates", len(plates), "stages", len(ages))
 		}
 	}
 
+	if strictFlag && missing > 0 {
+		return fmt.Errorf("rotation model %q: undefined rotations for %d pixels", rotFile, missing)
+	}
+
 	if err := writeRecons(modFile, rec); err != nil {
 		return err
 	}
+	log.Debug("write plate motion model", "event", "write", "file", modFile, "stages", len(rec.Stages()))
 
 	return nil
 }
@@ -176,60 +239,6 @@ func readRecons(name string, pix *earth.Pixelation) (*model.Recons, error) {
 	return rec, nil
 }
 
-func makeRotation(rec *model.Recons, pp *model.PixPlate, rot rotation.Rotation, plate int, age int64) {
-	r, ok := rot.Rotation(plate, age)
-	if !ok {
-		return
-	}
-
-	l := pp.Pixels(plate)
-	locs := make(map[int][]int, len(l))
-	pix := make(map[int]bool, len(l))
-	used := make(map[int]bool, len(l))
-	first := pp.Pixelation().Len()
-	last := 0
-	for _, id := range l {
-		px := pp.Pixel(plate, id)
-		if px.Begin < age || px.End > age {
-			continue
-		}
-		pix[id] = true
-		pt := pp.Pixelation().ID(id).Point().Vector()
-		v := r.Rotate(pt)
-		np := pp.Pixelation().FromVector(v)
-		locs[id] = []int{np.ID()}
-		used[np.ID()] = true
-		if np.ID() < first {
-			first = np.ID()
-		}
-		if np.ID() > last {
-			last = np.ID()
-		}
-	}
-
-	// Get "present" pixels from "past" pixels
-	// so we are sure that every pixel in the past
-	// has an assignment in the present.
-	// This reduce the number of "holes" produced
-	// when a rotation is performed
-	// because of the discrete nature of the pixelation.
-	inv := rotation.Inverse(r)
-	for id := first; id <= last; id++ {
-		if used[id] {
-			continue
-		}
-		np := pp.Pixelation().ID(id).Point().Vector()
-		v := inv.Rotate(np)
-		px := pp.Pixelation().FromVector(v)
-		if !pix[px.ID()] {
-			continue
-		}
-		locs[px.ID()] = append(locs[px.ID()], id)
-	}
-
-	rec.Add(plate, locs, age)
-}
-
 func writeRecons(name string, rec *model.Recons) (err error) {
 	f, err := os.Create(name)
 	if err != nil {
@@ -0,0 +1,88 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package rotate_test
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/js-arias/earth/cmd/plates/rotate"
+)
+
+// makeInput writes a pixelated plates file
+// with a plate whose rotation ends
+// before its pixels do,
+// and returns the name of the files.
+func makeInput(t testing.TB) (pix, rot string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	pix = filepath.Join(dir, "plates.tab")
+	p := "equator\tplate\tpixel\tbegin\tend\n360\t801\t100\t150000000\t0\n360\t801\t20000\t50000000\t0\n"
+	if err := os.WriteFile(pix, []byte(p), 0o644); err != nil {
+		t.Fatalf("unable to write pixelated plates: %v", err)
+	}
+
+	// the rotation of the plate
+	// is only defined up to 100 Ma
+	rot = filepath.Join(dir, "model.rot")
+	r := "801 0.0 90.0 0.0 0.0 0\n801 100.0 13.0 35.0 -50.0 0\n"
+	if err := os.WriteFile(rot, []byte(r), 0o644); err != nil {
+		t.Fatalf("unable to write rotation file: %v", err)
+	}
+	return pix, rot
+}
+
+func TestRotationGap(t *testing.T) {
+	pix, rot := makeInput(t)
+	mod := filepath.Join(t.TempDir(), "recons.tab")
+
+	var stdout, stderr bytes.Buffer
+	rotate.Command.SetStdout(&stdout)
+	rotate.Command.SetStderr(&stderr)
+	args := []string{"--pix", pix, "--rot", rot, mod, "0", "80", "120"}
+	if err := rotate.Command.Execute(args); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := "plate 801: pixels without rotation at 120.000000 Ma: 1\n"
+	if got := stderr.String(); got != want {
+		t.Errorf("stderr: got %q, want %q", got, want)
+	}
+	if _, err := os.Stat(mod); err != nil {
+		t.Errorf("model file: %v", err)
+	}
+}
+
+func TestRotationGapStrict(t *testing.T) {
+	pix, rot := makeInput(t)
+	mod := filepath.Join(t.TempDir(), "recons.tab")
+
+	var stdout, stderr bytes.Buffer
+	rotate.Command.SetStdout(&stdout)
+	rotate.Command.SetStderr(&stderr)
+	args := []string{"--strict", "--pix", pix, "--rot", rot, mod, "0", "80", "120"}
+	err := rotate.Command.Execute(args)
+	if err == nil {
+		t.Fatalf("expecting error with --strict")
+	}
+	if !strings.Contains(err.Error(), "undefined rotations for 1 pixels") {
+		t.Errorf("error: got %q, want undefined rotations for %d pixels", err, 1)
+	}
+	if _, err := os.Stat(mod); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("model file: got %v, want file not written", err)
+	}
+
+	// without gaps
+	args = []string{"--strict", "--pix", pix, "--rot", rot, mod, "0", "80"}
+	if err := rotate.Command.Execute(args); err != nil {
+		t.Errorf("without gaps: unexpected error: %v", err)
+	}
+}
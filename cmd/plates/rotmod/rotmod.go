@@ -10,6 +10,7 @@ import (
 	"github.com/js-arias/command"
 	"github.com/js-arias/earth/cmd/plates/rotmod/euler"
 	"github.com/js-arias/earth/cmd/plates/rotmod/plates"
+	"github.com/js-arias/earth/cmd/plates/rotmod/stages"
 )
 
 var Command = &command.Command{
@@ -20,4 +21,5 @@ var Command = &command.Command{
 func init() {
 	Command.Add(euler.Command)
 	Command.Add(plates.Command)
+	Command.Add(stages.Command)
 }
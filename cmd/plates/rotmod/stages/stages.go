@@ -0,0 +1,112 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package stages implements a command to print
+// the stage rotations of a plate.
+package stages
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/earth"
+	"github.com/js-arias/earth/rotation"
+)
+
+var Command = &command.Command{
+	Usage: "stages <rotation-model> [<plate>...]",
+	Short: "print stage rotations of a plate",
+	Long: `
+Command stages reads a rotation model and prints the stage rotations for the
+plates in that model.
+
+A rotation model stores total rotations, anchored in the present day. A stage
+rotation is the rotation of a plate, relative to its fixed plate, between two
+consecutive total rotations, and it moves a point of the plate from its
+location at the oldest age of the stage, to its location at the youngest age
+(i.e. forward in time). Consecutive total rotations with different fixed
+plates, or with the same age, are ignored.
+
+The first argument of the command is the name of the file that contains the
+rotation model. One or more plate IDs can be given as additional arguments
+(in any order, repeated plates are printed only once). If no plate is given,
+the command will print the stage rotations of all plates in the model.
+
+The output is a tab-delimited table, sorted by plate and age, with the
+following columns:
+
+	- plate  the ID of the moving plate
+	- young  the youngest age of the stage, in million years
+	- old    the oldest age of the stage, in million years
+	- lat    the latitude of the stage pole
+	- lon    the longitude of the stage pole
+	- angle  the angle of the rotation in degrees (always positive)
+	- fixed  the ID of the fixed plate
+	`,
+	Run: run,
+}
+
+func run(c *command.Command, args []string) error {
+	if len(args) < 1 {
+		return c.UsageError("expecting rotation model file")
+	}
+
+	rot, err := readRotationModel(args[0])
+	if err != nil {
+		return err
+	}
+
+	var plates []int
+	args = args[1:]
+	for _, a := range args {
+		p, err := strconv.Atoi(a)
+		if err != nil {
+			return fmt.Errorf("invalid plate ID %q: %v", a, err)
+		}
+		plates = append(plates, p)
+	}
+	if len(args) == 0 {
+		plates = rot.Plates()
+	}
+	slices.Sort(plates)
+	plates = slices.Compact(plates)
+
+	fmt.Fprintf(c.Stdout(), "plate\tyoung\told\tlat\tlon\tangle\tfixed\n")
+	for _, p := range plates {
+		printStages(c.Stdout(), rot, p)
+	}
+
+	return nil
+}
+
+func readRotationModel(name string) (rotation.Rotation, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return rotation.Rotation{}, err
+	}
+	defer f.Close()
+
+	rot, err := rotation.Read(f)
+	if err != nil {
+		return rotation.Rotation{}, fmt.Errorf("when reading file %q: %v", name, err)
+	}
+	return rot, nil
+}
+
+const millionYears = 1_000_000
+
+func printStages(w io.Writer, rot rotation.Rotation, plate int) {
+	for _, s := range rot.StagePoles(plate) {
+		young := strconv.FormatFloat(float64(s.Young)/millionYears, 'f', -1, 64)
+		old := strconv.FormatFloat(float64(s.Old)/millionYears, 'f', -1, 64)
+		lat := s.E.Latitude()
+		lon := s.E.Longitude()
+		a := earth.ToDegree(s.Angle)
+		fmt.Fprintf(w, "%d\t%s\t%s\t%.3f\t%.3f\t%.3f\t%d\n", plate, young, old, lat, lon, a, s.Fix)
+	}
+}
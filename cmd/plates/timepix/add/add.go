@@ -17,13 +17,15 @@ import (
 
 	"github.com/js-arias/command"
 	"github.com/js-arias/earth"
+	"github.com/js-arias/earth/cmd/plates/dryrun"
 	"github.com/js-arias/earth/model"
 )
 
 var Command = &command.Command{
-	Usage: `add [--from <age>] [--to <age>] [--at <age>]
-	[-f|--format <format>]
-	[--source <value>] [--only <value>] --val <value>
+	Usage: `add [--from <age>] [--to <age>] [--at <age>] [--compact]
+	[--dry-run] [-f|--format <format>]
+	[--source <value>] [--only <value>] [--combine <rule>]
+	--val <value>
 	--in <model-file>
 	<time-pix-file>`,
 	Short: "add pixels to a time pixelation",
@@ -51,9 +53,19 @@ same as the value to be added. To read a different source pixel value, use the
 --source flag.
 
 The flag --val is required and sets the value used for the pixels to be
-assigned. If the pixel has a value already, the largest value will be stored.
-With the flag --only, only the pixels defined with the given value in the
-destination pixelation will be modified.
+assigned. If the pixel has a value already, by default the largest value will
+be stored. Use the flag --combine to define a different rule to combine the
+new value with the value already stored. Valid rules are:
+
+	keep       keep the stored value
+	max        default value, keep the largest value
+	min        keep the smallest value
+	overwrite  always use the new value
+
+In all rules, pixels without a value (or with a value of 0) will be set to the
+new value. With the flag --only, only the pixels defined with the given value
+in the destination pixelation will be modified; in that case the default rule
+is overwrite.
 
 The argument of the command is the file that contains the time pixelation. If
 the files does not exist, it will create a new file, if it exists, pixels will
@@ -63,6 +75,15 @@ By default, all time stages of the source model (as defined by --in) will be
 used. With the flags --from and --to, it will use only the stages inside of the
 indicated ages (in million years). Another possibility is using the flag --at
 to set a particular time stage.
+
+If the flag --compact is defined, pixels with a value of 0 will be removed
+from the time pixelation before writing it, and time stages without pixels
+will be also removed.
+
+If the flag --dry-run is defined, the time pixelation file will not be
+modified. Instead, a summary of the changes will be printed in the standard
+output: the number of time stages and pixels that would be modified, and the
+number of pixels of each value transition at each time stage.
 	`,
 	SetFlags: setFlags,
 	Run:      run,
@@ -76,14 +97,20 @@ var onlyFlag int
 var fromFlag float64
 var toFlag float64
 var atFlag float64
+var compact bool
+var dryRun bool
+var combineFlag string
 
 func setFlags(c *command.Command) {
+	c.Flags().BoolVar(&compact, "compact", false, "")
+	c.Flags().BoolVar(&dryRun, "dry-run", false, "")
 	c.Flags().Float64Var(&fromFlag, "from", -1, "")
 	c.Flags().Float64Var(&toFlag, "to", -1, "")
 	c.Flags().Float64Var(&atFlag, "at", -1, "")
 	c.Flags().IntVar(&onlyFlag, "only", -1, "")
 	c.Flags().IntVar(&srcFlag, "source", -1, "")
 	c.Flags().IntVar(&valFlag, "val", -1, "")
+	c.Flags().StringVar(&combineFlag, "combine", "", "")
 	c.Flags().StringVar(&format, "format", "model", "")
 	c.Flags().StringVar(&format, "f", "model", "")
 	c.Flags().StringVar(&inFlag, "in", "", "")
@@ -106,7 +133,19 @@ func run(c *command.Command, args []string) error {
 	}
 	output := args[0]
 
-	var tp *model.TimePix
+	rule := model.CombineMax
+	if onlyFlag > 0 {
+		rule = model.CombineOverwrite
+	}
+	if combineFlag != "" {
+		var err error
+		rule, err = model.ParseCombineRule(combineFlag)
+		if err != nil {
+			return c.UsageError(fmt.Sprintf("flag --combine: %v", err))
+		}
+	}
+
+	var tp, orig *model.TimePix
 
 	if format == "" {
 		format = "model"
@@ -123,11 +162,11 @@ func run(c *command.Command, args []string) error {
 			stages = []int64{tot.ClosestStageAge(int64(atFlag * millionYears))}
 		} else {
 			st := tot.Stages()
-			from := st[len(st)-1]
+			from, _ := tot.LastStage()
 			if fromFlag >= 0 {
 				from = int64(fromFlag * millionYears)
 			}
-			to := st[0]
+			to, _ := tot.FirstStage()
 			if toFlag >= 0 {
 				to = int64(toFlag * millionYears)
 			}
@@ -150,7 +189,10 @@ func run(c *command.Command, args []string) error {
 		if err != nil {
 			return err
 		}
-		setTimeValue(tp, tot, stages)
+		if dryRun {
+			orig = tp.Clone()
+		}
+		setTimeValue(tp, tot, stages, rule)
 	case "mask":
 		if atFlag < 0 {
 			return fmt.Errorf("flag --at must be set for an image map")
@@ -166,8 +208,11 @@ func run(c *command.Command, args []string) error {
 		if err != nil {
 			return err
 		}
+		if dryRun {
+			orig = tp.Clone()
+		}
 
-		setMaskValue(tp, mask, age)
+		setMaskValue(tp, mask, age, rule)
 	case "pix":
 		if atFlag < 0 {
 			return fmt.Errorf("flag --at must be set for an image map")
@@ -183,7 +228,10 @@ func run(c *command.Command, args []string) error {
 		if err != nil {
 			return err
 		}
-		setPixValue(tp, pp, age)
+		if dryRun {
+			orig = tp.Clone()
+		}
+		setPixValue(tp, pp, age, rule)
 	case "timepix":
 		src, err := readSourceTimePix(inFlag)
 		if err != nil {
@@ -195,11 +243,11 @@ func run(c *command.Command, args []string) error {
 			stages = []int64{src.ClosestStageAge(int64(atFlag * millionYears))}
 		} else {
 			st := src.Stages()
-			from := st[len(st)-1]
+			from, _ := src.LastStage()
 			if fromFlag >= 0 {
 				from = int64(fromFlag * millionYears)
 			}
-			to := st[0]
+			to, _ := src.FirstStage()
 			if toFlag >= 0 {
 				to = int64(toFlag * millionYears)
 			}
@@ -226,39 +274,40 @@ func run(c *command.Command, args []string) error {
 		if err != nil {
 			return err
 		}
-		setTimePixValue(tp, src, stages)
+		if dryRun {
+			orig = tp.Clone()
+		}
+		setTimePixValue(tp, src, stages, rule)
 	default:
 		return fmt.Errorf("format %q, not known", format)
 	}
 
+	if compact {
+		tp.Compact()
+	}
+	if dryRun {
+		dryrun.Report(c.Stdout(), orig.Diff(tp))
+		return nil
+	}
 	if err := writeTimePix(output, tp); err != nil {
 		return err
 	}
 	return nil
 }
 
-func setTimeValue(tp *model.TimePix, tot *model.Total, ages []int64) {
+func setTimeValue(tp *model.TimePix, tot *model.Total, ages []int64, rule model.CombineRule) {
 	for _, a := range ages {
 		st := tot.Rotation(a)
 		if st == nil {
 			continue
 		}
 		for id := range st {
-			v, _ := tp.At(a, id)
-			if onlyFlag > 0 {
-				if onlyFlag == v {
-					tp.Set(a, id, valFlag)
-				}
-				continue
-			}
-			if valFlag > v {
-				tp.Set(a, id, valFlag)
-			}
+			setValue(tp, a, id, rule)
 		}
 	}
 }
 
-func setTimePixValue(tp *model.TimePix, src *model.TimePix, ages []int64) {
+func setTimePixValue(tp *model.TimePix, src *model.TimePix, ages []int64, rule model.CombineRule) {
 	for _, a := range ages {
 		st := src.Stage(a)
 		if st == nil {
@@ -268,67 +317,59 @@ func setTimePixValue(tp *model.TimePix, src *model.TimePix, ages []int64) {
 			if pv != srcFlag {
 				continue
 			}
-			v, _ := tp.At(a, id)
-			if onlyFlag > 0 {
-				if onlyFlag == v {
-					tp.Set(a, id, valFlag)
-				}
-				continue
-			}
-			if valFlag > v {
-				tp.Set(a, id, valFlag)
-			}
+			setValue(tp, a, id, rule)
 		}
 	}
 }
 
-func setMaskValue(tp *model.TimePix, mask image.Image, age int64) {
-	stepX := float64(360) / float64(mask.Bounds().Dx())
-	stepY := float64(180) / float64(mask.Bounds().Dy())
-
+func setMaskValue(tp *model.TimePix, mask image.Image, age int64, rule model.CombineRule) {
 	pix := tp.Pixelation()
 	for px := 0; px < pix.Len(); px++ {
 		v, _ := tp.At(age, px)
-		if onlyFlag > 0 {
-			if onlyFlag != v {
-				continue
-			}
-		} else if v >= valFlag {
+		if onlyFlag > 0 && onlyFlag != v {
+			continue
+		}
+		nv, ok := rule.Combine(v, valFlag)
+		if !ok {
 			continue
 		}
 
 		pt := pix.ID(px).Point()
-		x := int((pt.Longitude() + 180) / stepX)
-		y := int((90 - pt.Latitude()) / stepY)
+		x, y := earth.PlateCarreeXY(pt.Latitude(), pt.Longitude(), mask.Bounds().Dx(), mask.Bounds().Dy())
 		if r, _, _, _ := mask.At(x, y).RGBA(); r < 1000 {
 			continue
 		}
 
-		tp.Set(age, px, valFlag)
+		tp.Set(age, px, nv)
 	}
 }
 
-func setPixValue(tp *model.TimePix, pp *model.PixPlate, age int64) {
+func setPixValue(tp *model.TimePix, pp *model.PixPlate, age int64, rule model.CombineRule) {
 	for _, p := range pp.Plates() {
 		for _, id := range pp.Pixels(p) {
 			px := pp.Pixel(p, id)
-			if px.Begin < age || px.End > age {
+			if !px.AliveAt(age) {
 				continue
 			}
-			v, _ := tp.At(age, id)
-			if onlyFlag > 0 {
-				if onlyFlag == v {
-					tp.Set(age, id, valFlag)
-				}
-				continue
-			}
-			if valFlag > v {
-				tp.Set(age, id, valFlag)
-			}
+			setValue(tp, age, id, rule)
 		}
 	}
 }
 
+// SetValue sets the value of a pixel
+// using a combine rule.
+// If the flag --only is defined,
+// only pixels with that value will be modified.
+func setValue(tp *model.TimePix, age int64, pixel int, rule model.CombineRule) {
+	v, _ := tp.At(age, pixel)
+	if onlyFlag > 0 && onlyFlag != v {
+		return
+	}
+	if nv, ok := rule.Combine(v, valFlag); ok {
+		tp.Set(age, pixel, nv)
+	}
+}
+
 func readTimePix(name string, pix *earth.Pixelation) (*model.TimePix, error) {
 	f, err := os.Open(name)
 	if errors.Is(err, os.ErrNotExist) {
@@ -0,0 +1,48 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package add_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/js-arias/earth/cmd/plates/timepix/add"
+)
+
+func TestDryRun(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "tp.tab")
+	data := []byte("equator\tage\tstage-pixel\tvalue\n360\t0\t100\t3\n360\t0\t20000\t1\n")
+	if err := os.WriteFile(name, data, 0o644); err != nil {
+		t.Fatalf("unable to write time pixelation: %v", err)
+	}
+	src := filepath.Join(dir, "src.tab")
+	srcData := []byte("equator\tage\tstage-pixel\tvalue\n360\t0\t200\t7\n360\t0\t20000\t0\n")
+	if err := os.WriteFile(src, srcData, 0o644); err != nil {
+		t.Fatalf("unable to write source time pixelation: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	add.Command.SetStdout(&stdout)
+	add.Command.SetStderr(&stderr)
+	if err := add.Command.Execute([]string{"--dry-run", "-f", "timepix", "--val", "7", "--in", src, name}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatalf("unable to read time pixelation: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("file modified: got %q, want %q", got, data)
+	}
+
+	want := "# stages: 1\n# pixels: 1\nage\told\tnew\tpixels\n0.000000\t0\t7\t1\n"
+	if stdout.String() != want {
+		t.Errorf("report: got %q, want %q", stdout.String(), want)
+	}
+}
@@ -13,18 +13,20 @@ import (
 
 	"github.com/js-arias/command"
 	"github.com/js-arias/earth"
+	"github.com/js-arias/earth/cmd/plates/dryrun"
 	"github.com/js-arias/earth/model"
 )
 
 var Command = &command.Command{
-	Usage: `change [--from <age>] [--to <age>] [--at <age>]
-	--old <value> --new <value> <time-pix-file>`,
+	Usage: `change [--from <age>] [--to <age>] [--at <age>] [--compact]
+	[--dry-run] --old <value> --new <value> <time-pix-file>`,
 	Short: "change pixel values of a time pixelation",
 	Long: `
 Command change reads a time pixelation model and changes its pixel values.
 
 The flag --old is required and is used to set the pixel value to be changed.
-The flag --new is required and is used to set the new value of the pixels.
+The flag --new is required and is used to set the new value of the pixels. If
+the new value is 0, the pixels will be removed.
 
 By default, all time stages of the time pixelation will be changed. With the
 flags --from and --to, it will change only the stages inside the indicated
@@ -33,6 +35,15 @@ a particular time stage.
 
 The argument of the command is the file that contains the time pixelation.
 This argument is required.
+
+If the flag --compact is defined, pixels with a value of 0 will be removed
+from the time pixelation before writing it, and time stages without pixels
+will be also removed.
+
+If the flag --dry-run is defined, the time pixelation file will not be
+modified. Instead, a summary of the changes will be printed in the standard
+output: the number of time stages and pixels that would be modified, and the
+number of pixels of each value transition at each time stage.
 	`,
 	SetFlags: setFlags,
 	Run:      run,
@@ -43,8 +54,12 @@ var newValue int
 var fromFlag float64
 var toFlag float64
 var atFlag float64
+var compact bool
+var dryRun bool
 
 func setFlags(c *command.Command) {
+	c.Flags().BoolVar(&compact, "compact", false, "")
+	c.Flags().BoolVar(&dryRun, "dry-run", false, "")
 	c.Flags().Float64Var(&fromFlag, "from", -1, "")
 	c.Flags().Float64Var(&toFlag, "to", -1, "")
 	c.Flags().Float64Var(&atFlag, "at", -1, "")
@@ -72,17 +87,21 @@ func run(c *command.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	var orig *model.TimePix
+	if dryRun {
+		orig = tp.Clone()
+	}
 
 	var stages []int64
 	if atFlag >= 0 {
 		stages = []int64{tp.ClosestStageAge(int64(atFlag * millionYears))}
 	} else {
 		st := tp.Stages()
-		from := st[len(st)-1]
+		from, _ := tp.LastStage()
 		if fromFlag >= 0 {
 			from = int64(fromFlag * millionYears)
 		}
-		to := st[0]
+		to, _ := tp.FirstStage()
 		if toFlag >= 0 {
 			to = int64(toFlag * millionYears)
 		}
@@ -102,8 +121,20 @@ func run(c *command.Command, args []string) error {
 		slices.Sort(stages)
 	}
 
-	setTimeValue(tp, stages)
+	tp.Apply(stages, func(_ int64, _, v int) int {
+		if v != oldValue {
+			return v
+		}
+		return newValue
+	})
 
+	if compact {
+		tp.Compact()
+	}
+	if dryRun {
+		dryrun.Report(c.Stdout(), orig.Diff(tp))
+		return nil
+	}
 	if err := writeTimePix(output, tp); err != nil {
 		return err
 	}
@@ -124,25 +155,6 @@ func readTimePix(name string, pix *earth.Pixelation) (*model.TimePix, error) {
 	return tp, nil
 }
 
-func setTimeValue(tp *model.TimePix, ages []int64) {
-	for _, a := range ages {
-		r := tp.Stage(a)
-		if r == nil {
-			continue
-		}
-		for pix := 0; pix < tp.Pixelation().Len(); pix++ {
-			v, ok := r[pix]
-			if !ok {
-				continue
-			}
-			if v != oldValue {
-				continue
-			}
-			tp.Set(a, pix, newValue)
-		}
-	}
-}
-
 func writeTimePix(name string, tp *model.TimePix) (err error) {
 	f, err := os.Create(name)
 	if err != nil {
@@ -0,0 +1,42 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package change_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/js-arias/earth/cmd/plates/timepix/change"
+)
+
+func TestDryRun(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "tp.tab")
+	data := []byte("equator\tage\tstage-pixel\tvalue\n360\t0\t100\t3\n360\t0\t20000\t1\n")
+	if err := os.WriteFile(name, data, 0o644); err != nil {
+		t.Fatalf("unable to write time pixelation: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	change.Command.SetStdout(&stdout)
+	change.Command.SetStderr(&stderr)
+	if err := change.Command.Execute([]string{"--dry-run", "--old", "3", "--new", "5", name}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatalf("unable to read time pixelation: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("file modified: got %q, want %q", got, data)
+	}
+
+	want := "# stages: 1\n# pixels: 1\nage\told\tnew\tpixels\n0.000000\t3\t5\t1\n"
+	if stdout.String() != want {
+		t.Errorf("report: got %q, want %q", stdout.String(), want)
+	}
+}
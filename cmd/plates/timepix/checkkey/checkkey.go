@@ -0,0 +1,131 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package checkkey implements a command to check
+// that all values of a time pixelation model
+// are defined in a key file.
+package checkkey
+
+import (
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/earth/model"
+	"github.com/js-arias/earth/pixkey"
+)
+
+var Command = &command.Command{
+	Usage: "check-key --key <key-file> <time-pix-file>",
+	Short: "check time pixelation values against a key",
+	Long: `
+Command check-key reads a time pixelation model and a key file, and reports
+any pixel value in the model that is not defined in the key.
+
+The flag --key is required and sets the key file. A key file is a tab-delimited
+file with the following required columns:
+
+	key	the value used as identifier
+	color	an RGB value separated by commas,
+		for example "125,132,148".
+
+The argument of the command is the name of the file that contains the time
+pixelation model.
+
+The output is a tab-delimited table with the following columns:
+
+	age	the age of the time stage (in million years)
+	value	the pixel value missing from the key
+	pixels	the number of pixels with that value in the stage
+
+If any value is missing from the key, the command will end with an error.
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var keyFlag string
+
+func setFlags(c *command.Command) {
+	c.Flags().StringVar(&keyFlag, "key", "", "")
+}
+
+// MillionYears is used to transform ages
+// an integer in years
+// to a float in million years.
+const millionYears = 1_000_000
+
+func run(c *command.Command, args []string) error {
+	if len(args) < 1 {
+		return c.UsageError("expecting time pixelation model file")
+	}
+	if keyFlag == "" {
+		return c.UsageError("flag --key must be set")
+	}
+
+	tp, err := readTimePix(args[0])
+	if err != nil {
+		return err
+	}
+	pk, err := readKey(keyFlag)
+	if err != nil {
+		return err
+	}
+
+	missing := 0
+	fmt.Fprintf(c.Stdout(), "age\tvalue\tpixels\n")
+	for _, a := range tp.Stages() {
+		count := make(map[int]int)
+		for _, v := range tp.Stage(a) {
+			if _, ok := pk.Color(v); ok {
+				continue
+			}
+			count[v]++
+		}
+
+		vals := make([]int, 0, len(count))
+		for v := range count {
+			vals = append(vals, v)
+		}
+		slices.Sort(vals)
+		for _, v := range vals {
+			fmt.Fprintf(c.Stdout(), "%.6f\t%d\t%d\n", float64(a)/millionYears, v, count[v])
+		}
+		missing += len(vals)
+	}
+
+	if missing > 0 {
+		return fmt.Errorf("file %q: found %d values undefined in key %q", args[0], missing, keyFlag)
+	}
+	return nil
+}
+
+func readTimePix(name string) (*model.TimePix, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	tp, err := model.ReadTimePix(f, nil)
+	if err != nil {
+		return nil, fmt.Errorf("when reading file %q: %v", name, err)
+	}
+	return tp, nil
+}
+
+func readKey(name string) (*pixkey.PixKey, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	pk, err := pixkey.Read(f)
+	if err != nil {
+		return nil, fmt.Errorf("when reading file %q: %v", name, err)
+	}
+	return pk, nil
+}
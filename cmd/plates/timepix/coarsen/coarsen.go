@@ -0,0 +1,144 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package coarsen implements a command to merge
+// the time stages of a time pixelation
+// into larger time stages.
+package coarsen
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/earth/model"
+)
+
+var Command = &command.Command{
+	Usage: `coarsen --step <age> [--rule <rule>]
+	[-o|--output <file>] <time-pix-file>`,
+	Short: "merge time stages of a time pixelation",
+	Long: `
+Command coarsen reads a time pixelation and merges its time stages into larger
+time stages.
+
+The argument of the command is the name of the file that contains the time
+pixelation.
+
+The flag --step is required, and sets the size of the new time stages (in
+million years). Time stages are grouped in bins: each bin includes the time
+stages from its age (a multiple of the step size), up to, but not including,
+the age of the next bin. For example, with a step of 10, the time stages at
+10, 11, and 19.9 million years will be merged into a single time stage at 10
+million years.
+
+By default, the merged time stage will have the largest value of each pixel in
+the bin. Use the flag --rule to define a different rule to combine the values
+of the pixels. Valid rules are:
+
+	max       default value, the largest value
+	min       the smallest value
+	oldest    the value of the oldest time stage with a value for the pixel
+	youngest  the value of the youngest time stage with a value for the pixel
+
+Pixels with a value of 0 are ignored.
+
+By default, the input file will be replaced by the merged time pixelation. Use
+the flag --output, or -o, to define a different output file.
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var stepFlag float64
+var ruleFlag string
+var output string
+
+func setFlags(c *command.Command) {
+	c.Flags().Float64Var(&stepFlag, "step", 0, "")
+	c.Flags().StringVar(&ruleFlag, "rule", "max", "")
+	c.Flags().StringVar(&output, "output", "", "")
+	c.Flags().StringVar(&output, "o", "", "")
+}
+
+// MillionYears is used to transform ages
+// (a float in million years)
+// to an integer in years.
+const millionYears = 1_000_000
+
+func run(c *command.Command, args []string) error {
+	if len(args) < 1 {
+		return c.UsageError("expecting time pixelation file")
+	}
+	step := int64(stepFlag * millionYears)
+	if step <= 0 {
+		return c.UsageError("flag --step must be set")
+	}
+	rule, err := parseRule(ruleFlag)
+	if err != nil {
+		return c.UsageError(err.Error())
+	}
+	if output == "" {
+		output = args[0]
+	}
+
+	tp, err := readTimePix(args[0])
+	if err != nil {
+		return err
+	}
+
+	if err := writeTimePix(output, tp.Coarsen(step, rule)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ParseRule returns the combine rule
+// for the values of the --rule flag.
+func parseRule(rule string) (model.CombineRule, error) {
+	switch strings.ToLower(rule) {
+	case "max":
+		return model.CombineMax, nil
+	case "min":
+		return model.CombineMin, nil
+	case "oldest":
+		return model.CombineOverwrite, nil
+	case "youngest":
+		return model.CombineKeep, nil
+	}
+	return 0, fmt.Errorf("flag --rule: unknown rule %q", rule)
+}
+
+func readTimePix(name string) (*model.TimePix, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	tp, err := model.ReadTimePix(f, nil)
+	if err != nil {
+		return nil, fmt.Errorf("when reading file %q: %v", name, err)
+	}
+	return tp, nil
+}
+
+func writeTimePix(name string, tp *model.TimePix) (err error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		e := f.Close()
+		if e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	if err := tp.TSV(f); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package coastline implements a command to print
+// the length of the boundary between land and ocean
+// at each time stage of a time pixelation.
+package coastline
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/earth"
+	"github.com/js-arias/earth/cmd/internal/intset"
+	"github.com/js-arias/earth/model"
+)
+
+var Command = &command.Command{
+	Usage: "coastline [--land <value>]... <time-pix-file>",
+	Short: "print the coastline length of a time pixelation",
+	Long: `
+Command coastline reads a time pixelation model and prints the length of the
+boundary between land and ocean pixels at each time stage.
+
+The argument of the command is the name of the file that contains the time
+pixelation model.
+
+By default, any pixel with a value (i.e. a value different from 0) is
+considered as land. Use the flag --land to define the pixel values that are
+land. The flag can be repeated to define several land values. All other pixel
+values are considered as ocean.
+
+The length is approximated from the pixel adjacencies: it is the sum of the
+great circle distances between the centers of each pair of adjacent pixels
+with one pixel in land and the other in the ocean. As the length is measured
+between pixel centers, instead of along the actual coastline, this
+approximation depends on the resolution of the pixelation, and overestimates
+the length of smooth coastlines (the length of a circular island can be more
+than twice its perimeter). Then, the length is only useful to compare
+coastlines in pixelations with the same resolution.
+
+The output is a tab-delimited table with the following columns:
+
+	- age     the age of the time stage, in million years
+	- length  the coastline length, in kilometers
+
+Rows are sorted from the youngest to the oldest time stage.
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var landFlag = intset.Set{}
+
+func setFlags(c *command.Command) {
+	c.Flags().Var(landFlag, "land", "")
+}
+
+// MillionYears is used to transform ages
+// (an integer in years)
+// to a float in million years.
+const millionYears = 1_000_000
+
+func run(c *command.Command, args []string) error {
+	if len(args) < 1 {
+		return c.UsageError("expecting time pixelation model file")
+	}
+
+	tp, err := readTimePix(args[0])
+	if err != nil {
+		return err
+	}
+
+	land := map[int]bool(landFlag)
+	if len(land) == 0 {
+		land = make(map[int]bool)
+		for _, a := range tp.Stages() {
+			for _, v := range tp.Stage(a) {
+				if v != 0 {
+					land[v] = true
+				}
+			}
+		}
+	}
+
+	if err := writeLength(c.Stdout(), tp, land); err != nil {
+		return fmt.Errorf("when writing on stdout: %v", err)
+	}
+	return nil
+}
+
+func writeLength(w io.Writer, tp *model.TimePix, land map[int]bool) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "age\tlength\n")
+
+	for _, a := range tp.Stages() {
+		km := tp.BoundaryLength(a, land) * earth.Radius / 1000
+		fmt.Fprintf(bw, "%.6f\t%.3f\n", float64(a)/millionYears, km)
+	}
+	return bw.Flush()
+}
+
+func readTimePix(name string) (*model.TimePix, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	tp, err := model.ReadTimePix(f, nil)
+	if err != nil {
+		return nil, fmt.Errorf("when reading file %q: %v", name, err)
+	}
+	return tp, nil
+}
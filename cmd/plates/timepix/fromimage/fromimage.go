@@ -0,0 +1,227 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package fromimage implements a command to set the pixel values
+// of a time pixelation
+// from the colors of an image.
+package fromimage
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/earth"
+	"github.com/js-arias/earth/cmd/plates/cmdlog"
+	"github.com/js-arias/earth/model"
+	"github.com/js-arias/earth/pixkey"
+)
+
+var Command = &command.Command{
+	Usage: `from-image [-e|--equator <value>] [--tolerance <value>]
+	[--log <format>]
+	--key <key-file> --at <age>
+	<image-file> <time-pix-file>`,
+	Short: "set pixel values from the colors of an image",
+	Long: `
+Command from-image reads an image in plate carrée projection (also known as
+equirectangular projection), and sets the value of each pixel of a time
+pixelation at a time stage, using the color of the image at the pixel center,
+and a key of pixel values and colors.
+
+The first argument of the command is the name of the image file. Valid image
+formats are PNG and JPEG.
+
+The second argument of the command is the name of the file that contains the
+time pixelation. If the file does not exist, it will create a new time
+pixelation. By default, a new time pixelation will have 360 pixels at the
+equator. Use the flag --equator, or -e, to change the number of pixels. If the
+file already exists, the pixels of the time stage will be replaced.
+
+The flag --key is required and sets the file with the key of pixel values and
+colors. The key file is a tab-delimited file with the following required
+columns:
+
+	- key    the value used as identifier
+	- color  an RGB value separated by commas, for example "125,132,148"
+
+The flag --at is required and sets the age of the time stage (in million
+years).
+
+Each pixel will be set with the value of the key with the closest color. The
+distance between two colors is the Euclidean distance between their red,
+green, and blue values (from 0 to 255). If the distance to the closest color
+is larger than 30, the color is unmatched. Use the flag --tolerance to set a
+different maximum distance; a negative value accepts any distance. Unmatched
+colors, as well as transparent points of the image, are set as value 0 (i.e.
+they will be undefined in the time pixelation). The number of unmatched pixels
+will be printed on the standard error.
+
+Use the flag --log to set the format of the messages printed on the standard
+error. By default, the messages are human readable ("text"). If the format is
+"json", each step of the command (files read and written, and unmatched pixels)
+will be printed as a JSON object, one per line.
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var equator int
+var atFlag float64
+var keyFlag string
+var tolFlag float64
+var logFlag string
+
+func setFlags(c *command.Command) {
+	c.Flags().IntVar(&equator, "equator", 360, "")
+	c.Flags().IntVar(&equator, "e", 360, "")
+	c.Flags().Float64Var(&atFlag, "at", -1, "")
+	c.Flags().Float64Var(&tolFlag, "tolerance", 30, "")
+	c.Flags().StringVar(&keyFlag, "key", "", "")
+	c.Flags().StringVar(&logFlag, "log", "text", "")
+}
+
+// MillionYears is used to transform ages in the flags
+// (floats in million years)
+// to an integer in years.
+const millionYears = 1_000_000
+
+func run(c *command.Command, args []string) error {
+	if len(args) < 2 {
+		return c.UsageError("expecting image file and time pixelation file")
+	}
+	if keyFlag == "" {
+		return c.UsageError("flag --key must be set")
+	}
+	if atFlag < 0 {
+		return c.UsageError("flag --at must be set")
+	}
+	age := int64(atFlag * millionYears)
+	log, err := cmdlog.New(c.Stderr(), logFlag)
+	if err != nil {
+		return c.UsageError(fmt.Sprintf("flag --log: %v", err))
+	}
+
+	pk, err := readKey(keyFlag)
+	if err != nil {
+		return err
+	}
+	log.Debug("read key", "event", "read", "file", keyFlag, "keys", len(pk.Keys()))
+	img, err := readImage(args[0])
+	if err != nil {
+		return err
+	}
+	log.Debug("read image", "event", "read", "file", args[0])
+	tp, err := readTimePix(args[1])
+	if err != nil {
+		return err
+	}
+	log.Debug("read time pixelation", "event", "read", "file", args[1], "stages", len(tp.Stages()))
+
+	if n := setImageValues(tp, img, pk, age); n > 0 {
+		msg := fmt.Sprintf("unmatched pixels: %d", n)
+		log.Info(msg, "event", "unmatched", "age", age, "pixels", n)
+	}
+
+	if err := writeTimePix(args[1], tp); err != nil {
+		return err
+	}
+	log.Debug("write time pixelation", "event", "write", "file", args[1], "stages", len(tp.Stages()))
+	return nil
+}
+
+// SetImageValues sets the values of the pixels
+// at a time stage
+// using the colors of an image.
+// It returns the number of unmatched pixels.
+func setImageValues(tp *model.TimePix, img image.Image, pk *pixkey.PixKey, age int64) int {
+	pix := tp.Pixelation()
+	b := img.Bounds()
+
+	var unmatched int
+	for px := 0; px < pix.Len(); px++ {
+		pt := pix.ID(px).Point()
+		x, y := earth.PlateCarreeXY(pt.Latitude(), pt.Longitude(), b.Dx(), b.Dy())
+		c := img.At(b.Min.X+x, b.Min.Y+y)
+
+		v, ok := pk.Nearest(c, tolFlag)
+		if _, _, _, a := c.RGBA(); a == 0 || !ok {
+			unmatched++
+			v = 0
+		}
+		if v == 0 {
+			tp.Del(age, px)
+			continue
+		}
+		tp.Set(age, px, v)
+	}
+	return unmatched
+}
+
+func readKey(name string) (*pixkey.PixKey, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	pk, err := pixkey.Read(f)
+	if err != nil {
+		return nil, fmt.Errorf("when reading file %q: %v", name, err)
+	}
+	return pk, nil
+}
+
+func readImage(name string) (image.Image, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("when decoding image %q: %v", name, err)
+	}
+	return img, nil
+}
+
+func readTimePix(name string) (*model.TimePix, error) {
+	f, err := os.Open(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return model.NewTimePix(earth.NewPixelation(equator)), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	tp, err := model.ReadTimePix(f, nil)
+	if err != nil {
+		return nil, fmt.Errorf("when reading file %q: %v", name, err)
+	}
+	return tp, nil
+}
+
+func writeTimePix(name string, tp *model.TimePix) (err error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		e := f.Close()
+		if e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	if err := tp.TSV(f); err != nil {
+		return fmt.Errorf("while writing to %q: %v", name, err)
+	}
+	return nil
+}
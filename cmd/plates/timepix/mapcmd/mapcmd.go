@@ -7,26 +7,24 @@
 package mapcmd
 
 import (
-	"encoding/csv"
-	"errors"
 	"fmt"
 	"image"
 	"image/color"
-	"image/png"
-	"io"
-	"math/rand"
+	"image/jpeg"
 	"os"
-	"strconv"
-	"strings"
 
-	"github.com/js-arias/blind"
 	"github.com/js-arias/command"
+	"github.com/js-arias/earth"
+	"github.com/js-arias/earth/cmd/internal/imgfmt"
+	"github.com/js-arias/earth/cmd/plates/cmdlog"
 	"github.com/js-arias/earth/model"
+	"github.com/js-arias/earth/pixkey"
 )
 
 var Command = &command.Command{
 	Usage: `map [-c|--columns <value>] [--at <age>]
-	[--key <key-file>] -o|--output <out-image-file>
+	[--key <key-file>] [--format <format>] [--quality <value>]
+	[--log <format>] -o|--output <out-image-file>
 	<time-pix-file>`,
 	Short: "draw a map from a time pixelation model",
 	Long: `
@@ -39,10 +37,10 @@ pixelation model.
 
 The flag --output, or -o, is required and sets the name of the output image. If
 multiple stages are used, the time stage will append to the name of the image.
-In the image all pixels with a given value will have the same color (selected
-at random). With the flag --key a key-file can be used to define the colors to
-be used in the output. A key file is a tab-delimited file with the following
-required columns:
+In the image all pixels with a given value will have the same color (derived
+from the value, so it will be the same in different images). With the flag
+--key a key-file can be used to define the colors to be used in the output. A
+key file is a tab-delimited file with the following required columns:
 
 	key	the value used as identifier
 	color	an RGB value separated by commas,
@@ -50,7 +48,7 @@ required columns:
 
 Any other column will be ignored. Here is an example of a key file:
 
-	key	color	gray	comment
+	key	color	gray	label
 	0	54, 75, 154	255	deep ocean
 	1	74, 123, 183	235	oceanic plateaus
 	2	152, 202, 225	225	continental shelf
@@ -58,13 +56,31 @@ Any other column will be ignored. Here is an example of a key file:
 	4	246, 126, 75	185	highlands
 	5	231, 231, 231	245	ice sheets
 
-In this case, gray a comment columns will be ignored.
+In this case, gray and label columns will be ignored.
 
 By default the image will be 3600 pixels wide, use the flag --columns, or -c,
 to define a different number of image columns.
 
 By default all time stages will be produced. Use the flag --at to define a
-particular time stage to be draw (in million years).
+particular time stage to be draw (in million years). If the age is not a time
+stage of the model, the closest time stage (i.e. the oldest time stage
+younger than the age, or the youngest time stage, if the age is younger than
+all time stages) will be used, and if that time stage is more than 5
+million years apart from the age, a warning will be printed in the standard
+error.
+
+By default, images are encoded as PNG files. Use the flag --format to define
+the image format, valid formats are "png" and "jpeg". If the flag is not
+defined, the format will be inferred from the extension of the output name
+(the extension will be removed before adding the time stage). Use the flag
+--quality to set the quality of JPEG images (from 1 to 100, default 75). Note
+that JPEG is a lossy format, and its artifacts make it inappropriate for maps
+of categorical values or masks.
+
+Use the flag --log to set the format of the messages printed on the standard
+error. By default, the messages are human readable ("text"). If the format is
+"json", each step of the command (files read and written, and stage warnings)
+will be printed as a JSON object, one per line.
 	`,
 	SetFlags: setFlags,
 	Run:      run,
@@ -74,12 +90,18 @@ var colsFlag int
 var atFlag float64
 var keyFlag string
 var output string
+var formatFlag string
+var qualityFlag int
+var logFlag string
 
 func setFlags(c *command.Command) {
 	c.Flags().IntVar(&colsFlag, "columns", 3600, "")
 	c.Flags().IntVar(&colsFlag, "c", 3600, "")
 	c.Flags().Float64Var(&atFlag, "at", -1, "")
 	c.Flags().StringVar(&keyFlag, "key", "", "")
+	c.Flags().IntVar(&qualityFlag, "quality", jpeg.DefaultQuality, "")
+	c.Flags().StringVar(&formatFlag, "format", "", "")
+	c.Flags().StringVar(&logFlag, "log", "text", "")
 	c.Flags().StringVar(&output, "output", "", "")
 	c.Flags().StringVar(&output, "o", "", "")
 }
@@ -89,6 +111,13 @@ func setFlags(c *command.Command) {
 // to an integer in years.
 const millionYears = 1_000_000
 
+// MaxStageGap is the maximum difference
+// (in years)
+// between a requested age
+// and the time stage used,
+// before printing a warning.
+const maxStageGap = 5 * millionYears
+
 func run(c *command.Command, args []string) error {
 	if len(args) == 0 {
 		return c.UsageError("expecting time pixelation model")
@@ -96,14 +125,36 @@ func run(c *command.Command, args []string) error {
 	if output == "" {
 		return c.UsageError("flag --output must be set")
 	}
+	format, err := imgfmt.Format(output, formatFlag)
+	if err != nil {
+		return c.UsageError(err.Error())
+	}
+	prefix := imgfmt.Prefix(output)
+	if err := imgfmt.CheckQuality(qualityFlag); err != nil {
+		return c.UsageError(fmt.Sprintf("flag --quality: %v", err))
+	}
+	log, err := cmdlog.New(c.Stderr(), logFlag)
+	if err != nil {
+		return c.UsageError(fmt.Sprintf("flag --log: %v", err))
+	}
 
 	tp, err := readTimePix(args[0])
 	if err != nil {
 		return err
 	}
+	log.Debug("read time pixelation", "event", "read", "file", args[0], "stages", len(tp.Stages()))
 	var ages []int64
 	if atFlag >= 0 {
-		ages = []int64{tp.ClosestStageAge(int64(atFlag * millionYears))}
+		age := int64(atFlag * millionYears)
+		if d := tp.StageDistance(age); d > maxStageGap {
+			msg := fmt.Sprintf("warning: closest time stage to %.6f Ma is %.6f million years apart", atFlag, float64(d)/millionYears)
+			log.Warn(msg, "event", "stage-gap", "age", age, "gap", d)
+		}
+		first, ok := tp.FirstStage()
+		if !ok {
+			return fmt.Errorf("on file %q: empty time pixelation", args[0])
+		}
+		ages = []int64{tp.ClosestStageAge(max(age, first))}
 	} else {
 		ages = tp.Stages()
 	}
@@ -119,10 +170,11 @@ func run(c *command.Command, args []string) error {
 	}
 
 	for _, a := range ages {
-		name := fmt.Sprintf("%s-%d.png", output, a/millionYears)
-		if err := writeImage(name, makeStage(tp, a, keys)); err != nil {
+		name := fmt.Sprintf("%s-%d.%s", prefix, a/millionYears, format)
+		if err := writeImage(name, makeStage(tp, a, keys), format); err != nil {
 			return err
 		}
+		log.Debug("write image", "event", "write", "file", name, "age", a)
 	}
 	return nil
 }
@@ -148,78 +200,16 @@ func readKey() (map[int]color.RGBA, error) {
 	}
 	defer f.Close()
 
-	r := csv.NewReader(f)
-	r.Comma = '\t'
-	r.Comment = '#'
-
-	head, err := r.Read()
+	pk, err := pixkey.Read(f)
 	if err != nil {
-		return nil, fmt.Errorf("while reading file %q: while reading header: %v", keyFlag, err)
-	}
-	fields := make(map[string]int, len(head))
-	for i, h := range head {
-		h = strings.ToLower(h)
-		fields[h] = i
-	}
-	for _, h := range []string{"key", "color"} {
-		if _, ok := fields[h]; !ok {
-			return nil, fmt.Errorf("while reading file %q: expecting field %q", keyFlag, h)
-		}
+		return nil, fmt.Errorf("while reading file %q: %v", keyFlag, err)
 	}
 
 	keys := make(map[int]color.RGBA)
-	for {
-		row, err := r.Read()
-		if errors.Is(err, io.EOF) {
-			break
-		}
-		ln, _ := r.FieldPos(0)
-		if err != nil {
-			return nil, fmt.Errorf("while reading file %q: on row %d: %v", keyFlag, ln, err)
-		}
-
-		f := "key"
-		k, err := strconv.Atoi(row[fields[f]])
-		if err != nil {
-			return nil, fmt.Errorf("while reading file %q: on row %d: %v", keyFlag, ln, err)
-		}
-
-		f = "color"
-		vals := strings.Split(row[fields[f]], ",")
-		if len(vals) != 3 {
-			return nil, fmt.Errorf("while reading file %q: on row %d: field %q: found %d values", keyFlag, ln, f, len(vals))
-		}
-
-		red, err := strconv.Atoi(strings.TrimSpace(vals[0]))
-		if err != nil {
-			return nil, fmt.Errorf("while reading file %q: on row %d: field %q [red value]: %v", keyFlag, ln, f, err)
-		}
-		if red > 255 {
-			return nil, fmt.Errorf("while reading file %q: on row %d: field %q [red value]: invalid value %d", keyFlag, ln, f, red)
-		}
-
-		green, err := strconv.Atoi(strings.TrimSpace(vals[1]))
-		if err != nil {
-			return nil, fmt.Errorf("while reading file %q: on row %d: field %q [green value]: %v", keyFlag, ln, f, err)
-		}
-		if green > 255 {
-			return nil, fmt.Errorf("while reading file %q: on row %d: field %q [green value]: invalid value %d", keyFlag, ln, f, green)
-		}
-
-		blue, err := strconv.Atoi(strings.TrimSpace(vals[2]))
-		if err != nil {
-			return nil, fmt.Errorf("while reading file %q: on row %d: field %q [blue value]: %v", keyFlag, ln, f, err)
-		}
-		if blue > 255 {
-			return nil, fmt.Errorf("while reading file %q: on row %d: field %q [blue value]: invalid value %d", keyFlag, ln, f, blue)
-		}
-
-		c := color.RGBA{uint8(red), uint8(green), uint8(blue), 255}
+	for _, k := range pk.Keys() {
+		c, _ := pk.Color(k)
 		keys[k] = c
 	}
-	if len(keys) == 0 {
-		return nil, fmt.Errorf("while reading file %q: %v", keyFlag, io.EOF)
-	}
 	return keys, nil
 }
 
@@ -231,19 +221,14 @@ func makeKeyPalette(tp *model.TimePix, ages []int64) map[int]color.RGBA {
 			if _, ok := keys[v]; ok {
 				continue
 			}
-			keys[v] = randColor()
+			keys[v] = pixkey.ColorFor(v)
 		}
 	}
 	return keys
 }
 
-func randColor() color.RGBA {
-	return blind.Sequential(blind.Iridescent, rand.Float64())
-}
-
 // A stagePix stores a time pixelation
 type stagePix struct {
-	step float64
 	age  int64
 	keys map[int]color.RGBA
 	tp   *model.TimePix
@@ -252,8 +237,7 @@ type stagePix struct {
 func (s stagePix) ColorModel() color.Model { return color.RGBAModel }
 func (s stagePix) Bounds() image.Rectangle { return image.Rect(0, 0, colsFlag, colsFlag/2) }
 func (s stagePix) At(x, y int) color.Color {
-	lat := 90 - float64(y)*s.step
-	lon := float64(x)*s.step - 180
+	lat, lon := earth.PlateCarreeLatLon(x, y, colsFlag, colsFlag/2)
 
 	pix := s.tp.Pixelation().Pixel(lat, lon).ID()
 	v, _ := s.tp.At(s.age, pix)
@@ -266,14 +250,13 @@ func (s stagePix) At(x, y int) color.Color {
 
 func makeStage(tp *model.TimePix, age int64, keys map[int]color.RGBA) stagePix {
 	return stagePix{
-		step: 360 / float64(colsFlag),
 		age:  age,
 		keys: keys,
 		tp:   tp,
 	}
 }
 
-func writeImage(name string, sp stagePix) (err error) {
+func writeImage(name string, sp stagePix, format string) (err error) {
 	f, err := os.Create(name)
 	if err != nil {
 		return err
@@ -285,7 +268,7 @@ func writeImage(name string, sp stagePix) (err error) {
 		}
 	}()
 
-	if err := png.Encode(f, sp); err != nil {
+	if err := imgfmt.Encode(f, sp, format, qualityFlag); err != nil {
 		return fmt.Errorf("when encoding image file %q: %v", name, err)
 	}
 	return nil
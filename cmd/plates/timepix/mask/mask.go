@@ -0,0 +1,217 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package mask implements a command to draw
+// a black and white mask image
+// from a time pixelation.
+package mask
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/earth"
+	"github.com/js-arias/earth/cmd/internal/imgfmt"
+	"github.com/js-arias/earth/model"
+)
+
+var Command = &command.Command{
+	Usage: `mask [-c|--columns <value>] [--at <age>]
+	[--format <format>] [--quality <value>]
+	--land <value>[,<value>...] -o|--output <out-image-file>
+	<time-pix-file>`,
+	Short: "draw a mask image from a time pixelation model",
+	Long: `
+Command mask reads a time pixelation model from a file and draws a black and
+white mask of the pixel values at the indicated time stage as an image,
+using a plate carrée projection.
+
+The argument of the command is the name of the file that contains the time
+pixelation model.
+
+The flag --land is required and defines the pixel values, separated by commas,
+that will be drawn in white (for example "3,4,5"). All other pixels will be
+drawn in black. The resulting image can be used as a mask in the command
+"plates timepix add --format mask".
+
+The flag --output, or -o, is required and sets the name of the output image.
+The time stage will append to the name of the image. By default the image will
+be 3600 pixels wide, use the flag --columns, or -c, to define a different
+number of image columns.
+
+By default all time stages will be produced. Use the flag --at to define a
+particular time stage to be draw (in million years). If the age is not a time
+stage of the model, the closest time stage (i.e. the oldest time stage
+younger than the age, or the youngest time stage, if the age is younger than
+all time stages) will be used.
+
+By default, images are encoded as PNG files. Use the flag --format to define
+the image format, valid formats are "png" and "jpeg". If the flag is not
+defined, the format will be inferred from the extension of the output name
+(the extension will be removed before adding the time stage). Use the flag
+--quality to set the quality of JPEG images (from 1 to 100, default 75). Note
+that JPEG is a lossy format, and its artifacts might change the mask values
+at the borders between black and white regions.
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var colsFlag int
+var atFlag float64
+var landFlag string
+var output string
+var formatFlag string
+var qualityFlag int
+
+func setFlags(c *command.Command) {
+	c.Flags().IntVar(&colsFlag, "columns", 3600, "")
+	c.Flags().IntVar(&colsFlag, "c", 3600, "")
+	c.Flags().Float64Var(&atFlag, "at", -1, "")
+	c.Flags().StringVar(&landFlag, "land", "", "")
+	c.Flags().IntVar(&qualityFlag, "quality", jpeg.DefaultQuality, "")
+	c.Flags().StringVar(&formatFlag, "format", "", "")
+	c.Flags().StringVar(&output, "output", "", "")
+	c.Flags().StringVar(&output, "o", "", "")
+}
+
+// MillionYears is used to transform ages
+// (a float in million years)
+// to an integer in years.
+const millionYears = 1_000_000
+
+func run(c *command.Command, args []string) error {
+	if len(args) == 0 {
+		return c.UsageError("expecting time pixelation model")
+	}
+	if output == "" {
+		return c.UsageError("flag --output must be set")
+	}
+	format, err := imgfmt.Format(output, formatFlag)
+	if err != nil {
+		return c.UsageError(err.Error())
+	}
+	prefix := imgfmt.Prefix(output)
+	if err := imgfmt.CheckQuality(qualityFlag); err != nil {
+		return c.UsageError(fmt.Sprintf("flag --quality: %v", err))
+	}
+	if landFlag == "" {
+		return c.UsageError("flag --land must be set")
+	}
+	land, err := parseLand(landFlag)
+	if err != nil {
+		return c.UsageError(err.Error())
+	}
+
+	if colsFlag%2 != 0 {
+		colsFlag++
+	}
+
+	tp, err := readTimePix(args[0])
+	if err != nil {
+		return err
+	}
+	var ages []int64
+	if atFlag >= 0 {
+		first, ok := tp.FirstStage()
+		if !ok {
+			return fmt.Errorf("on file %q: empty time pixelation", args[0])
+		}
+		a := max(int64(atFlag*millionYears), first)
+		ages = []int64{tp.ClosestStageAge(a)}
+	} else {
+		ages = tp.Stages()
+	}
+
+	for _, a := range ages {
+		name := fmt.Sprintf("%s-%d.%s", prefix, a/millionYears, format)
+		img := stageMask{
+			age:  a,
+			land: land,
+			tp:   tp,
+		}
+		if err := writeImage(name, img, format); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parseLand(s string) (map[int]bool, error) {
+	land := make(map[int]bool)
+	for _, v := range strings.Split(s, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("flag --land: invalid value %q: %v", v, err)
+		}
+		land[n] = true
+	}
+	if len(land) == 0 {
+		return nil, fmt.Errorf("flag --land: no values defined")
+	}
+	return land, nil
+}
+
+func readTimePix(name string) (*model.TimePix, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	tp, err := model.ReadTimePix(f, nil)
+	if err != nil {
+		return nil, fmt.Errorf("when reading file %q: %v", name, err)
+	}
+	return tp, nil
+}
+
+// A stageMask is a mask of a time stage
+// of a time pixelation.
+type stageMask struct {
+	age  int64
+	land map[int]bool
+	tp   *model.TimePix
+}
+
+func (s stageMask) ColorModel() color.Model { return color.GrayModel }
+func (s stageMask) Bounds() image.Rectangle { return image.Rect(0, 0, colsFlag, colsFlag/2) }
+func (s stageMask) At(x, y int) color.Color {
+	lat, lon := earth.PlateCarreeLatLon(x, y, colsFlag, colsFlag/2)
+
+	pix := s.tp.Pixelation().Pixel(lat, lon).ID()
+	v, _ := s.tp.At(s.age, pix)
+	if s.land[v] {
+		return color.Gray{255}
+	}
+	return color.Gray{0}
+}
+
+func writeImage(name string, img image.Image, format string) (err error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		e := f.Close()
+		if e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	if err := imgfmt.Encode(f, img, format, qualityFlag); err != nil {
+		return fmt.Errorf("when encoding image file %q: %v", name, err)
+	}
+	return nil
+}
@@ -12,11 +12,12 @@ import (
 
 	"github.com/js-arias/command"
 	"github.com/js-arias/earth"
+	"github.com/js-arias/earth/cmd/plates/cmdlog"
 	"github.com/js-arias/earth/model"
 )
 
 var Command = &command.Command{
-	Usage: `rotate --model <motion-model> [--unrot]
+	Usage: `rotate --model <motion-model> [--unrot] [--log <format>]
 	-o|--output <time-pix-file> <time-pix-file>`,
 	Short: "rotate a time pixelation",
 	Long: `
@@ -32,23 +33,47 @@ moved to a present time stage.
 As it is possible that multiple assignations will be given to a pixel, the
 maximum stored value will be preserved.
 
+Each time stage of the time pixelation is rotated using the closest time stage
+of the plate motion model (i.e. the oldest time stage younger than the time
+pixelation stage). If that time stage is more than 5 million years apart from
+the time pixelation stage, a warning will be printed in the standard error.
+
 The time pixelation resulted from the rotation will be stored in the file
 indicated by the --output, or -o, flag.
 
 The argument of the command is the file that contains the time pixelation to
 be rotated. This argument is required.
+
+Use the flag --log to set the format of the messages printed on the standard
+error. By default, the messages are human readable ("text"). If the format is
+"json", each step of the command (files read and written, and stage warnings)
+will be printed as a JSON object, one per line.
 	`,
 	SetFlags: setFlags,
 	Run:      run,
 }
 
+// MillionYears is used to transform ages
+// an integer in years
+// to a float in million years.
+const millionYears = 1_000_000
+
+// MaxStageGap is the maximum difference
+// (in years)
+// between a time pixelation stage
+// and the time stage of the motion model,
+// before printing a warning.
+const maxStageGap = 5 * millionYears
+
 var modFile string
 var output string
 var unRot bool
+var logFlag string
 
 func setFlags(c *command.Command) {
 	c.Flags().StringVar(&modFile, "model", "", "")
 	c.Flags().BoolVar(&unRot, "unrot", false, "")
+	c.Flags().StringVar(&logFlag, "log", "text", "")
 	c.Flags().StringVar(&output, "output", "", "")
 	c.Flags().StringVar(&output, "o", "", "")
 }
@@ -64,24 +89,37 @@ func run(c *command.Command, args []string) error {
 	if output == "" {
 		return c.UsageError("flag --output must be defined")
 	}
+	log, err := cmdlog.New(c.Stderr(), logFlag)
+	if err != nil {
+		return c.UsageError(fmt.Sprintf("flag --log: %v", err))
+	}
 
 	tp, err := readTimePix(args[0])
 	if err != nil {
 		return err
 	}
+	log.Debug("read time pixelation", "event", "read", "file", args[0], "stages", len(tp.Stages()))
 	pix := tp.Pixelation()
 
 	tot, err := readRotation(modFile, pix)
 	if err != nil {
 		return err
 	}
-	max := lastRotationStage(tot)
+	log.Debug("read plate motion model", "event", "read", "file", modFile, "stages", len(tot.Stages()))
+	max, ok := tot.LastStage()
+	if !ok {
+		return fmt.Errorf("on file %q: empty rotation model", modFile)
+	}
 
 	np := model.NewTimePix(pix)
 	for _, age := range tp.Stages() {
 		if age > max {
 			break
 		}
+		if d := tot.StageDistance(age); d > maxStageGap {
+			msg := fmt.Sprintf("warning: stage %.6f Ma: closest model stage is %.6f million years apart", float64(age)/millionYears, float64(d)/millionYears)
+			log.Warn(msg, "event", "stage-gap", "age", age, "gap", d)
+		}
 		rot := tot.Rotation(age)
 		for px := 0; px < pix.Len(); px++ {
 			v, _ := tp.At(age, px)
@@ -101,6 +139,7 @@ func run(c *command.Command, args []string) error {
 	if err := writeTimePix(output, np); err != nil {
 		return err
 	}
+	log.Debug("write time pixelation", "event", "write", "file", output, "stages", len(np.Stages()))
 
 	return nil
 }
@@ -134,11 +173,6 @@ func readRotation(name string, pix *earth.Pixelation) (*model.Total, error) {
 	return rot, nil
 }
 
-func lastRotationStage(m *model.Total) int64 {
-	stages := m.Stages()
-	return stages[len(stages)-1]
-}
-
 func writeTimePix(name string, tp *model.TimePix) (err error) {
 	f, err := os.Create(name)
 	if err != nil {
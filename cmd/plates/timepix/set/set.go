@@ -18,12 +18,14 @@ import (
 
 	"github.com/js-arias/command"
 	"github.com/js-arias/earth"
+	"github.com/js-arias/earth/cmd/plates/dryrun"
 	"github.com/js-arias/earth/model"
 )
 
 var Command = &command.Command{
 	Usage: `set [--from <age>] [--to <age>] [--at <age>] [--nozero]
-	[-f|--format <format>] --in <model-file> <time-pix-file>`,
+	[-f|--format <format>] [--column <name>] [--compact]
+	[--dry-run] --in <model-file> <time-pix-file>`,
 	Short: "set pixels of a time pixelation",
 	Long: `
 Command set reads pixels from time pixelation file, and set that values into a
@@ -50,6 +52,9 @@ The locations file is a tab-delimited text file with the following columns:
 	             at the indicated age
 	- value      the value to be set
 
+By default, the values of a locations file are read from the column "value".
+Use the flag --column to read the values from a different column.
+
 The argument of the command is the file that contains the time pixelation.
 This argument is required.
 
@@ -57,6 +62,15 @@ By default, all time stages of the source pixels (as defined by --in) will be
 set. With the flags --from and --to, it will use only the stages inside of the
 indicated ages (in million years). Another possibility is using the flag --at
 to set a particular time stage.
+
+If the flag --compact is defined, pixels with a value of 0 will be removed
+from the time pixelation before writing it, and time stages without pixels
+will be also removed.
+
+If the flag --dry-run is defined, the time pixelation file will not be
+modified. Instead, a summary of the changes will be printed in the standard
+output: the number of time stages and pixels that would be modified, and the
+number of pixels of each value transition at each time stage.
 	`,
 	SetFlags: setFlags,
 	Run:      run,
@@ -65,16 +79,22 @@ to set a particular time stage.
 var noZero bool
 var inFlag string
 var format string
+var column string
 var fromFlag float64
 var toFlag float64
 var atFlag float64
+var compact bool
+var dryRun bool
 
 func setFlags(c *command.Command) {
+	c.Flags().BoolVar(&compact, "compact", false, "")
+	c.Flags().BoolVar(&dryRun, "dry-run", false, "")
 	c.Flags().BoolVar(&noZero, "nozero", false, "")
 	c.Flags().Float64Var(&fromFlag, "from", -1, "")
 	c.Flags().Float64Var(&toFlag, "to", -1, "")
 	c.Flags().Float64Var(&atFlag, "at", -1, "")
 	c.Flags().StringVar(&inFlag, "in", "", "")
+	c.Flags().StringVar(&column, "column", "value", "")
 	c.Flags().StringVar(&format, "format", "timepix", "")
 	c.Flags().StringVar(&format, "f", "timepix", "")
 }
@@ -98,17 +118,21 @@ func run(c *command.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	var orig *model.TimePix
+	if dryRun {
+		orig = tp.Clone()
+	}
 
 	var stages []int64
 	if atFlag >= 0 {
 		stages = []int64{tp.ClosestStageAge(int64(atFlag * millionYears))}
 	} else {
 		st := tp.Stages()
-		from := st[len(st)-1]
+		from, _ := tp.LastStage()
 		if fromFlag >= 0 {
 			from = int64(fromFlag * millionYears)
 		}
-		to := st[0]
+		to, _ := tp.FirstStage()
 		if toFlag >= 0 {
 			to = int64(toFlag * millionYears)
 		}
@@ -148,6 +172,13 @@ func run(c *command.Command, args []string) error {
 		return fmt.Errorf("unknown format %q", format)
 	}
 
+	if compact {
+		tp.Compact()
+	}
+	if dryRun {
+		dryrun.Report(c.Stdout(), orig.Diff(tp))
+		return nil
+	}
 	if err := writeTimePix(output, tp); err != nil {
 		return err
 	}
@@ -194,7 +225,6 @@ var locHead = []string{
 	"age",
 	"latitude",
 	"longitude",
-	"value",
 }
 
 func addLocations(name string, tp *model.TimePix, ages map[int64]bool) error {
@@ -222,6 +252,10 @@ func addLocations(name string, tp *model.TimePix, ages map[int64]bool) error {
 			return fmt.Errorf("file %q: expecting field %q", name, h)
 		}
 	}
+	valField := strings.ToLower(column)
+	if _, ok := fields[valField]; !ok {
+		return fmt.Errorf("file %q: expecting field %q", name, valField)
+	}
 
 	pix := tp.Pixelation()
 	for {
@@ -261,7 +295,7 @@ func addLocations(name string, tp *model.TimePix, ages map[int64]bool) error {
 			return fmt.Errorf("on file %q: row %d: field %q: invalid longitude value %.6f", name, ln, f, lon)
 		}
 
-		f = "value"
+		f = valField
 		v, err := strconv.Atoi(row[fields[f]])
 		if err != nil {
 			return fmt.Errorf("on file %q: row %d: field %q: %v", name, ln, f, err)
@@ -10,11 +10,18 @@ import (
 	"github.com/js-arias/command"
 	"github.com/js-arias/earth/cmd/plates/timepix/add"
 	"github.com/js-arias/earth/cmd/plates/timepix/change"
+	"github.com/js-arias/earth/cmd/plates/timepix/checkkey"
+	"github.com/js-arias/earth/cmd/plates/timepix/coarsen"
+	"github.com/js-arias/earth/cmd/plates/timepix/coastline"
+	"github.com/js-arias/earth/cmd/plates/timepix/fromimage"
 	"github.com/js-arias/earth/cmd/plates/timepix/mapcmd"
+	"github.com/js-arias/earth/cmd/plates/timepix/mask"
 	"github.com/js-arias/earth/cmd/plates/timepix/rotate"
 	"github.com/js-arias/earth/cmd/plates/timepix/set"
 	"github.com/js-arias/earth/cmd/plates/timepix/stages"
 	"github.com/js-arias/earth/cmd/plates/timepix/values"
+	"github.com/js-arias/earth/cmd/plates/timepix/vectorize"
+	"github.com/js-arias/earth/cmd/plates/timepix/zonal"
 )
 
 var Command = &command.Command{
@@ -25,9 +32,16 @@ var Command = &command.Command{
 func init() {
 	Command.Add(add.Command)
 	Command.Add(change.Command)
+	Command.Add(checkkey.Command)
+	Command.Add(coarsen.Command)
+	Command.Add(coastline.Command)
+	Command.Add(fromimage.Command)
 	Command.Add(mapcmd.Command)
+	Command.Add(mask.Command)
 	Command.Add(rotate.Command)
 	Command.Add(set.Command)
 	Command.Add(stages.Command)
 	Command.Add(values.Command)
+	Command.Add(vectorize.Command)
+	Command.Add(zonal.Command)
 }
@@ -0,0 +1,202 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package vectorize implements a command to convert
+// the pixels of a time pixelation
+// into a set of vector polygons.
+package vectorize
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/earth"
+	"github.com/js-arias/earth/model"
+	"github.com/js-arias/earth/vector"
+)
+
+var Command = &command.Command{
+	Usage: `vectorize [--at <age>] [-o|--output <file>]
+	<time-pix-file>`,
+	Short: "convert a time pixelation into vector polygons",
+	Long: `
+Command vectorize reads a time pixelation model and converts the connected
+regions of pixels with the same value into vector polygons, written as a
+GeoJSON feature collection.
+
+The argument of the command is the name of the file that contains the time
+pixelation model.
+
+Pixels with the same value that share an edge are part of the same region,
+and each region is encoded as a GeoJSON feature with a MultiPolygon geometry.
+The region is not dissolved into a single outline: each polygon is the cell
+outline of a run of consecutive pixels of the same ring of the pixelation, so
+the edges of the polygons are at the pixel resolution. Cells that cross the
+antimeridian are split at the antimeridian.
+
+Each feature has the following properties:
+
+	- value   the pixel value of the region
+	- age     the age of the time stage (in years)
+	- pixels  the number of pixels in the region
+
+By default all time stages will be converted. Use the flag --at to define a
+particular time stage (in million years). If the age is not a time stage of
+the model, the closest time stage (i.e. the oldest time stage younger than the
+age, or the youngest time stage, if the age is younger than all time stages)
+will be used.
+
+By default the output will be written in the standard output. Use the flag
+--output, or -o, to define an output file.
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var atFlag float64
+var output string
+
+func setFlags(c *command.Command) {
+	c.Flags().Float64Var(&atFlag, "at", -1, "")
+	c.Flags().StringVar(&output, "output", "", "")
+	c.Flags().StringVar(&output, "o", "", "")
+}
+
+// MillionYears is used to transform ages
+// (a float in million years)
+// to an integer in years.
+const millionYears = 1_000_000
+
+func run(c *command.Command, args []string) error {
+	if len(args) < 1 {
+		return c.UsageError("expecting time pixelation model file")
+	}
+
+	tp, err := readTimePix(args[0])
+	if err != nil {
+		return err
+	}
+
+	var ages []int64
+	if atFlag >= 0 {
+		first, ok := tp.FirstStage()
+		if !ok {
+			return fmt.Errorf("on file %q: empty time pixelation", args[0])
+		}
+		a := max(int64(atFlag*millionYears), first)
+		ages = []int64{tp.ClosestStageAge(a)}
+	} else {
+		ages = tp.Stages()
+	}
+
+	var fs []vector.PixelFeature
+	for _, a := range ages {
+		for _, r := range regions(tp, a) {
+			fs = append(fs, vector.PixelFeature{
+				Pixels: r.pixels,
+				Properties: geoProperties{
+					Value:  r.value,
+					Age:    a,
+					Pixels: len(r.pixels),
+				},
+			})
+		}
+	}
+
+	if err := write(c.Stdout(), output, tp.Pixelation(), fs); err != nil {
+		return err
+	}
+	return nil
+}
+
+func readTimePix(name string) (*model.TimePix, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	tp, err := model.ReadTimePix(f, nil)
+	if err != nil {
+		return nil, fmt.Errorf("when reading file %q: %v", name, err)
+	}
+	return tp, nil
+}
+
+// A region is a set of connected pixels
+// with the same value.
+type region struct {
+	value  int
+	pixels []int
+}
+
+// Regions returns the connected regions
+// of a time stage.
+func regions(tp *model.TimePix, age int64) []region {
+	pix := tp.Pixelation()
+	st := tp.StageSlice(age)
+
+	var rs []region
+	done := make(map[int]bool, len(st))
+	for _, pv := range st {
+		if done[pv.Pixel] {
+			continue
+		}
+		done[pv.Pixel] = true
+
+		r := region{value: pv.Value}
+		queue := []int{pv.Pixel}
+		for len(queue) > 0 {
+			px := queue[0]
+			queue = queue[1:]
+			r.pixels = append(r.pixels, px)
+
+			for _, nb := range pix.Neighbors(px) {
+				if done[nb] {
+					continue
+				}
+				if v, _ := tp.At(age, nb); v != pv.Value {
+					continue
+				}
+				done[nb] = true
+				queue = append(queue, nb)
+			}
+		}
+		slices.Sort(r.pixels)
+		rs = append(rs, r)
+	}
+	return rs
+}
+
+type geoProperties struct {
+	Value  int   `json:"value"`
+	Age    int64 `json:"age"`
+	Pixels int   `json:"pixels"`
+}
+
+func write(w io.Writer, name string, pix *earth.Pixelation, fs []vector.PixelFeature) (err error) {
+	if name != "" {
+		f, err := os.Create(name)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			e := f.Close()
+			if e != nil && err == nil {
+				err = e
+			}
+		}()
+		w = f
+	} else {
+		name = "stdout"
+	}
+
+	if err := vector.EncodePixelGeoJSON(w, pix, fs); err != nil {
+		return fmt.Errorf("when writing on file %q: %v", name, err)
+	}
+	return nil
+}
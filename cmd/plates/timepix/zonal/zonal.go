@@ -0,0 +1,128 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package zonal implements a command to print
+// the number of pixels of each value
+// at each ring of a time pixelation.
+package zonal
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/earth/model"
+)
+
+var Command = &command.Command{
+	Usage: "zonal [--at <age>] <time-pix-file>",
+	Short: "print the pixel values at each ring of a time pixelation",
+	Long: `
+Command zonal reads a time pixelation model and prints the number of pixels of
+each value at each ring (i.e. each latitude band) of the pixelation.
+
+The argument of the command is the name of the file that contains the time
+pixelation model.
+
+By default, all time stages will be printed, one block per time stage, sorted
+from the youngest to the oldest time stage. Use the flag --at to print only the
+time stage of the indicated age (in million years). If the age is not a time
+stage of the model, the closest time stage will be used (i.e. the oldest time
+stage younger than the age).
+
+The output is a tab-delimited table with the following columns:
+
+	- age    the age of the time stage, in million years
+	- ring   the ring of the pixelation, from north to south
+	- lat    the latitude of the ring
+	- value  a pixel value
+	- count  the number of pixels of the ring with the value
+
+Rows are sorted by ring, and then by value. Pixels without a value (i.e. with
+the value 0) are not counted, and rings without pixels with a value are not
+printed.
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var atFlag float64
+
+func setFlags(c *command.Command) {
+	c.Flags().Float64Var(&atFlag, "at", -1, "")
+}
+
+// MillionYears is used to transform ages
+// (a float in million years)
+// to an integer in years.
+const millionYears = 1_000_000
+
+func run(c *command.Command, args []string) error {
+	if len(args) < 1 {
+		return c.UsageError("expecting time pixelation model file")
+	}
+
+	tp, err := readTimePix(args[0])
+	if err != nil {
+		return err
+	}
+
+	ages := tp.Stages()
+	if atFlag >= 0 && len(ages) > 0 {
+		a := max(int64(atFlag*millionYears), ages[0])
+		ages = []int64{tp.ClosestStageAge(a)}
+	}
+
+	if err := writeZonal(c.Stdout(), tp, ages); err != nil {
+		return fmt.Errorf("when writing on stdout: %v", err)
+	}
+	return nil
+}
+
+func writeZonal(w io.Writer, tp *model.TimePix, ages []int64) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "age\tring\tlat\tvalue\tcount\n")
+
+	pix := tp.Pixelation()
+	for _, a := range ages {
+		st := tp.Stage(a)
+		for r := 0; r < pix.Rings(); r++ {
+			count := make(map[int]int)
+			for _, id := range pix.RingPixels(r) {
+				v := st[id]
+				if v == 0 {
+					continue
+				}
+				count[v]++
+			}
+
+			vals := make([]int, 0, len(count))
+			for v := range count {
+				vals = append(vals, v)
+			}
+			slices.Sort(vals)
+			for _, v := range vals {
+				fmt.Fprintf(bw, "%.6f\t%d\t%.6f\t%d\t%d\n", float64(a)/millionYears, r, pix.RingLat(r), v, count[v])
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+func readTimePix(name string) (*model.TimePix, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	tp, err := model.ReadTimePix(f, nil)
+	if err != nil {
+		return nil, fmt.Errorf("when reading file %q: %v", name, err)
+	}
+	return tp, nil
+}
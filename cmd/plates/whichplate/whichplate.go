@@ -0,0 +1,286 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package whichplate implements a command to print
+// the plate of a set of geographic points.
+package whichplate
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/earth/model"
+)
+
+var Command = &command.Command{
+	Usage: `which-plate --pix <pix-file> [--at <age>]
+	[-o|--output <file>] [<point-file>]`,
+	Short: "print the plate of geographic points",
+	Long: `
+Command which-plate reads a set of geographic points (for example, sampling
+sites) and prints the tectonic plate in which each point is located, using a
+pixelated plates file.
+
+The argument of the command is the name of the file that contains the points.
+If no file is given, the points will be read from the standard input. It is a
+tab-delimited text file with the following columns:
+
+	- latitude   the geographic latitude of the point at present time
+	- longitude  the geographic longitude of the point at present time
+	- name       (optional) the name of the point
+
+The flag --pix is required and indicates the file containing the pixelated
+plates. Each point is assigned to the pixel of its location, and the plate of
+the point is the plate of that pixel. If the pixel is assigned to more than one
+plate, the plate in which the pixel is older will be used, and in case of ties,
+the one with the lowest ID.
+
+By default, the plate at the present time will be printed. Use the flag --at
+to define a different age (in million years). Only the pixels that exist at
+that age will be used.
+
+The output is a tab-delimited table with the following columns:
+
+	- name       the name of the point (only if defined in the input)
+	- latitude   the latitude of the point
+	- longitude  the longitude of the point
+	- pixel      the ID of the pixel of the point
+	- plate      the ID of the plate of the point
+
+If the pixel is not assigned to any plate at the given age, the plate will be
+printed as "none".
+
+By default the output is printed in the standard output, use the flag
+--output, or -o, to define an output file.
+	`,
+	SetFlags: setFlags,
+	Run:      run,
+}
+
+var atFlag float64
+var output string
+var pixFile string
+
+func setFlags(c *command.Command) {
+	c.Flags().Float64Var(&atFlag, "at", 0, "")
+	c.Flags().StringVar(&output, "output", "", "")
+	c.Flags().StringVar(&output, "o", "", "")
+	c.Flags().StringVar(&pixFile, "pix", "", "")
+}
+
+// MillionYears is used to transform ages
+// (a float in million years)
+// to an integer in years.
+const millionYears = 1_000_000
+
+func run(c *command.Command, args []string) (err error) {
+	if pixFile == "" {
+		return c.UsageError("undefined value for --pix flag")
+	}
+	if atFlag < 0 {
+		return c.UsageError("invalid value for --at flag")
+	}
+	age := int64(atFlag * millionYears)
+
+	pp, err := readPixPlate(pixFile)
+	if err != nil {
+		return err
+	}
+
+	name := "-"
+	if len(args) > 0 {
+		name = args[0]
+	}
+	pts, hasName, err := readPoints(c.Stdin(), name)
+	if err != nil {
+		return err
+	}
+
+	w := c.Stdout()
+	if output != "" {
+		var f *os.File
+		f, err = os.Create(output)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			e := f.Close()
+			if e != nil && err == nil {
+				err = e
+			}
+		}()
+		w = f
+	}
+
+	if err := writePlates(w, pp, pts, hasName, age); err != nil {
+		if output == "" {
+			output = "stdout"
+		}
+		return fmt.Errorf("when writing on %q: %v", output, err)
+	}
+	return nil
+}
+
+// WritePlates writes the pixel and plate
+// of each point
+// at the given age.
+func writePlates(w io.Writer, pp *model.PixPlate, pts []point, hasName bool, age int64) error {
+	pix := pp.Pixelation()
+	idx := pp.PlateIndex(age)
+
+	bw := bufio.NewWriter(w)
+	tab := csv.NewWriter(bw)
+	tab.Comma = '\t'
+	tab.UseCRLF = true
+
+	head := []string{"latitude", "longitude", "pixel", "plate"}
+	if hasName {
+		head = append([]string{"name"}, head...)
+	}
+	if err := tab.Write(head); err != nil {
+		return err
+	}
+
+	for _, p := range pts {
+		px := pix.Pixel(p.lat, p.lon).ID()
+		plate := "none"
+		if pl, ok := idx[px]; ok {
+			plate = strconv.Itoa(pl)
+		}
+		row := []string{
+			strconv.FormatFloat(p.lat, 'f', 6, 64),
+			strconv.FormatFloat(p.lon, 'f', 6, 64),
+			strconv.Itoa(px),
+			plate,
+		}
+		if hasName {
+			row = append([]string{p.name}, row...)
+		}
+		if err := tab.Write(row); err != nil {
+			return err
+		}
+	}
+
+	tab.Flush()
+	if err := tab.Error(); err != nil {
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func readPixPlate(name string) (*model.PixPlate, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	pp, err := model.ReadPixPlate(f, nil)
+	if err != nil {
+		return nil, fmt.Errorf("when reading file %q: %v", name, err)
+	}
+	return pp, nil
+}
+
+type point struct {
+	name     string
+	lat, lon float64
+}
+
+var pointHead = []string{
+	"latitude",
+	"longitude",
+}
+
+// ReadPoints returns the points of a file,
+// and true if the points have names.
+func readPoints(r io.Reader, name string) ([]point, bool, error) {
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, false, err
+		}
+		defer f.Close()
+		r = f
+	} else {
+		name = "stdin"
+	}
+
+	pts, hasName, err := parsePoints(r)
+	if err != nil {
+		return nil, false, fmt.Errorf("on file %q: %v", name, err)
+	}
+	return pts, hasName, nil
+}
+
+func parsePoints(r io.Reader) ([]point, bool, error) {
+	tab := csv.NewReader(r)
+	tab.Comma = '\t'
+	tab.Comment = '#'
+
+	head, err := tab.Read()
+	if err != nil {
+		return nil, false, fmt.Errorf("header: %v", err)
+	}
+	fields := make(map[string]int, len(head))
+	for i, h := range head {
+		h = strings.ToLower(h)
+		fields[h] = i
+	}
+	for _, h := range pointHead {
+		if _, ok := fields[h]; !ok {
+			return nil, false, fmt.Errorf("expecting field %q", h)
+		}
+	}
+	_, hasName := fields["name"]
+
+	var pts []point
+	for {
+		row, err := tab.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		ln, _ := tab.FieldPos(0)
+		if err != nil {
+			return nil, false, fmt.Errorf("on row %d: %v", ln, err)
+		}
+
+		var p point
+		f := "name"
+		if hasName {
+			p.name = strings.TrimSpace(row[fields[f]])
+		}
+
+		f = "latitude"
+		p.lat, err = strconv.ParseFloat(row[fields[f]], 64)
+		if err != nil {
+			return nil, false, fmt.Errorf("on row %d: field %q: %v", ln, f, err)
+		}
+		if p.lat < -90 || p.lat > 90 {
+			return nil, false, fmt.Errorf("on row %d: field %q: invalid latitude value %.6f", ln, f, p.lat)
+		}
+
+		f = "longitude"
+		p.lon, err = strconv.ParseFloat(row[fields[f]], 64)
+		if err != nil {
+			return nil, false, fmt.Errorf("on row %d: field %q: %v", ln, f, err)
+		}
+		if p.lon < -180 || p.lon > 180 {
+			return nil, false, fmt.Errorf("on row %d: field %q: invalid longitude value %.6f", ln, f, p.lon)
+		}
+
+		pts = append(pts, p)
+	}
+	return pts, hasName, nil
+}
@@ -35,6 +35,7 @@ import (
 
 	"github.com/js-arias/blind"
 	"github.com/js-arias/command"
+	"github.com/js-arias/earth"
 	"github.com/js-arias/earth/model"
 )
 
@@ -369,21 +370,18 @@ func events(gtx layout.Context, sp *mapStagePix) {
 
 func (sp *mapStagePix) setLocation() {
 	y := sp.pt.Y - sp.offset.Y
-	lat := 90 - float64(y)*sp.step
-	if lat < -90 || lat >= 90 {
+	if y < 0 || int(y) >= sp.cols/2 {
 		sp.lat = math.NaN()
 		sp.lon = math.NaN()
 		return
 	}
 	x := sp.pt.X - sp.offset.X
-	lon := float64(x)*sp.step - 180
-	if lon < -180 || lon > 180 {
+	if x < 0 || int(x) >= sp.cols {
 		sp.lat = math.NaN()
 		sp.lon = math.NaN()
 		return
 	}
-	sp.lat = lat
-	sp.lon = lon
+	sp.lat, sp.lon = earth.PlateCarreeLatLon(int(x), int(y), sp.cols, sp.cols/2)
 
 }
 
@@ -406,8 +404,7 @@ func (sp mapStagePix) At(x, y int) color.Color {
 		return color.RGBA{R: 255, G: 255, B: 255, A: 255}
 	}
 
-	lat := 90 - float64(y)*sp.step
-	lon := float64(x)*sp.step - 180
+	lat, lon := earth.PlateCarreeLatLon(x, y, sp.cols, sp.cols/2)
 
 	pix := sp.tp.Pixelation().Pixel(lat, lon).ID()
 	v, _ := sp.tp.At(sp.stages[sp.stage], pix)
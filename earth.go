@@ -8,6 +8,7 @@ package earth
 import (
 	"fmt"
 	"math"
+	"strconv"
 
 	"gonum.org/v1/gonum/spatial/r3"
 )
@@ -32,6 +33,32 @@ func ToRad(angle float64) float64 {
 	return angle * math.Pi / 180
 }
 
+// NormalizeLat returns a latitude value
+// (in degrees)
+// clamped to the valid range [-90, 90].
+// It is useful to remove small numerical errors,
+// for example,
+// after a rotation.
+func NormalizeLat(lat float64) float64 {
+	return math.Max(-90, math.Min(90, lat))
+}
+
+// NormalizeLon returns a longitude value
+// (in degrees)
+// wrapped into the valid range [-180, 180].
+// Values already in the valid range
+// are returned unchanged.
+func NormalizeLon(lon float64) float64 {
+	if lon >= -180 && lon <= 180 {
+		return lon
+	}
+	lon = math.Mod(lon+180, 360)
+	if lon < 0 {
+		lon += 360
+	}
+	return lon - 180
+}
+
 // A Point is a geographic point
 // on the surface of the unit length sphere.
 type Point struct {
@@ -75,6 +102,82 @@ func (p Point) Longitude() float64 {
 	return p.lon
 }
 
+// Round returns a new point
+// with the latitude and longitude
+// rounded to the given number of decimals.
+// Rounded values are always inside the valid range
+// of geographic coordinates.
+func (p Point) Round(decimals int) Point {
+	lat, lon := p.round(decimals)
+	return NewPoint(lat, lon)
+}
+
+// Key returns a canonical string key
+// for the point,
+// with the latitude and longitude
+// rounded to the given number of decimals
+// (as in Round),
+// in the form "lat|lon".
+// It is intended as a map key,
+// to deduplicate points from noisy sources.
+//
+// Two points that round to the same coordinates
+// always have the same key.
+// As rounding is done independently on each coordinate,
+// two points that are very close
+// but at different sides of a cell boundary
+// (e.g. 0.004999 and 0.005 with two decimals)
+// will have different keys.
+// Negative zero is written as zero,
+// all points at a pole share the same key
+// (with longitude 0),
+// and points at longitude 180
+// share the key of longitude -180.
+func (p Point) Key(decimals int) string {
+	lat, lon := p.round(decimals)
+	if lat == 90 || lat == -90 {
+		lon = 0
+	}
+	if lon == 180 {
+		lon = -180
+	}
+	// remove negative zeros
+	if lat == 0 {
+		lat = 0
+	}
+	if lon == 0 {
+		lon = 0
+	}
+
+	prec := max(decimals, 0)
+	var buf [64]byte
+	k := strconv.AppendFloat(buf[:0], lat, 'f', prec, 64)
+	k = append(k, '|')
+	k = strconv.AppendFloat(k, lon, 'f', prec, 64)
+	return string(k)
+}
+
+// Round returns the coordinates of the point
+// rounded to the given number of decimals.
+func (p Point) round(decimals int) (lat, lon float64) {
+	// coordinates have at most three integer digits,
+	// so with more than 15 decimals
+	// the rounding is beyond the precision of a float64,
+	// and with less than -3 decimals
+	// all coordinates are rounded to 0.
+	if decimals > 15 {
+		return p.lat, p.lon
+	}
+	decimals = max(decimals, -3)
+
+	scale := math.Pow10(decimals)
+	lat = math.Round(p.lat*scale) / scale
+	lat = math.Max(-90, math.Min(90, lat))
+	lon = math.Round(p.lon*scale) / scale
+	lon = math.Max(-180, math.Min(180, lon))
+	return lat, lon
+}
+
 // Vector returns the 2D vector representation of a point.
 func (p Point) Vector() r3.Vec {
 	return p.vec
@@ -104,6 +207,34 @@ func Distance(p, q Point) float64 {
 	return math.Acos(dot)
 }
 
+// Chord2ToDistance returns the great circle distance,
+// in radians,
+// for a squared Euclidean chord distance
+// between two points on the unit sphere.
+// Values outside the valid range
+// (i.e. [0, 4])
+// are clamped.
+func Chord2ToDistance(c2 float64) float64 {
+	if c2 < 0 {
+		c2 = 0
+	}
+	if c2 > 4 {
+		c2 = 4
+	}
+	// chord = 2 sin(d/2)
+	return 2 * math.Asin(math.Sqrt(c2)/2)
+}
+
+// DistanceToChord2 returns the squared Euclidean chord distance
+// between two points on the unit sphere
+// separated by a great circle distance
+// in radians
+// (i.e. 2 - 2 cos(d)).
+func DistanceToChord2(distRad float64) float64 {
+	s := math.Sin(distRad / 2)
+	return 4 * s * s
+}
+
 // Bearing returns the direction angle
 // between a meridian and the great circle line
 // that connect two points,
@@ -127,6 +258,34 @@ func Bearing(p, q Point) float64 {
 	return b
 }
 
+// GreatCircleTo returns the great circle distance
+// and the bearing
+// (both in radians)
+// from the point p to the point q.
+// It is equivalent to call Distance and Bearing,
+// but the trigonometric values are calculated
+// only once.
+//
+// The distance is calculated with the Vincenty formula,
+// so it is accurate for both small
+// and antipodal distances.
+func (p Point) GreatCircleTo(q Point) (distRad, bearingRad float64) {
+	sinPLat, cosPLat := math.Sincos(ToRad(p.lat))
+	sinQLat, cosQLat := math.Sincos(ToRad(q.lat))
+	sinDLon, cosDLon := math.Sincos(ToRad(q.lon) - ToRad(p.lon))
+
+	x := cosQLat * sinDLon
+	y := cosPLat*sinQLat - sinPLat*cosQLat*cosDLon
+	z := sinPLat*sinQLat + cosPLat*cosQLat*cosDLon
+
+	distRad = math.Atan2(math.Hypot(x, y), z)
+	bearingRad = math.Atan2(x, y)
+	if bearingRad < 0 {
+		bearingRad = 2*math.Pi + bearingRad
+	}
+	return distRad, bearingRad
+}
+
 // Destination returns the destination point
 // of a trip starting at point p,
 // given a bearing and a distance
@@ -135,16 +294,144 @@ func Destination(p Point, dist, bearing float64) Point {
 	pLat := ToRad(p.lat)
 
 	sinLat := math.Sin(pLat)*math.Cos(dist) + math.Cos(pLat)*math.Sin(dist)*math.Cos(bearing)
-	rLat := math.Asin(sinLat)
+	rLat := math.Asin(math.Max(-1, math.Min(1, sinLat)))
 	tanLonX := math.Sin(bearing) * math.Sin(dist) * math.Cos(pLat)
 	tanLonY := math.Cos(dist) - math.Sin(pLat)*math.Sin(rLat)
 	lon := p.lon + ToDegree(math.Atan2(tanLonX, tanLonY))
-	if lon > 180 {
-		lon = lon - 360
+
+	return NewPoint(NormalizeLat(ToDegree(rLat)), NormalizeLon(lon))
+}
+
+// Interpolate returns a point
+// in the great circle arc between p and q,
+// at a fraction t of the distance between them
+// (i.e. 0 is p, 1 is q, and 0.5 is the midpoint).
+// If p and q are antipodal,
+// the great circle is undefined
+// and p will be returned.
+func Interpolate(p, q Point, t float64) Point {
+	d := Distance(p, q)
+	sinD := math.Sin(d)
+	if sinD < 1e-12 {
+		return p
+	}
+
+	a := math.Sin((1-t)*d) / sinD
+	b := math.Sin(t*d) / sinD
+	v := r3.Add(r3.Scale(a, p.vec), r3.Scale(b, q.vec))
+	return fromVector(r3.Unit(v))
+}
+
+// Triangle returns the interior angles
+// (in radians)
+// and the area
+// (in steradians,
+// i.e. on the unit sphere)
+// of the spherical triangle defined by three points.
+// Angles are calculated using the spherical law of cosines,
+// and the area is the spherical excess
+// (i.e. the sum of the angles minus π).
+//
+// If the points are collinear
+// (i.e. in the same great circle)
+// the triangle is degenerate:
+// the angles will be 0 or π,
+// and the area will be 0.
+// If two points are the same,
+// all angles and the area will be 0.
+func Triangle(a, b, c Point) (angleA, angleB, angleC, areaSteradian float64) {
+	// sides opposite to each vertex
+	sa := Distance(b, c)
+	sb := Distance(a, c)
+	sc := Distance(a, b)
+
+	angle := func(opp, s1, s2 float64) float64 {
+		den := math.Sin(s1) * math.Sin(s2)
+		if den < 1e-15 {
+			return 0
+		}
+		cos := (math.Cos(opp) - math.Cos(s1)*math.Cos(s2)) / den
+		return math.Acos(math.Max(-1, math.Min(1, cos)))
+	}
+
+	angleA = angle(sa, sb, sc)
+	angleB = angle(sb, sa, sc)
+	angleC = angle(sc, sa, sb)
+	if angleA == 0 && angleB == 0 && angleC == 0 {
+		return 0, 0, 0, 0
+	}
+
+	areaSteradian = max(0, angleA+angleB+angleC-math.Pi)
+	return angleA, angleB, angleC, areaSteradian
+}
+
+// Midpoint returns the middle point
+// of the great circle arc between p and q.
+// It is equivalent to Interpolate(p, q, 0.5).
+//
+// If p and q are antipodal points
+// the midpoint is undefined,
+// and p will be returned.
+func Midpoint(p, q Point) Point {
+	v := r3.Add(p.vec, q.vec)
+	if r3.Norm(v) < 1e-12 {
+		return p
+	}
+	return fromVector(r3.Unit(v))
+}
+
+// Centroid returns the spherical centroid
+// of a set of points,
+// i.e. the normalized mean of the unit vectors
+// of the points.
+//
+// If the points cancel each other
+// (for example two antipodal points)
+// the centroid is undefined,
+// and the first point will be returned.
+// If there are no points,
+// it returns the zero Point.
+func Centroid(pts []Point) Point {
+	if len(pts) == 0 {
+		return Point{}
+	}
+
+	var v r3.Vec
+	for _, p := range pts {
+		v = r3.Add(v, p.vec)
 	}
-	if lon < -180 {
-		lon = 360 + lon
+	if r3.Norm(v) < 1e-12 {
+		return pts[0]
 	}
+	return fromVector(r3.Unit(v))
+}
+
+// Waypoints returns the points of the great circle arc
+// between p and q
+// separated by at most the given distance
+// (in radians).
+// Points are equally spaced
+// and include both p and q.
+func Waypoints(p, q Point, step float64) []Point {
+	d := Distance(p, q)
+	n := 1
+	if step > 0 {
+		n = max(1, int(math.Ceil(d/step)))
+	}
+
+	pts := make([]Point, 0, n+1)
+	pts = append(pts, p)
+	for i := 1; i < n; i++ {
+		pts = append(pts, Interpolate(p, q, float64(i)/float64(n)))
+	}
+	pts = append(pts, q)
+	return pts
+}
 
-	return NewPoint(ToDegree(rLat), lon)
+// FromVector returns a point
+// from a unit length vector.
+func fromVector(v r3.Vec) Point {
+	lat := ToDegree(math.Asin(math.Max(-1, math.Min(1, v.Z))))
+	lon := ToDegree(math.Atan2(v.Y, v.X))
+	return NewPoint(NormalizeLat(lat), NormalizeLon(lon))
 }
@@ -11,6 +11,115 @@ import (
 	"github.com/js-arias/earth"
 )
 
+func TestNormalizeLon(t *testing.T) {
+	tests := map[float64]float64{
+		0:           0,
+		180:         180,
+		-180:        -180,
+		180.0000001: -179.9999999,
+		-180.000001: 179.999999,
+		190:         -170,
+		-190:        170,
+		360:         0,
+		540:         -180,
+		725:         5,
+		-725:        -5,
+	}
+	for lon, want := range tests {
+		if got := earth.NormalizeLon(lon); math.Abs(got-want) > 1e-9 {
+			t.Errorf("longitude %v: got %v, want %v", lon, got, want)
+		}
+	}
+}
+
+func TestNormalizeLat(t *testing.T) {
+	tests := map[float64]float64{
+		0:           0,
+		90:          90,
+		-90:         -90,
+		90.0000001:  90,
+		-90.0000001: -90,
+		45.5:        45.5,
+	}
+	for lat, want := range tests {
+		if got := earth.NormalizeLat(lat); got != want {
+			t.Errorf("latitude %v: got %v, want %v", lat, got, want)
+		}
+	}
+}
+
+func TestPointRound(t *testing.T) {
+	tests := map[string]struct {
+		lat, lon float64
+		decimals int
+		wLat     float64
+		wLon     float64
+	}{
+		"Erebus":       {lat: -77.99999999999999, lon: 167.00000000000006, decimals: 6, wLat: -78, wLon: 167},
+		"Tucuman":      {lat: -26.8241, lon: -65.2226, decimals: 2, wLat: -26.82, wLon: -65.22},
+		"north pole":   {lat: 90, lon: 0, decimals: 2, wLat: 90, wLon: 0},
+		"near north":   {lat: 89.99999, lon: 45.123, decimals: 3, wLat: 90, wLon: 45.123},
+		"near south":   {lat: -89.99996, lon: -120.5, decimals: 4, wLat: -90, wLon: -120.5},
+		"antimeridian": {lat: 10.4, lon: 179.99999, decimals: 2, wLat: 10.4, wLon: 180},
+		"west":         {lat: 10.4, lon: -180, decimals: 0, wLat: 10, wLon: -180},
+		"tens":         {lat: 44, lon: 123.4, decimals: -1, wLat: 40, wLon: 120},
+		"thousands":    {lat: 44, lon: 123.4, decimals: -3, wLat: 0, wLon: 0},
+		"precise":      {lat: 44.123456789, lon: 123.4, decimals: 20, wLat: 44.123456789, wLon: 123.4},
+		"huge":         {lat: 44, lon: 123.4, decimals: 400, wLat: 44, wLon: 123.4},
+		"overflow":     {lat: 44, lon: 123.4, decimals: 307, wLat: 44, wLon: 123.4},
+		"tiny":         {lat: 44, lon: 123.4, decimals: -400, wLat: 0, wLon: 0},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			p := earth.NewPoint(test.lat, test.lon).Round(test.decimals)
+			if p.Latitude() != test.wLat {
+				t.Errorf("latitude: got %v, want %v", p.Latitude(), test.wLat)
+			}
+			if p.Longitude() != test.wLon {
+				t.Errorf("longitude: got %v, want %v", p.Longitude(), test.wLon)
+			}
+			if d := earth.Distance(p, earth.NewPoint(test.wLat, test.wLon)); d > 1e-12 {
+				t.Errorf("vector: distance %v from expected point", d)
+			}
+		})
+	}
+}
+
+func TestPointKey(t *testing.T) {
+	tests := map[string]struct {
+		lat, lon float64
+		decimals int
+		want     string
+	}{
+		"Erebus":        {lat: -77.99999999999999, lon: 167.00000000000006, decimals: 6, want: "-78.000000|167.000000"},
+		"Tucuman":       {lat: -26.8241, lon: -65.2226, decimals: 2, want: "-26.82|-65.22"},
+		"negative zero": {lat: -0.001, lon: -0.001, decimals: 2, want: "0.00|0.00"},
+		"north pole":    {lat: 89.99999, lon: 45.123, decimals: 3, want: "90.000|0.000"},
+		"south pole":    {lat: -90, lon: -120.5, decimals: 1, want: "-90.0|0.0"},
+		"antimeridian":  {lat: 10.4, lon: 179.99999, decimals: 2, want: "10.40|-180.00"},
+		"west":          {lat: 10.4, lon: -180, decimals: 2, want: "10.40|-180.00"},
+		"tens":          {lat: 44, lon: 123.4, decimals: -1, want: "40|120"},
+	}
+
+	for name, test := range tests {
+		k := earth.NewPoint(test.lat, test.lon).Key(test.decimals)
+		if k != test.want {
+			t.Errorf("%s: got %q, want %q", name, k, test.want)
+		}
+	}
+
+	// points in the same cell
+	p := earth.NewPoint(-26.8241, -65.2226)
+	q := earth.NewPoint(-26.8249, -65.2151)
+	if p.Key(2) != q.Key(2) {
+		t.Errorf("same cell: got %q and %q", p.Key(2), q.Key(2))
+	}
+	if p.Key(3) == q.Key(3) {
+		t.Errorf("different cells: got same key %q", p.Key(3))
+	}
+}
+
 func TestPointDistance(t *testing.T) {
 	tests := map[string]struct {
 		p1, p2 earth.Point
@@ -64,6 +173,44 @@ func TestPointDistance(t *testing.T) {
 	}
 }
 
+func TestChord2Distance(t *testing.T) {
+	tests := map[string]struct {
+		dist   float64
+		chord2 float64
+	}{
+		"zero": {dist: 0, chord2: 0},
+		"90°":  {dist: math.Pi / 2, chord2: 2},
+		"180°": {dist: math.Pi, chord2: 4},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			c2 := earth.DistanceToChord2(test.dist)
+			if math.Abs(c2-test.chord2) > 1e-9 {
+				t.Errorf("chord2: got %.9f, want %.9f", c2, test.chord2)
+			}
+			d := earth.Chord2ToDistance(c2)
+			if math.Abs(d-test.dist) > 1e-9 {
+				t.Errorf("distance: got %.9f, want %.9f", d, test.dist)
+			}
+		})
+	}
+
+	// chord of two points
+	p, q := earth.NewPoint(-34, 18), earth.NewPoint(59, 18)
+	if c2, want := earth.DistanceToChord2(earth.Distance(p, q)), earth.Chord2(p, q); math.Abs(c2-want) > 1e-9 {
+		t.Errorf("Cape Town - Stockholm: chord2: got %.9f, want %.9f", c2, want)
+	}
+
+	// out of range values
+	if d := earth.Chord2ToDistance(4.0000001); d != math.Pi {
+		t.Errorf("chord2 > 4: got %.9f, want %.9f", d, math.Pi)
+	}
+	if d := earth.Chord2ToDistance(-1e-12); d != 0 {
+		t.Errorf("chord2 < 0: got %.9f, want %.9f", d, 0.0)
+	}
+}
+
 func TestBearing(t *testing.T) {
 	tests := map[string]struct {
 		p1, p2  earth.Point
@@ -115,6 +262,69 @@ func TestBearing(t *testing.T) {
 	}
 }
 
+func TestGreatCircleTo(t *testing.T) {
+	tests := map[string]struct {
+		p1, p2  earth.Point
+		dist    float64
+		bearing float64
+	}{
+		"east": {
+			p1:      earth.NewPoint(0, 0),
+			p2:      earth.NewPoint(0, 90),
+			dist:    math.Pi / 2,
+			bearing: math.Pi / 2,
+		},
+		"west": {
+			p1:      earth.NewPoint(0, 0),
+			p2:      earth.NewPoint(0, -90),
+			dist:    math.Pi / 2,
+			bearing: 3 * math.Pi / 2,
+		},
+		"north": {
+			p1:      earth.NewPoint(0, 0),
+			p2:      earth.NewPoint(45, 0),
+			dist:    math.Pi / 4,
+			bearing: 0,
+		},
+		"south": {
+			p1:      earth.NewPoint(10, 20),
+			p2:      earth.NewPoint(-10, 20),
+			dist:    earth.ToRad(20),
+			bearing: math.Pi,
+		},
+		"Tasmania - Tucuman": {
+			p1:      earth.NewPoint(-42, 147),
+			p2:      earth.NewPoint(-26, -65),
+			dist:    earth.Distance(earth.NewPoint(-42, 147), earth.NewPoint(-26, -65)),
+			bearing: 2.623630,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			dist, bearing := test.p1.GreatCircleTo(test.p2)
+			if math.Abs(dist-test.dist) > 1e-6 {
+				t.Errorf("%s: distance: got %.6f, want %.6f", name, dist, test.dist)
+			}
+			if math.Abs(bearing-test.bearing) > 1e-6 {
+				t.Errorf("%s: bearing: got %.6f, want %.6f", name, bearing, test.bearing)
+			}
+		})
+	}
+
+	// same point
+	p := earth.NewPoint(-26, -65)
+	if dist, bearing := p.GreatCircleTo(p); dist != 0 || bearing != 0 {
+		t.Errorf("same point: got %.6f %.6f, want %.6f %.6f", dist, bearing, 0.0, 0.0)
+	}
+
+	// antipode
+	q := earth.NewPoint(26, 115)
+	if dist, _ := p.GreatCircleTo(q); math.Abs(dist-math.Pi) > 1e-9 {
+		t.Errorf("antipode: distance: got %.9f, want %.9f", dist, math.Pi)
+	}
+}
+
 func TestDestination(t *testing.T) {
 	tests := map[string]struct {
 		p1, p2 earth.Point
@@ -163,3 +373,118 @@ func TestDestination(t *testing.T) {
 	}
 
 }
+
+func TestInterpolate(t *testing.T) {
+	p := earth.NewPoint(0, 0)
+	q := earth.NewPoint(0, 90)
+
+	tests := map[float64]earth.Point{
+		0:    p,
+		0.5:  earth.NewPoint(0, 45),
+		1:    q,
+		0.25: earth.NewPoint(0, 22.5),
+	}
+	for f, want := range tests {
+		got := earth.Interpolate(p, q, f)
+		if d := earth.Distance(got, want); d > 1e-9 {
+			t.Errorf("interpolate %.2f: got %.6f,%.6f, want %.6f,%.6f", f, got.Latitude(), got.Longitude(), want.Latitude(), want.Longitude())
+		}
+	}
+
+	// over the pole
+	got := earth.Interpolate(earth.NewPoint(80, 0), earth.NewPoint(80, 180), 0.5)
+	if d := earth.Distance(got, earth.NorthPole); d > 1e-9 {
+		t.Errorf("interpolate over pole: got %.6f,%.6f, want north pole", got.Latitude(), got.Longitude())
+	}
+}
+
+func TestMidpoint(t *testing.T) {
+	p := earth.NewPoint(0, -20)
+	q := earth.NewPoint(0, 40)
+	want := earth.NewPoint(0, 10)
+
+	got := earth.Midpoint(p, q)
+	if d := earth.Distance(got, want); d > 1e-9 {
+		t.Errorf("midpoint: got %.6f,%.6f, want %.6f,%.6f", got.Latitude(), got.Longitude(), want.Latitude(), want.Longitude())
+	}
+	if d := earth.Distance(got, earth.Interpolate(p, q, 0.5)); d > 1e-9 {
+		t.Errorf("midpoint: got %.6f,%.6f, different from interpolation", got.Latitude(), got.Longitude())
+	}
+
+	// antipodal points
+	if got := earth.Midpoint(p, earth.NewPoint(0, 160)); got != p {
+		t.Errorf("midpoint antipodal: got %.6f,%.6f, want %.6f,%.6f", got.Latitude(), got.Longitude(), p.Latitude(), p.Longitude())
+	}
+}
+
+func TestCentroid(t *testing.T) {
+	pts := []earth.Point{
+		earth.NewPoint(10, 0),
+		earth.NewPoint(-10, 0),
+		earth.NewPoint(0, 10),
+		earth.NewPoint(0, -10),
+	}
+	got := earth.Centroid(pts)
+	if d := earth.Distance(got, earth.NewPoint(0, 0)); d > 1e-9 {
+		t.Errorf("centroid: got %.6f,%.6f, want 0,0", got.Latitude(), got.Longitude())
+	}
+
+	// two points
+	p := earth.NewPoint(0, -20)
+	q := earth.NewPoint(0, 40)
+	if d := earth.Distance(earth.Centroid([]earth.Point{p, q}), earth.Midpoint(p, q)); d > 1e-9 {
+		t.Errorf("centroid: two points: different from midpoint")
+	}
+
+	// antipodal points
+	if got := earth.Centroid([]earth.Point{p, earth.NewPoint(0, 160)}); got != p {
+		t.Errorf("centroid antipodal: got %.6f,%.6f, want %.6f,%.6f", got.Latitude(), got.Longitude(), p.Latitude(), p.Longitude())
+	}
+}
+
+func TestTriangle(t *testing.T) {
+	// an octant
+	a, b, c, area := earth.Triangle(earth.NorthPole, earth.NewPoint(0, 0), earth.NewPoint(0, 90))
+	for i, ang := range []float64{a, b, c} {
+		if math.Abs(ang-math.Pi/2) > 1e-9 {
+			t.Errorf("octant: angle %d: got %.6f, want %.6f", i, ang, math.Pi/2)
+		}
+	}
+	if math.Abs(area-math.Pi/2) > 1e-9 {
+		t.Errorf("octant: area: got %.6f, want %.6f", area, math.Pi/2)
+	}
+
+	// collinear points
+	_, _, _, area = earth.Triangle(earth.NewPoint(0, 0), earth.NewPoint(0, 10), earth.NewPoint(0, 30))
+	if area > 1e-9 {
+		t.Errorf("collinear: area: got %.6f, want 0", area)
+	}
+
+	// repeated points
+	p := earth.NewPoint(-34, -58)
+	if a, b, c, area := earth.Triangle(p, p, earth.NewPoint(0, 0)); a != 0 || b != 0 || c != 0 || area != 0 {
+		t.Errorf("repeated point: got %.6f, %.6f, %.6f, %.6f, want all 0", a, b, c, area)
+	}
+}
+
+func TestWaypoints(t *testing.T) {
+	p := earth.NewPoint(-42, 147)
+	q := earth.NewPoint(-26, -65)
+	step := earth.ToRad(1)
+
+	pts := earth.Waypoints(p, q, step)
+	if pts[0] != p || pts[len(pts)-1] != q {
+		t.Errorf("waypoints: endpoints not included")
+	}
+
+	d := earth.Distance(p, q)
+	want := int(math.Ceil(d/step)) + 1
+	if len(pts) != want {
+		t.Errorf("waypoints: got %d points, want %d", len(pts), want)
+	}
+	for i := 1; i < len(pts); i++ {
+		if s := earth.Distance(pts[i-1], pts[i]); s > step+1e-9 {
+			t.Errorf("waypoint %d: step %.6f, want <= %.6f", i, s, step)
+		}
+	}
+}
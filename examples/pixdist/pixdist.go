@@ -67,7 +67,7 @@ func main() {
 
 func distances(pix *earth.Pixelation, px int) plotter.XYs {
 	v := make(plotter.XYs, pix.Rings())
-	rStep := earth.ToRad(pix.Step())
+	rStep := pix.StepRad()
 	for r := 0; r < pix.Rings(); r++ {
 		v[r].X = float64(r) * rStep
 	}
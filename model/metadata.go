@@ -0,0 +1,38 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package model
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// Metadata is a set of key-value pairs
+// (for example,
+// the source files or the parameters used to build a model)
+// that are stored as comments in a TSV file.
+//
+// Each pair is written as a comment line
+// with the format "# key: value",
+// sorted by key.
+// As readers ignore comment lines,
+// metadata is not read back.
+type Metadata map[string]string
+
+// Write writes the metadata as comment lines.
+func (m Metadata) write(w io.Writer) {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	r := strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ")
+	for _, k := range keys {
+		fmt.Fprintf(w, "# %s: %s\n", r.Replace(k), r.Replace(m[k]))
+	}
+}
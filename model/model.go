@@ -27,6 +27,8 @@ import (
 	"time"
 
 	"github.com/js-arias/earth"
+	"github.com/js-arias/earth/rotation"
+	"gonum.org/v1/gonum/spatial/r3"
 )
 
 // A Recons is an editable plate motion model
@@ -49,6 +51,84 @@ func NewRecons(pix *earth.Pixelation) *Recons {
 	}
 }
 
+// BuildRecons returns a new reconstruction model
+// with the locations of the pixels of a pixelated plates model
+// rotated to each one of the given ages
+// (in years)
+// using a rotation model.
+//
+// Pixels of plates without a rotation
+// at a given age
+// are ignored.
+// Use AddRotation to retrieve the number
+// of pixels without a rotation.
+func BuildRecons(pp *PixPlate, rot rotation.Rotation, ages []int64) *Recons {
+	rec := NewRecons(pp.Pixelation())
+	for _, p := range pp.Plates() {
+		for _, a := range ages {
+			rec.AddRotation(pp, rot, p, a)
+		}
+	}
+	return rec
+}
+
+// BuildReconsFixed is like BuildRecons,
+// but the locations of the pixels
+// are in the reference frame of a fixed plate
+// (e.g. an Africa-fixed frame)
+// instead of the absolute frame
+// of the rotation model.
+// Then,
+// the pixels of the fixed plate
+// are at their present locations at every age.
+func BuildReconsFixed(pp *PixPlate, rot rotation.Rotation, fixed int, ages []int64) *Recons {
+	rec := NewRecons(pp.Pixelation())
+	for _, p := range pp.Plates() {
+		for _, a := range ages {
+			rec.AddRelativeRotation(pp, rot, p, fixed, a)
+		}
+	}
+	return rec
+}
+
+// Consensus returns the number of reconstruction models
+// that place any plate at each pixel
+// at the given age
+// (in years).
+// Pixels not occupied by any model are not included.
+//
+// For each model,
+// the closest time stage of the age is used
+// (or the youngest time stage,
+// if the age is younger than all time stages).
+// All models must use the same pixelation.
+func Consensus(age int64, recs ...*Recons) (map[int]int, error) {
+	count := make(map[int]int)
+	for i, rec := range recs {
+		if eq, want := rec.pix.Equator(), recs[0].pix.Equator(); eq != want {
+			return nil, fmt.Errorf("model %d: got equator %d, want %d", i, eq, want)
+		}
+		first, ok := rec.FirstStage()
+		if !ok {
+			continue
+		}
+		a := rec.ClosestStageAge(max(age, first))
+
+		used := make(map[int]bool)
+		for _, p := range rec.plates {
+			for _, px := range p.pix {
+				for _, id := range px.stages[a] {
+					used[id] = true
+				}
+			}
+		}
+		for id := range used {
+			count[id]++
+		}
+	}
+	return count, nil
+}
+
 // Add adds a set of pixel locations
 // at a time stage,
 // in years,
@@ -70,10 +150,7 @@ func (rec *Recons) Add(plate int, locations map[int][]int, age int64) {
 	}
 
 	for pixel, stPix := range locations {
-		if pixel >= rec.pix.Len() {
-			msg := fmt.Errorf("pixel ID %d is invalid", pixel)
-			panic(msg)
-		}
+		rec.pix.MustID(pixel)
 
 		px, ok := p.pix[pixel]
 		if !ok {
@@ -106,6 +183,172 @@ func (rec *Recons) Add(plate int, locations map[int][]int, age int64) {
 	}
 }
 
+// AddRotation adds the locations of the pixels of a plate,
+// defined in a pixelated plates model,
+// at the given age
+// (in years),
+// using a rotation model.
+// It returns the number of pixels alive at that age
+// that were not rotated
+// because the rotation of the plate is undefined.
+//
+// The pixelation of the pixelated plates model
+// must be the same as the one of the reconstruction model.
+func (rec *Recons) AddRotation(pp *PixPlate, rot rotation.Rotation, plate int, age int64) int {
+	l := pp.AliveAt(plate, age)
+	r, ok := rot.Rotation(plate, age)
+	if !ok {
+		return len(l)
+	}
+	rec.addRotated(plate, l, r, age)
+	return 0
+}
+
+// AddRelativeRotation is like AddRotation,
+// but the pixels are rotated
+// using the rotation of the plate
+// relative to a fixed plate,
+// so the locations are in the reference frame
+// in which the fixed plate does not move.
+// It returns the number of pixels alive at that age
+// that were not rotated
+// because the rotation of the plate,
+// or the rotation of the fixed plate,
+// is undefined.
+func (rec *Recons) AddRelativeRotation(pp *PixPlate, rot rotation.Rotation, plate, fixed int, age int64) int {
+	l := pp.AliveAt(plate, age)
+	r, ok := rot.RelativeRotation(plate, fixed, age)
+	if !ok {
+		return len(l)
+	}
+	rec.addRotated(plate, l, r, age)
+	return 0
+}
+
+// AddRotated adds the locations of the given pixels
+// of a plate
+// rotated with a total rotation.
+func (rec *Recons) addRotated(plate int, l []int, r r3.Rotation, age int64) {
+	locs := make(map[int][]int, len(l))
+	pix := make(map[int]bool, len(l))
+	used := make(map[int]bool, len(l))
+	first := rec.pix.Len()
+	last := 0
+	for _, id := range l {
+		pix[id] = true
+		pt := rec.pix.ID(id).Point().Vector()
+		v := r.Rotate(pt)
+		np := rec.pix.FromVector(v)
+		locs[id] = []int{np.ID()}
+		used[np.ID()] = true
+		if np.ID() < first {
+			first = np.ID()
+		}
+		if np.ID() > last {
+			last = np.ID()
+		}
+	}
+
+	// Get "present" pixels from "past" pixels
+	// so we are sure that every pixel in the past
+	// has an assignment in the present.
+	// This reduce the number of "holes" produced
+	// when a rotation is performed
+	// because of the discrete nature of the pixelation.
+	inv := rotation.Inverse(r)
+	for id := first; id <= last; id++ {
+		if used[id] {
+			continue
+		}
+		np := rec.pix.ID(id).Point().Vector()
+		v := inv.Rotate(np)
+		px := rec.pix.FromVector(v)
+		if !pix[px.ID()] {
+			continue
+		}
+		locs[px.ID()] = append(locs[px.ID()], id)
+	}
+
+	rec.Add(plate, locs, age)
+}
+
+// ClosestStageAge returns the closest stage age
+// for a given time
+// (i.e. the age of the oldest time stage
+// that is youngest than the given age).
+func (rec *Recons) ClosestStageAge(age int64) int64 {
+	return closestStageAge(rec.Stages(), age)
+}
+
+// EnsurePresent adds a present time stage
+// (i.e. age 0)
+// to a reconstruction model,
+// in which each pixel is at its own location,
+// if the model does not have a present time stage.
+// If the stage is already defined,
+// the model is not changed.
+func (rec *Recons) EnsurePresent() {
+	for _, p := range rec.plates {
+		for _, px := range p.pix {
+			if _, ok := px.stages[0]; ok {
+				return
+			}
+		}
+	}
+
+	for _, p := range rec.plates {
+		for _, px := range p.pix {
+			px.stages[0] = []int{px.id}
+		}
+	}
+}
+
+// FirstStage returns the age of the youngest time stage
+// of a plate motion model.
+// It returns false if there are no stages defined.
+func (rec *Recons) FirstStage() (int64, bool) {
+	return firstStage(rec.Stages())
+}
+
+// LastStage returns the age of the oldest time stage
+// of a plate motion model.
+// It returns false if there are no stages defined.
+func (rec *Recons) LastStage() (int64, bool) {
+	return lastStage(rec.Stages())
+}
+
+// PixelHistory returns the locations
+// of a pixel at present time
+// at each time stage,
+// in years.
+// As a present pixel might be assigned to more than one plate,
+// the locations are grouped by plate,
+// and sorted by plate ID.
+// If the pixel is not assigned to any plate,
+// it returns nil.
+func (rec *Recons) PixelHistory(presentPixel int) map[int64][]PlatePixels {
+	var h map[int64][]PlatePixels
+	for _, plate := range rec.Plates() {
+		px, ok := rec.plates[plate].pix[presentPixel]
+		if !ok {
+			continue
+		}
+		if h == nil {
+			h = make(map[int64][]PlatePixels)
+		}
+		for a, st := range px.stages {
+			if len(st) == 0 {
+				continue
+			}
+			h[a] = append(h[a], PlatePixels{
+				Plate:  plate,
+				Pixels: slices.Clone(st),
+			})
+		}
+	}
+	return h
+}
+
 // Pixelation returns the underlying equal area pixelation
 // of the model.
 func (rec *Recons) Pixelation() *earth.Pixelation {
@@ -150,6 +393,30 @@ func (rec *Recons) PixStage(plate int, age int64) map[int][]int {
 	return st
 }
 
+// PixStageAlive is like PixStage,
+// but it only returns the locations of the pixels
+// that exist at the time stage,
+// as defined by their ages
+// in a pixelated plates model.
+// Pixels absent from the pixelated plates model
+// are not returned.
+func (rec *Recons) PixStageAlive(pp *PixPlate, plate int, age int64) map[int][]int {
+	st := rec.PixStage(plate, age)
+	if len(st) == 0 {
+		return st
+	}
+
+	alive := make(map[int][]int, len(st))
+	for _, id := range pp.AliveAt(plate, age) {
+		sp, ok := st[id]
+		if !ok {
+			continue
+		}
+		alive[id] = sp
+	}
+	return alive
+}
+
 // Plates returns an slice with the plate IDs
 // of the reconstruction model.
 func (rec *Recons) Plates() []int {
@@ -161,6 +428,20 @@ func (rec *Recons) Plates() []int {
 	return ps
 }
 
+// StageDistance returns the difference
+// (in years)
+// between an age
+// and the age of the time stage
+// returned by ClosestStageAge.
+// If the age is younger than all time stages
+// (for which ClosestStageAge is undefined),
+// it returns the difference with the youngest stage.
+// If there are no time stages,
+// it returns -1.
+func (rec *Recons) StageDistance(age int64) int64 {
+	return stageDistance(rec.Stages(), age)
+}
+
 // Stages returns the time stages,
 // in years,
 // defined for a reconstruction model.
@@ -183,6 +464,62 @@ func (rec *Recons) Stages() []int64 {
 	return st
 }
 
+// Validate checks the consistency of a reconstruction model,
+// and returns an error for each problem found.
+// It reports plates without pixels,
+// pixels without locations at a time stage,
+// and stage pixels with an invalid ID
+// for the pixelation.
+// If the model is consistent,
+// it returns nil.
+//
+// Errors are sorted by plate,
+// pixel,
+// and age.
+func (rec *Recons) Validate() []error {
+	var errs []error
+	for _, pID := range rec.Plates() {
+		p := rec.plates[pID]
+		if len(p.pix) == 0 {
+			errs = append(errs, fmt.Errorf("plate %d: no pixels", pID))
+			continue
+		}
+
+		ids := make([]int, 0, len(p.pix))
+		for id := range p.pix {
+			ids = append(ids, id)
+		}
+		slices.Sort(ids)
+
+		for _, id := range ids {
+			px := p.pix[id]
+			if !rec.pix.Valid(id) {
+				errs = append(errs, fmt.Errorf("plate %d: invalid pixel %d", pID, id))
+			}
+			for _, a := range stageAges(px.stages) {
+				st := px.stages[a]
+				if len(st) == 0 {
+					errs = append(errs, fmt.Errorf("plate %d: pixel %d: age %d: no stage pixels", pID, id, a))
+					continue
+				}
+				for _, sp := range st {
+					if !rec.pix.Valid(sp) {
+						errs = append(errs, fmt.Errorf("plate %d: pixel %d: age %d: invalid stage pixel %d", pID, id, a, sp))
+					}
+				}
+			}
+		}
+	}
+	return errs
+}
+
+// PlatePixels is a set of pixel locations
+// of a given plate.
+type PlatePixels struct {
+	Plate  int
+	Pixels []int
+}
+
 // RecPlate is a collection of time pixels
 // associated with a tectonic plate.
 type recPlate struct {
@@ -287,7 +624,10 @@ func ReadReconsTSV(r io.Reader, pix *earth.Pixelation) (*Recons, error) {
 			return nil, fmt.Errorf("on row %d: field %q: %v", ln, f, err)
 		}
 		if pix == nil {
-			pix = earth.NewPixelation(eq)
+			pix, err = earth.NewPixelationErr(eq)
+			if err != nil {
+				return nil, fmt.Errorf("on row %d: field %q: %v", ln, f, err)
+			}
 		}
 		if pix.Equator() != eq {
 			return nil, fmt.Errorf("on row %d: field %q: got %d, want %d value", ln, f, eq, pix.Equator())
@@ -315,7 +655,7 @@ func ReadReconsTSV(r io.Reader, pix *earth.Pixelation) (*Recons, error) {
 		if err != nil {
 			return nil, fmt.Errorf("on row %d: field %q: %v", ln, f, err)
 		}
-		if id >= pix.Len() {
+		if !pix.Valid(id) {
 			return nil, fmt.Errorf("on row %d: field %q: invalid pixel value %d", ln, f, id)
 		}
 		px, ok := p.pix[id]
@@ -338,7 +678,7 @@ func ReadReconsTSV(r io.Reader, pix *earth.Pixelation) (*Recons, error) {
 		if err != nil {
 			return nil, fmt.Errorf("on row %d: field %q: %v", ln, f, err)
 		}
-		if sID >= pix.Len() {
+		if !pix.Valid(sID) {
 			return nil, fmt.Errorf("on row %d: field %q: invalid pixel value %d", ln, f, sID)
 		}
 		px.stages[age] = append(px.stages[age], sID)
@@ -361,19 +701,49 @@ func ReadReconsTSV(r io.Reader, pix *earth.Pixelation) (*Recons, error) {
 
 // TSV encodes a plate motion model
 // as a TSV file.
-func (rec *Recons) TSV(w io.Writer) error {
+// Additional metadata can be given
+// and it will be written as comments
+// in the header of the file.
+func (rec *Recons) TSV(w io.Writer, meta ...Metadata) error {
 	bw := bufio.NewWriter(w)
 	fmt.Fprintf(bw, "# plate motion model\n")
 	fmt.Fprintf(bw, "# data save on: %s\n", time.Now().Format(time.RFC3339))
-	tab := csv.NewWriter(bw)
-	tab.Comma = '\t'
-	tab.UseCRLF = true
+	for _, m := range meta {
+		m.write(bw)
+	}
+	if err := rec.writeRows(bw); err != nil {
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("while writing data: %v", err)
+	}
+	return nil
+}
 
-	if err := tab.Write(recHeader); err != nil {
+// WriteTo writes a plate motion model
+// into a TSV file,
+// as in TSV without metadata.
+// It implements the io.WriterTo interface.
+func (rec *Recons) WriteTo(w io.Writer) (int64, error) {
+	cw := &countWriter{w: w}
+	err := rec.TSV(cw)
+	return cw.n, err
+}
+
+// WriteRows writes the header
+// and the data rows
+// of a plate motion model.
+// Rows are written in the same format
+// used by a csv.Writer
+// (tab delimited, with CRLF line endings),
+// reusing the buffers
+// to reduce memory allocations.
+func (rec *Recons) writeRows(w *bufio.Writer) error {
+	if _, err := w.WriteString(strings.Join(recHeader, "\t") + "\r\n"); err != nil {
 		return fmt.Errorf("while writing header: %v", err)
 	}
 
-	eq := strconv.Itoa(rec.pix.Equator())
+	eq := strconv.AppendInt(nil, int64(rec.pix.Equator()), 10)
 
 	plates := make([]int, 0, len(rec.plates))
 	for _, p := range rec.plates {
@@ -381,50 +751,56 @@ func (rec *Recons) TSV(w io.Writer) error {
 	}
 	slices.Sort(plates)
 
+	var pxs []int
+	var st []int64
+	var row []byte
 	for _, p := range plates {
 		plate := rec.plates[p]
-		pxs := make([]int, 0, len(plate.pix))
+		pxs = pxs[:0]
 		for _, px := range plate.pix {
 			pxs = append(pxs, px.id)
 		}
 		slices.Sort(pxs)
 
-		pID := strconv.Itoa(plate.plate)
-
 		for _, id := range pxs {
 			ps := plate.pix[id]
-			st := make([]int64, 0, len(ps.stages))
+			st = st[:0]
 			for a := range ps.stages {
 				st = append(st, a)
 			}
 			slices.Sort(st)
 
-			pixID := strconv.Itoa(ps.id)
-
 			for _, a := range st {
-				age := strconv.FormatInt(a, 10)
 				for _, sp := range ps.stages[a] {
-					row := []string{
-						eq,
-						pID,
-						pixID,
-						age,
-						strconv.Itoa(sp),
-					}
-					if err := tab.Write(row); err != nil {
+					row = append(row[:0], eq...)
+					row = append(row, '\t')
+					row = strconv.AppendInt(row, int64(plate.plate), 10)
+					row = append(row, '\t')
+					row = strconv.AppendInt(row, int64(ps.id), 10)
+					row = append(row, '\t')
+					row = strconv.AppendInt(row, a, 10)
+					row = append(row, '\t')
+					row = strconv.AppendInt(row, int64(sp), 10)
+					row = append(row, '\r', '\n')
+					if _, err := w.Write(row); err != nil {
 						return fmt.Errorf("while writing data: %v", err)
 					}
 				}
 			}
 		}
 	}
-
-	tab.Flush()
-	if err := tab.Error(); err != nil {
-		return fmt.Errorf("while writing data: %v", err)
-	}
-	if err := bw.Flush(); err != nil {
-		return fmt.Errorf("while writing data: %v", err)
-	}
 	return nil
 }
+
+// A countWriter is a writer
+// that counts the number of bytes written.
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
@@ -6,12 +6,18 @@ package model_test
 
 import (
 	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
 	"reflect"
+	"slices"
+	"strconv"
 	"strings"
 	"testing"
 
 	"github.com/js-arias/earth"
 	"github.com/js-arias/earth/model"
+	"github.com/js-arias/earth/rotation"
 )
 
 func TestNewRecon(t *testing.T) {
@@ -126,3 +132,542 @@ func makeRecons(t testing.TB) *model.Recons {
 
 	return rec
 }
+
+func TestReconsPixelHistory(t *testing.T) {
+	rec := makeRecons(t)
+	rec.Add(101, map[int][]int{17055: {19060}}, 100_000_000)
+
+	want := map[int64][]model.PlatePixels{
+		100_000_000: {
+			{Plate: 101, Pixels: []int{19060}},
+			{Plate: 59_999, Pixels: []int{19055}},
+		},
+		140_000_000: {
+			{Plate: 59_999, Pixels: []int{20055, 20056}},
+		},
+	}
+	if got := rec.PixelHistory(17055); !reflect.DeepEqual(got, want) {
+		t.Errorf("pixel history: got %v, want %v", got, want)
+	}
+
+	if got := rec.PixelHistory(1); got != nil {
+		t.Errorf("pixel history: unknown pixel: got %v, want nil", got)
+	}
+}
+
+func TestTSVMetadata(t *testing.T) {
+	rec := makeRecons(t)
+
+	meta := model.Metadata{
+		"rotation": "model.rot",
+		"source":   "plates.gpml\nother.gpml",
+	}
+
+	var buf bytes.Buffer
+	if err := rec.TSV(&buf, meta); err != nil {
+		t.Fatalf("while writing data: %v", err)
+	}
+	for _, want := range []string{
+		"# rotation: model.rot\n",
+		"# source: plates.gpml other.gpml\n",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("metadata: %q not found in output", want)
+		}
+	}
+	if i, j := strings.Index(buf.String(), "# rotation"), strings.Index(buf.String(), "# source"); i > j {
+		t.Errorf("metadata: keys not sorted")
+	}
+
+	nr, err := model.ReadReconsTSV(strings.NewReader(buf.String()), nil)
+	if err != nil {
+		t.Fatalf("while reading data: %v", err)
+	}
+	testRecons(t, nr)
+
+	// without metadata
+	buf.Reset()
+	if err := rec.TSV(&buf); err != nil {
+		t.Fatalf("while writing data: %v", err)
+	}
+	var comments int
+	for _, ln := range strings.Split(buf.String(), "\n") {
+		if strings.HasPrefix(ln, "#") {
+			comments++
+		}
+	}
+	if comments != 2 {
+		t.Errorf("comments: got %d, want %d", comments, 2)
+	}
+}
+
+func TestReconsFirstLastStage(t *testing.T) {
+	empty := model.NewRecons(earth.NewPixelation(360))
+	if _, ok := empty.FirstStage(); ok {
+		t.Errorf("first stage: empty model with stages")
+	}
+	if _, ok := empty.LastStage(); ok {
+		t.Errorf("last stage: empty model with stages")
+	}
+
+	rec := makeRecons(t)
+	if f, ok := rec.FirstStage(); !ok || f != 100_000_000 {
+		t.Errorf("first stage: got %d, want %d", f, 100_000_000)
+	}
+	if l, ok := rec.LastStage(); !ok || l != 140_000_000 {
+		t.Errorf("last stage: got %d, want %d", l, 140_000_000)
+	}
+
+	tot := model.NewTotal(rec)
+	if f, ok := tot.FirstStage(); !ok || f != 100_000_000 {
+		t.Errorf("total: first stage: got %d, want %d", f, 100_000_000)
+	}
+	if l, ok := tot.LastStage(); !ok || l != 140_000_000 {
+		t.Errorf("total: last stage: got %d, want %d", l, 140_000_000)
+	}
+
+	emptyTot := model.NewTotal(empty)
+	if _, ok := emptyTot.LastStage(); ok {
+		t.Errorf("total: last stage: empty model with stages")
+	}
+}
+
+func TestReconsClosestStageAge(t *testing.T) {
+	rec := makeRecons(t)
+
+	tests := map[string]struct {
+		age  int64
+		want int64
+	}{
+		"exact":  {140_000_000, 140_000_000},
+		"middle": {125_000_000, 100_000_000},
+		"oldest": {150_000_000, 140_000_000},
+	}
+	for name, test := range tests {
+		if c := rec.ClosestStageAge(test.age); c != test.want {
+			t.Errorf("%s: got %d, want %d", name, c, test.want)
+		}
+	}
+}
+
+func TestReconsStageDistance(t *testing.T) {
+	empty := model.NewRecons(earth.NewPixelation(360))
+	if d := empty.StageDistance(100_000_000); d != -1 {
+		t.Errorf("empty model: got %d, want %d", d, -1)
+	}
+
+	rec := makeRecons(t)
+	tests := map[string]struct {
+		age  int64
+		want int64
+	}{
+		"exact":       {140_000_000, 0},
+		"equidistant": {120_000_000, 20_000_000},
+		"oldest":      {150_000_000, 10_000_000},
+		"youngest":    {90_000_000, 10_000_000},
+	}
+	for name, test := range tests {
+		if d := rec.StageDistance(test.age); d != test.want {
+			t.Errorf("%s: got %d, want %d", name, d, test.want)
+		}
+	}
+}
+
+func TestReconsWriteTo(t *testing.T) {
+	for name, rec := range map[string]*model.Recons{
+		"small": makeRecons(t),
+		"large": makeLargeRecons(t, 5, 500, 10),
+		"empty": model.NewRecons(earth.NewPixelation(360)),
+	} {
+		want := csvRecons(t, rec)
+
+		var tsv bytes.Buffer
+		if err := rec.TSV(&tsv); err != nil {
+			t.Fatalf("%s: while writing data: %v", name, err)
+		}
+		if got := noComments(tsv.String()); got != want {
+			t.Errorf("%s: output of TSV different from csv.Writer", name)
+		}
+
+		var buf bytes.Buffer
+		n, err := rec.WriteTo(&buf)
+		if err != nil {
+			t.Fatalf("%s: while writing data: %v", name, err)
+		}
+		if n != int64(buf.Len()) {
+			t.Errorf("%s: got %d bytes written, want %d", name, n, buf.Len())
+		}
+		if got := noComments(buf.String()); got != want {
+			t.Errorf("%s: output of WriteTo different from csv.Writer", name)
+		}
+	}
+}
+
+// CsvRecons returns the rows of a plate motion model
+// written with a csv.Writer,
+// as a reference for the TSV output.
+func csvRecons(t testing.TB, rec *model.Recons) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	tab := csv.NewWriter(&buf)
+	tab.Comma = '\t'
+	tab.UseCRLF = true
+	if err := tab.Write([]string{"equator", "plate", "pixel", "age", "stage-pixel"}); err != nil {
+		t.Fatalf("while writing header: %v", err)
+	}
+
+	eq := strconv.Itoa(rec.Pixelation().Equator())
+	ages := rec.Stages()
+	for _, p := range rec.Plates() {
+		stages := make(map[int64]map[int][]int, len(ages))
+		for _, a := range ages {
+			stages[a] = rec.PixStage(p, a)
+		}
+		for _, px := range rec.Pixels(p) {
+			for _, a := range ages {
+				for _, sp := range stages[a][px] {
+					row := []string{
+						eq,
+						strconv.Itoa(p),
+						strconv.Itoa(px),
+						strconv.FormatInt(a, 10),
+						strconv.Itoa(sp),
+					}
+					if err := tab.Write(row); err != nil {
+						t.Fatalf("while writing data: %v", err)
+					}
+				}
+			}
+		}
+	}
+	tab.Flush()
+	if err := tab.Error(); err != nil {
+		t.Fatalf("while writing data: %v", err)
+	}
+	return buf.String()
+}
+
+// NoComments removes the comment lines
+// (i.e. the metadata)
+// of a TSV output.
+func noComments(s string) string {
+	var lines []string
+	for _, ln := range strings.SplitAfter(s, "\n") {
+		if strings.HasPrefix(ln, "#") {
+			continue
+		}
+		lines = append(lines, ln)
+	}
+	return strings.Join(lines, "")
+}
+
+func BenchmarkReconsWriteTo(b *testing.B) {
+	rec := makeLargeRecons(b, 20, 2000, 20)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := rec.WriteTo(io.Discard); err != nil {
+			b.Fatalf("while writing data: %v", err)
+		}
+	}
+}
+
+// MakeLargeRecons returns a synthetic plate motion model
+// with the indicated number of plates,
+// pixels per plate,
+// and time stages.
+func makeLargeRecons(t testing.TB, plates, pixels, stages int) *model.Recons {
+	t.Helper()
+
+	pix := earth.NewPixelation(360)
+	rec := model.NewRecons(pix)
+	for p := 0; p < plates; p++ {
+		for s := 1; s <= stages; s++ {
+			locs := make(map[int][]int, pixels)
+			for i := 0; i < pixels; i++ {
+				id := (p*pixels + i) % pix.Len()
+				locs[id] = []int{(id + s*37) % pix.Len(), (id + s*37 + 1) % pix.Len()}
+			}
+			rec.Add(100+p, locs, int64(s)*5_000_000)
+		}
+	}
+	return rec
+}
+
+func TestBuildRecons(t *testing.T) {
+	pix := earth.NewPixelation(360)
+	pp := model.NewPixPlate(pix)
+	var pixels []int
+	for lat := -10.0; lat <= 10; lat++ {
+		for lon := -10.0; lon <= 10; lon++ {
+			pixels = append(pixels, pix.Pixel(lat, lon).ID())
+		}
+	}
+	pp.AddPixels(801, "block", pixels, 200_000_000, 0)
+	present := pp.Pixels(801)
+
+	rot, err := rotation.Read(strings.NewReader("801 0.0 90.0 0.0 0.0 0\n801 100.0 13.0 35.0 -50.0 0\n"))
+	if err != nil {
+		t.Fatalf("unable to read rotation: %v", err)
+	}
+
+	ages := []int64{0, 100_000_000}
+	rec := model.BuildRecons(pp, rot, ages)
+	if got := rec.Stages(); !reflect.DeepEqual(got, ages) {
+		t.Errorf("stages: got %v, want %v", got, ages)
+	}
+
+	for _, a := range ages {
+		st := rec.PixStage(801, a)
+		if len(st) != len(present) {
+			t.Errorf("age %d: present pixels: got %d, want %d", a, len(st), len(present))
+		}
+
+		past := make(map[int]bool)
+		for _, ids := range st {
+			for _, id := range ids {
+				past[id] = true
+			}
+		}
+
+		// the number of pixels in the past
+		// should be similar to the number of pixels
+		// at present time
+		diff := float64(len(past)-len(present)) / float64(len(present))
+		if diff < -0.05 || diff > 0.05 {
+			t.Errorf("age %d: past pixels: got %d, want %d", a, len(past), len(present))
+		}
+	}
+
+	// a plate without rotation
+	pp.AddPixels(802, "no rotation", []int{pix.Pixel(45, 45).ID()}, 200_000_000, 0)
+	rec = model.NewRecons(pix)
+	if n := rec.AddRotation(pp, rot, 802, 100_000_000); n != 1 {
+		t.Errorf("plate without rotation: got %d pixels, want %d", n, 1)
+	}
+}
+
+func TestBuildReconsFixed(t *testing.T) {
+	pix := earth.NewPixelation(360)
+	pp := model.NewPixPlate(pix)
+	var africa, america []int
+	for lat := -10.0; lat <= 10; lat++ {
+		for lon := -10.0; lon <= 10; lon++ {
+			africa = append(africa, pix.Pixel(lat, lon+20).ID())
+			america = append(america, pix.Pixel(lat, lon-60).ID())
+		}
+	}
+	pp.AddPixels(701, "Africa", africa, 200_000_000, 0)
+	pp.AddPixels(201, "South America", america, 200_000_000, 0)
+
+	rot, err := rotation.Read(strings.NewReader("701 0.0 90.0 0.0 0.0 0\n701 100.0 13.0 35.0 -50.0 0\n201 0.0 90.0 0.0 0.0 0\n201 100.0 -40.0 -30.0 20.0 0\n"))
+	if err != nil {
+		t.Fatalf("unable to read rotation: %v", err)
+	}
+
+	ages := []int64{0, 50_000_000, 100_000_000}
+	rec := model.BuildReconsFixed(pp, rot, 701, ages)
+	for _, a := range ages {
+		st := rec.PixStage(701, a)
+		if len(st) != len(pp.Pixels(701)) {
+			t.Errorf("age %d: fixed plate: got %d pixels, want %d", a, len(st), len(pp.Pixels(701)))
+		}
+		for id, ids := range st {
+			if !reflect.DeepEqual(ids, []int{id}) {
+				t.Errorf("age %d: fixed plate: pixel %d: got %v, want %v", a, id, ids, []int{id})
+			}
+		}
+	}
+
+	// the other plate moves relative to the fixed plate
+	abs := model.BuildRecons(pp, rot, ages)
+	r, _ := rot.RelativeRotation(201, 701, 100_000_000)
+	for id, ids := range rec.PixStage(201, 100_000_000) {
+		want := pix.FromVector(r.Rotate(pix.ID(id).Point().Vector())).ID()
+		if !slices.Contains(ids, want) {
+			t.Errorf("moving plate: pixel %d: got %v, want %d", id, ids, want)
+		}
+	}
+	if reflect.DeepEqual(rec.PixStage(201, 100_000_000), abs.PixStage(201, 100_000_000)) {
+		t.Errorf("moving plate: relative and absolute reconstructions are equal")
+	}
+}
+
+func TestReconsAddRotationGap(t *testing.T) {
+	pix := earth.NewPixelation(360)
+	pp := model.NewPixPlate(pix)
+	pp.AddPixels(801, "Australia", []int{100}, 150_000_000, 0)
+	pp.AddPixels(801, "Australia", []int{20000}, 50_000_000, 0)
+
+	// the rotation of the plate
+	// is only defined up to 100 Ma
+	rot, err := rotation.Read(strings.NewReader("801 0.0 90.0 0.0 0.0 0\n801 100.0 13.0 35.0 -50.0 0\n"))
+	if err != nil {
+		t.Fatalf("when reading rotation: %v", err)
+	}
+
+	tests := map[int64]struct {
+		missing int
+		pixels  int
+	}{
+		0:           {0, 2},
+		80_000_000:  {0, 1},
+		120_000_000: {1, 0},
+		200_000_000: {0, 0},
+	}
+	rec := model.NewRecons(pix)
+	for age, test := range tests {
+		if n := rec.AddRotation(pp, rot, 801, age); n != test.missing {
+			t.Errorf("age %d: got %d pixels without rotation, want %d", age, n, test.missing)
+		}
+		if got := len(rec.PixStage(801, age)); got != test.pixels {
+			t.Errorf("age %d: got %d rotated pixels, want %d", age, got, test.pixels)
+		}
+	}
+
+	// with a fixed plate
+	// without rotation
+	rec = model.NewRecons(pix)
+	if n := rec.AddRelativeRotation(pp, rot, 801, 701, 0); n != 2 {
+		t.Errorf("undefined fixed plate: got %d pixels without rotation, want %d", n, 2)
+	}
+}
+
+func TestReconsValidate(t *testing.T) {
+	rec := makeRecons(t)
+	if errs := rec.Validate(); errs != nil {
+		t.Errorf("valid model: got errors %v", errs)
+	}
+
+	pix := rec.Pixelation()
+	rec.Add(10, map[int][]int{}, 100_000_000)
+	rec.Add(20, map[int][]int{
+		100: nil,
+		200: {pix.Len(), 300},
+	}, 100_000_000)
+
+	want := []string{
+		"plate 10: no pixels",
+		fmt.Sprintf("plate 20: pixel 100: age %d: no stage pixels", 100_000_000),
+		fmt.Sprintf("plate 20: pixel 200: age %d: invalid stage pixel %d", 100_000_000, pix.Len()),
+	}
+	errs := rec.Validate()
+	if len(errs) != len(want) {
+		t.Fatalf("got %d errors %v, want %d", len(errs), errs, len(want))
+	}
+	for i, err := range errs {
+		if err.Error() != want[i] {
+			t.Errorf("error %d: got %q, want %q", i, err, want[i])
+		}
+	}
+}
+
+func TestReconsPixStageAlive(t *testing.T) {
+	pix := earth.NewPixelation(360)
+	pp := model.NewPixPlate(pix)
+	pixels := []int{pix.Pixel(0, 0).ID(), pix.Pixel(0, 1).ID()}
+	pp.AddPixels(801, "old", pixels, 200_000_000, 0)
+	pp.AddPixels(802, "young", []int{pix.Pixel(10, 10).ID()}, 50_000_000, 0)
+
+	// a model with stages stored for both plates
+	// at all ages
+	rec := model.NewRecons(pix)
+	for _, p := range pp.Plates() {
+		locs := make(map[int][]int)
+		for _, id := range pp.Pixels(p) {
+			locs[id] = []int{id}
+		}
+		rec.Add(p, locs, 0)
+		rec.Add(p, locs, 100_000_000)
+	}
+
+	if st := rec.PixStageAlive(pp, 802, 0); len(st) != 1 {
+		t.Errorf("plate 802: age 0: got %d pixels, want %d", len(st), 1)
+	}
+	if st := rec.PixStageAlive(pp, 802, 100_000_000); len(st) != 0 {
+		t.Errorf("plate 802: age 100: got %d pixels, want %d", len(st), 0)
+	}
+	if st := rec.PixStage(802, 100_000_000); len(st) != 1 {
+		t.Errorf("plate 802: age 100: stored pixels: got %d, want %d", len(st), 1)
+	}
+
+	got := rec.PixStageAlive(pp, 801, 100_000_000)
+	if want := rec.PixStage(801, 100_000_000); !reflect.DeepEqual(got, want) {
+		t.Errorf("plate 801: age 100: got %v, want %v", got, want)
+	}
+
+	if st := rec.PixStageAlive(pp, 803, 0); len(st) != 0 {
+		t.Errorf("plate 803: got %d pixels, want %d", len(st), 0)
+	}
+}
+
+func TestReconsEnsurePresent(t *testing.T) {
+	rec := makeRecons(t)
+	rec.EnsurePresent()
+	rec.EnsurePresent()
+
+	if got, want := rec.Stages(), []int64{0, 100_000_000, 140_000_000}; !reflect.DeepEqual(got, want) {
+		t.Errorf("stages: got %v, want %v", got, want)
+	}
+	want := map[int][]int{
+		17051: {17051},
+		17055: {17055},
+		17409: {17409},
+		17766: {17766},
+		18122: {18122},
+		18479: {18479},
+	}
+	if got := rec.PixStage(59_999, 0); !reflect.DeepEqual(got, want) {
+		t.Errorf("present stage: got %v, want %v", got, want)
+	}
+
+	// an already defined present stage
+	rec = makeRecons(t)
+	rec.Add(59_999, map[int][]int{17051: {17052}}, 0)
+	rec.EnsurePresent()
+	want = map[int][]int{17051: {17052}}
+	if got := rec.PixStage(59_999, 0); !reflect.DeepEqual(got, want) {
+		t.Errorf("defined present stage: got %v, want %v", got, want)
+	}
+}
+
+func TestConsensus(t *testing.T) {
+	pix := earth.NewPixelation(360)
+
+	r1 := model.NewRecons(pix)
+	r1.Add(1, map[int][]int{100: {200, 201}, 101: {202}}, 50_000_000)
+	r1.Add(1, map[int][]int{100: {300}}, 80_000_000)
+
+	r2 := model.NewRecons(pix)
+	r2.Add(5, map[int][]int{100: {201}}, 40_000_000)
+	r2.Add(6, map[int][]int{500: {600}}, 40_000_000)
+
+	got, err := model.Consensus(60_000_000, r1, r2)
+	if err != nil {
+		t.Fatalf("consensus: unexpected error: %v", err)
+	}
+	want := map[int]int{
+		200: 1,
+		201: 2,
+		202: 1,
+		600: 1,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("consensus: got %v, want %v", got, want)
+	}
+
+	// ages younger than all stages
+	got, err = model.Consensus(0, r1, r2)
+	if err != nil {
+		t.Fatalf("consensus: unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("consensus: at present: got %v, want %v", got, want)
+	}
+
+	r3 := model.NewRecons(earth.NewPixelation(36))
+	if _, err := model.Consensus(0, r1, r3); err == nil {
+		t.Errorf("consensus: expecting error on different pixelations")
+	}
+}
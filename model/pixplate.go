@@ -17,6 +17,7 @@ import (
 	"time"
 
 	"github.com/js-arias/earth"
+	"github.com/js-arias/earth/vector"
 )
 
 // A PixPlate is a collection of pixels
@@ -59,14 +60,76 @@ func (pp *PixPlate) AddPixels(plate int, name string, pixels []int, begin, end i
 	defer p.mu.Unlock()
 
 	for _, id := range pixels {
-		if id >= pp.pix.Len() {
-			msg := fmt.Errorf("pixel ID %d is invalid", id)
-			panic(msg)
-		}
+		pp.pix.MustID(id)
 		p.add(id, name, begin, end)
 	}
 }
 
+// AddFeature adds the pixels of a vector feature
+// to the plate of the feature,
+// using the name and time frame of the feature.
+func (pp *PixPlate) AddFeature(f vector.Feature) {
+	pix := f.Pixels(pp.pix)
+	pp.AddPixels(f.Plate, f.Name, pix, f.Begin, f.End)
+}
+
+// AliveAt returns the sorted IDs
+// of the pixels of a plate
+// that exist at the given age
+// (in years).
+func (pp *PixPlate) AliveAt(plate int, age int64) []int {
+	pp.mu.RLock()
+	p, ok := pp.plates[plate]
+	pp.mu.RUnlock()
+
+	if !ok {
+		return nil
+	}
+
+	p.mu.RLock()
+	var pxs []int
+	for _, px := range p.pix {
+		if !px.AliveAt(age) {
+			continue
+		}
+		pxs = append(pxs, px.ID)
+	}
+	p.mu.RUnlock()
+
+	slices.Sort(pxs)
+	return pxs
+}
+
+// Names returns the names of all the features
+// that contributed to a pixel of a plate.
+// The first name is the name of the pixel
+// (i.e. the name of the feature with the oldest begin age),
+// and the other names are sorted alphabetically.
+// Empty names are ignored.
+func (pp *PixPlate) Names(plate, pixel int) []string {
+	pp.mu.RLock()
+	p, ok := pp.plates[plate]
+	pp.mu.RUnlock()
+
+	if !ok {
+		return nil
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	px, ok := p.pix[pixel]
+	if !ok {
+		return nil
+	}
+
+	var names []string
+	if px.Name != "" {
+		names = append(names, px.Name)
+	}
+	return append(names, p.otherNames(px)...)
+}
+
 // Pixelation returns the underlying pixelation
 // of the pixel collection.
 func (pp *PixPlate) Pixelation() *earth.Pixelation {
@@ -94,6 +157,86 @@ func (pp *PixPlate) Pixel(plate, pixel int) PixAge {
 	return *px
 }
 
+// PlateOf returns the plate of a pixel
+// at the given age
+// (in years).
+// If the pixel is assigned to more than one plate
+// at that age,
+// the plate in which the pixel is older
+// (i.e. with the oldest begin age)
+// will be returned,
+// and if they have the same begin age,
+// the plate with the lowest ID
+// will be returned.
+// It returns false if the pixel is not assigned
+// to any plate at the given age.
+func (pp *PixPlate) PlateOf(pixel int, age int64) (plate int, ok bool) {
+	pp.mu.RLock()
+	defer pp.mu.RUnlock()
+
+	var begin int64
+	for _, p := range pp.plates {
+		p.mu.RLock()
+		px, found := p.pix[pixel]
+		p.mu.RUnlock()
+
+		if !found || !px.AliveAt(age) {
+			continue
+		}
+		if ok {
+			if px.Begin < begin {
+				continue
+			}
+			if px.Begin == begin && p.plate > plate {
+				continue
+			}
+		}
+		plate, begin, ok = p.plate, px.Begin, true
+	}
+	return plate, ok
+}
+
+// PlateIndex returns a map of pixel IDs
+// to the plate of the pixel
+// at the given age
+// (in years).
+// Only pixels assigned to a plate
+// at the given age
+// are included.
+// If a pixel is assigned to more than one plate,
+// the plate is selected
+// using the same rules as PlateOf.
+//
+// It is useful to find the plate
+// of many pixels at the same age.
+func (pp *PixPlate) PlateIndex(age int64) map[int]int {
+	pp.mu.RLock()
+	defer pp.mu.RUnlock()
+
+	idx := make(map[int]int)
+	begin := make(map[int]int64)
+	for _, p := range pp.plates {
+		p.mu.RLock()
+		for id, px := range p.pix {
+			if !px.AliveAt(age) {
+				continue
+			}
+			if pl, ok := idx[id]; ok {
+				if px.Begin < begin[id] {
+					continue
+				}
+				if px.Begin == begin[id] && p.plate > pl {
+					continue
+				}
+			}
+			idx[id] = p.plate
+			begin[id] = px.Begin
+		}
+		p.mu.RUnlock()
+	}
+	return idx
+}
+
 // Pixels return the pixel IDs of a plate.
 func (pp *PixPlate) Pixels(plate int) []int {
 	pp.mu.RLock()
@@ -142,6 +285,7 @@ func (pp *PixPlate) pixPlate(plate int) *pixPlate {
 	p = &pixPlate{
 		plate: plate,
 		pix:   make(map[int]*PixAge),
+		names: make(map[int][]string),
 	}
 
 	pp.mu.Lock()
@@ -169,6 +313,15 @@ type PixAge struct {
 	End   int64
 }
 
+// AliveAt returns true if the pixel exists
+// at the given age
+// (in years).
+// Both ends of the time range are inclusive,
+// so a pixel is alive at its begin and end ages.
+func (p PixAge) AliveAt(age int64) bool {
+	return p.End <= age && age <= p.Begin
+}
+
 // A pixPlate is a collection of pixels
 // associated with a particular tectonic plate.
 type pixPlate struct {
@@ -176,9 +329,15 @@ type pixPlate struct {
 
 	mu  sync.RWMutex
 	pix map[int]*PixAge
+
+	// names of all the features
+	// that contributed to a pixel
+	names map[int][]string
 }
 
 func (pp *pixPlate) add(id int, name string, begin, end int64) {
+	pp.addName(id, name)
+
 	px, ok := pp.pix[id]
 	if !ok {
 		px = &PixAge{
@@ -209,6 +368,37 @@ func (pp *pixPlate) add(id int, name string, begin, end int64) {
 	}
 }
 
+// AddName adds a feature name to a pixel.
+func (pp *pixPlate) addName(id int, name string) {
+	if name == "" {
+		return
+	}
+	if slices.Contains(pp.names[id], name) {
+		return
+	}
+	pp.names[id] = append(pp.names[id], name)
+}
+
+// OtherNames returns the sorted names of the features
+// that contributed to a pixel,
+// excluding the name of the pixel.
+func (pp *pixPlate) otherNames(px *PixAge) []string {
+	var names []string
+	for _, n := range pp.names[px.ID] {
+		if n == px.Name {
+			continue
+		}
+		names = append(names, n)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// NamesSep is the separator used
+// for the names of the features
+// in the names field of a pixelated plates file.
+const namesSep = ";"
+
 var pixHead = []string{
 	"equator",
 	"plate",
@@ -232,6 +422,9 @@ var pixHead = []string{
 // it can include the following fields:
 //
 //   - name, name of the tectonic feature
+//   - names, a semicolon separated list
+//     with the names of other features
+//     that contributed to the pixel
 //
 // Here is an example file:
 //
@@ -279,7 +472,10 @@ func ReadPixPlate(r io.Reader, pix *earth.Pixelation) (*PixPlate, error) {
 			return nil, fmt.Errorf("on row %d: field %q: %v", ln, f, err)
 		}
 		if pix == nil {
-			pix = earth.NewPixelation(eq)
+			pix, err = earth.NewPixelationErr(eq)
+			if err != nil {
+				return nil, fmt.Errorf("on row %d: field %q: %v", ln, f, err)
+			}
 		}
 		if pix.Equator() != eq {
 			return nil, fmt.Errorf("on row %d: field %q: got %d, want %d", ln, f, eq, pix.Equator())
@@ -300,7 +496,7 @@ func ReadPixPlate(r io.Reader, pix *earth.Pixelation) (*PixPlate, error) {
 		if err != nil {
 			return nil, fmt.Errorf("on row %d: field %q: %v", ln, f, err)
 		}
-		if id >= pix.Len() {
+		if !pix.Valid(id) {
 			return nil, fmt.Errorf("on row %d: field %q: invalid pixel value %d", ln, f, id)
 		}
 
@@ -325,6 +521,13 @@ func ReadPixPlate(r io.Reader, pix *earth.Pixelation) (*PixPlate, error) {
 		}
 
 		p.add(id, name, begin, end)
+
+		f = "names"
+		if _, ok := fields[f]; ok {
+			for _, n := range strings.Split(row[fields[f]], namesSep) {
+				p.addName(id, strings.TrimSpace(n))
+			}
+		}
 	}
 	if pp == nil {
 		return nil, fmt.Errorf("while reading data: %v", io.EOF)
@@ -334,10 +537,18 @@ func ReadPixPlate(r io.Reader, pix *earth.Pixelation) (*PixPlate, error) {
 
 // TSV encodes a plate pixelation
 // into a TSV file.
-func (pp *PixPlate) TSV(w io.Writer) error {
+// If a pixel has names of other features,
+// they will be written in the names column.
+// Additional metadata can be given
+// and it will be written as comments
+// in the header of the file.
+func (pp *PixPlate) TSV(w io.Writer, meta ...Metadata) error {
 	bw := bufio.NewWriter(w)
 	fmt.Fprintf(bw, "# tectonic plates pixelation\n")
 	fmt.Fprintf(bw, "# data save on: %s\n", time.Now().Format(time.RFC3339))
+	for _, m := range meta {
+		m.write(bw)
+	}
 
 	tab := csv.NewWriter(bw)
 	tab.Comma = '\t'
@@ -351,15 +562,20 @@ func (pp *PixPlate) TSV(w io.Writer) error {
 		"begin",
 		"end",
 	}
+
+	pp.mu.Lock()
+	defer pp.mu.Unlock()
+
+	withNames := pp.hasOtherNames()
+	if withNames {
+		header = append(header, "names")
+	}
 	if err := tab.Write(header); err != nil {
 		return fmt.Errorf("while writing header: %v", err)
 	}
 
 	eq := strconv.Itoa(pp.pix.Equator())
 
-	pp.mu.Lock()
-	defer pp.mu.Unlock()
-
 	plates := make([]int, 0, len(pp.plates))
 	for _, p := range pp.plates {
 		plates = append(plates, p.plate)
@@ -386,6 +602,9 @@ func (pp *PixPlate) TSV(w io.Writer) error {
 				strconv.FormatInt(px.Begin, 10),
 				strconv.FormatInt(px.End, 10),
 			}
+			if withNames {
+				row = append(row, strings.Join(p.otherNames(px), namesSep))
+			}
 			if err := tab.Write(row); err != nil {
 				return fmt.Errorf("while writing data: %v", err)
 			}
@@ -401,3 +620,16 @@ func (pp *PixPlate) TSV(w io.Writer) error {
 	}
 	return nil
 }
+
+// HasOtherNames returns true if a pixel
+// has names of other features.
+func (pp *PixPlate) hasOtherNames() bool {
+	for _, p := range pp.plates {
+		for _, px := range p.pix {
+			if len(p.otherNames(px)) > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -12,6 +12,8 @@ import (
 
 	"github.com/js-arias/earth"
 	"github.com/js-arias/earth/model"
+	"github.com/js-arias/earth/rotation"
+	"github.com/js-arias/earth/vector"
 )
 
 func TestNewPixPlate(t *testing.T) {
@@ -127,3 +129,219 @@ func testPixPlate(t testing.TB, pp *model.PixPlate) {
 		}
 	}
 }
+
+func TestPixPlateAddFeature(t *testing.T) {
+	pix := earth.NewPixelation(360)
+	pp := model.NewPixPlate(pix)
+
+	pt := vector.Feature{
+		Name:  "Parana",
+		Type:  vector.Generic,
+		Plate: 202,
+		Begin: 600_000_000,
+		Point: &vector.Point{Lat: -26, Lon: -65},
+	}
+	pp.AddFeature(pt)
+
+	want := model.PixAge{
+		Name:  "Parana",
+		ID:    29611,
+		Plate: 202,
+		Begin: 600_000_000,
+	}
+	if px := pp.Pixel(202, 29611); !reflect.DeepEqual(px, want) {
+		t.Errorf("point feature: got %v, want %v", px, want)
+	}
+
+	poly := vector.Feature{
+		Name:  "square",
+		Type:  vector.Generic,
+		Plate: 59_999,
+		Begin: 140_000_000,
+		End:   20_000_000,
+		Polygon: vector.Polygon{
+			{Lat: 10, Lon: 10},
+			{Lat: 10, Lon: 14},
+			{Lat: 6, Lon: 14},
+			{Lat: 6, Lon: 10},
+			{Lat: 10, Lon: 10},
+		},
+	}
+	pp.AddFeature(poly)
+
+	ids := poly.Pixels(pix)
+	if got := pp.Pixels(59_999); !reflect.DeepEqual(got, ids) {
+		t.Errorf("polygon feature: got %v, want %v", got, ids)
+	}
+	for _, id := range ids {
+		px := pp.Pixel(59_999, id)
+		if px.Name != "square" || px.Begin != 140_000_000 || px.End != 20_000_000 {
+			t.Errorf("polygon feature: pixel %d: got %v", id, px)
+		}
+	}
+}
+
+func TestPixAgeAliveAt(t *testing.T) {
+	px := model.PixAge{
+		ID:    17051,
+		Plate: 59_999,
+		Begin: 140_000_000,
+		End:   20_000_000,
+	}
+
+	tests := map[int64]bool{
+		0:           false,
+		19_999_999:  false,
+		20_000_000:  true,
+		100_000_000: true,
+		140_000_000: true,
+		140_000_001: false,
+	}
+	for age, want := range tests {
+		if got := px.AliveAt(age); got != want {
+			t.Errorf("age %d: got %v, want %v", age, got, want)
+		}
+	}
+}
+
+func TestPixPlateAliveAtRotationGap(t *testing.T) {
+	pp := model.NewPixPlate(earth.NewPixelation(360))
+	pp.Add(801, "Australia", -25, 135, 150_000_000, 0)
+	pp.Add(801, "Australia", -20, 120, 50_000_000, 0)
+
+	// the rotation of the plate
+	// is only defined up to 100 Ma
+	rot, err := rotation.Read(strings.NewReader("801 0.0 90.0 0.0 0.0 0\n801 100.0 13.0 35.0 -50.0 0\n"))
+	if err != nil {
+		t.Fatalf("when reading rotation: %v", err)
+	}
+
+	tests := map[int64]struct {
+		alive int
+		rot   bool
+	}{
+		0:           {2, true},
+		80_000_000:  {1, true},
+		120_000_000: {1, false},
+		200_000_000: {0, false},
+	}
+	for age, test := range tests {
+		alive := pp.AliveAt(801, age)
+		if len(alive) != test.alive {
+			t.Errorf("age %d: got %d alive pixels, want %d", age, len(alive), test.alive)
+		}
+		if _, ok := rot.Rotation(801, age); ok != test.rot {
+			t.Errorf("age %d: rotation %v, want %v", age, ok, test.rot)
+		}
+	}
+
+	if alive := pp.AliveAt(802, 0); alive != nil {
+		t.Errorf("undefined plate: got %v, want nil", alive)
+	}
+}
+
+func TestPixPlateNames(t *testing.T) {
+	pp := model.NewPixPlate(earth.NewPixelation(360))
+	pp.AddPixels(202, "Parana", []int{29611}, 600_000_000, 0)
+	pp.AddPixels(202, "Chaco", []int{29611, 29612}, 500_000_000, 0)
+	pp.AddPixels(202, "Andes", []int{29611}, 100_000_000, 0)
+	pp.AddPixels(202, "", []int{29611}, 50_000_000, 0)
+
+	testNames := func(t *testing.T, pp *model.PixPlate) {
+		t.Helper()
+		tests := map[int][]string{
+			29611: {"Parana", "Andes", "Chaco"},
+			29612: {"Chaco"},
+			29613: nil,
+		}
+		for id, want := range tests {
+			if got := pp.Names(202, id); !reflect.DeepEqual(got, want) {
+				t.Errorf("pixel %d: got %v, want %v", id, got, want)
+			}
+		}
+		if px := pp.Pixel(202, 29611); px.Name != "Parana" {
+			t.Errorf("pixel %d: name: got %q, want %q", 29611, px.Name, "Parana")
+		}
+	}
+	testNames(t, pp)
+
+	var buf bytes.Buffer
+	if err := pp.TSV(&buf); err != nil {
+		t.Fatalf("while writing data: %v", err)
+	}
+	np, err := model.ReadPixPlate(strings.NewReader(buf.String()), nil)
+	if err != nil {
+		t.Fatalf("while reading data: %v", err)
+	}
+	testNames(t, np)
+
+	// files without extra names
+	// do not include the names column
+	buf.Reset()
+	if err := makePixPlate(t).TSV(&buf); err != nil {
+		t.Fatalf("while writing data: %v", err)
+	}
+	if strings.Contains(buf.String(), "names") {
+		t.Errorf("unexpected names column in output")
+	}
+}
+
+func TestPixPlatePlateOf(t *testing.T) {
+	pp := model.NewPixPlate(earth.NewPixelation(360))
+	pp.AddPixels(202, "Parana", []int{29611}, 600_000_000, 0)
+	pp.AddPixels(291, "Andes", []int{29611}, 100_000_000, 0)
+	pp.AddPixels(101, "terrane", []int{29611}, 300_000_000, 200_000_000)
+	pp.AddPixels(150, "terrane", []int{29611}, 300_000_000, 200_000_000)
+	pp.AddPixels(59_999, "island", []int{29612}, 50_000_000, 10_000_000)
+
+	tests := map[string]struct {
+		pixel int
+		age   int64
+		plate int
+		ok    bool
+	}{
+		"oldest begin":       {29611, 50_000_000, 202, true},
+		"single plate":       {29612, 20_000_000, 59_999, true},
+		"no plate at age":    {29612, 60_000_000, 0, false},
+		"undefined pixel":    {29613, 0, 0, false},
+		"older than all":     {29611, 700_000_000, 0, false},
+		"older than overlap": {29611, 250_000_000, 202, true},
+	}
+	for name, test := range tests {
+		plate, ok := pp.PlateOf(test.pixel, test.age)
+		if ok != test.ok || plate != test.plate {
+			t.Errorf("%s: got %d %v, want %d %v", name, plate, ok, test.plate, test.ok)
+		}
+	}
+
+	// same begin age
+	tp := model.NewPixPlate(earth.NewPixelation(360))
+	tp.AddPixels(150, "terrane", []int{29611}, 300_000_000, 200_000_000)
+	tp.AddPixels(101, "terrane", []int{29611}, 300_000_000, 200_000_000)
+	tp.AddPixels(291, "Andes", []int{29611}, 100_000_000, 0)
+	if plate, ok := tp.PlateOf(29611, 250_000_000); !ok || plate != 101 {
+		t.Errorf("same begin: got %d %v, want %d %v", plate, ok, 101, true)
+	}
+}
+
+func TestPixPlatePlateIndex(t *testing.T) {
+	pp := model.NewPixPlate(earth.NewPixelation(360))
+	pp.AddPixels(202, "Parana", []int{29611}, 600_000_000, 0)
+	pp.AddPixels(291, "Andes", []int{29611}, 100_000_000, 0)
+	pp.AddPixels(150, "terrane", []int{29611, 29613}, 300_000_000, 200_000_000)
+	pp.AddPixels(101, "terrane", []int{29611, 29613}, 300_000_000, 200_000_000)
+	pp.AddPixels(59_999, "island", []int{29612}, 50_000_000, 10_000_000)
+
+	for _, age := range []int64{0, 20_000_000, 60_000_000, 250_000_000, 700_000_000} {
+		want := make(map[int]int)
+		for _, id := range []int{29611, 29612, 29613, 29614} {
+			if plate, ok := pp.PlateOf(id, age); ok {
+				want[id] = plate
+			}
+		}
+		got := pp.PlateIndex(age)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("age %d: got %v, want %v", age, got, want)
+		}
+	}
+}
@@ -114,7 +114,10 @@ func ReadTotal(r io.Reader, pix *earth.Pixelation, inverse bool) (*Total, error)
 			return nil, fmt.Errorf("on row %d: field %q: %v", ln, f, err)
 		}
 		if pix == nil {
-			pix = earth.NewPixelation(eq)
+			pix, err = earth.NewPixelationErr(eq)
+			if err != nil {
+				return nil, fmt.Errorf("on row %d: field %q: %v", ln, f, err)
+			}
 		}
 		if pix.Equator() != eq {
 			return nil, fmt.Errorf("on row %d: field %q: got %d, want %d value", ln, f, eq, pix.Equator())
@@ -152,7 +155,7 @@ func ReadTotal(r io.Reader, pix *earth.Pixelation, inverse bool) (*Total, error)
 		if err != nil {
 			return nil, fmt.Errorf("on row %d: field %q: %v", ln, f, err)
 		}
-		if id >= pix.Len() {
+		if !pix.Valid(id) {
 			return nil, fmt.Errorf("on row %d: field %q: invalid pixel value %d", ln, f, id)
 		}
 		f = "stage-pixel"
@@ -160,7 +163,7 @@ func ReadTotal(r io.Reader, pix *earth.Pixelation, inverse bool) (*Total, error)
 		if err != nil {
 			return nil, fmt.Errorf("on row %d: field %q: %v", ln, f, err)
 		}
-		if sID >= pix.Len() {
+		if !pix.Valid(sID) {
 			return nil, fmt.Errorf("on row %d: field %q: invalid pixel value %d", ln, f, sID)
 		}
 		if inverse {
@@ -195,6 +198,70 @@ func (t *Total) ClosestStageAge(age int64) int64 {
 	return age
 }
 
+// NearestStageAge returns the age of the time stage
+// closest in absolute years to a given age
+// (either younger or older).
+// Ties are resolved in favor of the younger stage.
+func (t *Total) NearestStageAge(age int64) int64 {
+	return nearestStageAge(t.Stages(), age)
+}
+
+// StageDistance returns the difference
+// (in years)
+// between an age
+// and the age of the time stage
+// returned by ClosestStageAge.
+// If the age is younger than all time stages
+// (for which ClosestStageAge is undefined),
+// it returns the difference with the youngest stage.
+// If there are no time stages,
+// it returns -1.
+func (t *Total) StageDistance(age int64) int64 {
+	return stageDistance(t.Stages(), age)
+}
+
+// EnsurePresent adds a present time stage
+// (i.e. age 0)
+// to a total rotation,
+// in which each pixel is at its own location,
+// if the total rotation does not have a present time stage.
+// If the stage is already defined,
+// the total rotation is not changed.
+func (t *Total) EnsurePresent() {
+	if _, ok := t.stages[0]; ok {
+		return
+	}
+
+	// pixels at present time
+	present := make(map[int]bool)
+	for _, rot := range t.stages {
+		for px, dest := range rot.Rot {
+			if !t.inverse {
+				present[px] = true
+				continue
+			}
+			for _, id := range dest {
+				present[id] = true
+			}
+		}
+	}
+
+	rot := &Rotation{
+		Rot: make(map[int][]int, len(present)),
+	}
+	for px := range present {
+		rot.Rot[px] = []int{px}
+	}
+	t.stages[0] = rot
+}
+
+// FirstStage returns the age of the youngest time stage
+// of a total rotation.
+// It returns false if there are no stages defined.
+func (t *Total) FirstStage() (int64, bool) {
+	return firstStage(t.Stages())
+}
+
 // Inverse returns an inverse total rotation,
 // a collection of pixels in past time
 // moved to current time.
@@ -238,6 +305,13 @@ func (t *Total) IsInverse() bool {
 	return t.inverse
 }
 
+// LastStage returns the age of the oldest time stage
+// of a total rotation.
+// It returns false if there are no stages defined.
+func (t *Total) LastStage() (int64, bool) {
+	return lastStage(t.Stages())
+}
+
 // Pixelation returns the underlying pixelation
 // of a total rotation model.
 func (t *Total) Pixelation() *earth.Pixelation {
@@ -263,6 +337,52 @@ func (t *Total) Rotation(age int64) map[int][]int {
 	return rot.Rot
 }
 
+// RotationRange returns the pixel locations
+// of all time stages in a time range
+// (in years),
+// from an older age to a younger age.
+// Locations is a map in which the key is the pixel ID at present time,
+// and the value is a sorted slice of the pixel IDs
+// of all the locations of the key pixel
+// in any of the time stages of the range,
+// without duplicates.
+//
+// The results include every time stage in [to, from].
+// As in Rotation,
+// if to is not a defined time stage,
+// the oldest time stage that is youngest than to
+// will be used,
+// and the range is clamped to the defined time stages.
+func (t *Total) RotationRange(from, to int64) map[int][]int {
+	if from < to {
+		from, to = to, from
+	}
+
+	rot := &Rotation{
+		Rot: make(map[int][]int),
+	}
+	st := t.Stages()
+	if len(st) == 0 {
+		return rot.Rot
+	}
+	if to < st[0] {
+		to = st[0]
+	}
+	to = t.ClosestStageAge(to)
+
+	for _, a := range st {
+		if a < to || a > from {
+			continue
+		}
+		for px, dest := range t.stages[a].Rot {
+			rot.Rot[px] = append(rot.Rot[px], dest...)
+		}
+	}
+	rot.removeDuplicates()
+
+	return rot.Rot
+}
+
 // Stages return the time stages defined
 // for the total rotation model.
 func (t *Total) Stages() []int64 {
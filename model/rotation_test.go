@@ -136,3 +136,117 @@ func testInverse(t testing.TB, tot *model.Total) {
 		t.Errorf("pixels at stage 100: got %v, want %v", l, pix140)
 	}
 }
+
+func TestTotalRotationRange(t *testing.T) {
+	tot := model.NewTotal(makeRecons(t))
+
+	pix100 := map[int][]int{
+		17051: {19051},
+		17055: {19055},
+		17409: {19409},
+		17766: {19766},
+		18122: {20122},
+		18479: {20479, 20480},
+	}
+	both := map[int][]int{
+		17051: {19051, 20051},
+		17055: {19055, 20055, 20056},
+		17409: {19409, 20409},
+		17766: {19766, 20766},
+		18122: {20122, 21122},
+		18479: {20479, 20480, 21479},
+	}
+
+	tests := map[string]struct {
+		from, to int64
+		want     map[int][]int
+	}{
+		"all stages":    {140_000_000, 100_000_000, both},
+		"inexact range": {150_000_000, 110_000_000, both},
+		"swapped range": {100_000_000, 140_000_000, both},
+		"first stage":   {120_000_000, 0, pix100},
+		"before stages": {90_000_000, 0, map[int][]int{}},
+	}
+	for name, test := range tests {
+		if got := tot.RotationRange(test.from, test.to); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v, want %v", name, got, test.want)
+		}
+	}
+}
+
+func TestTotalStageDistance(t *testing.T) {
+	tot := model.NewTotal(makeRecons(t))
+
+	tests := map[string]struct {
+		age  int64
+		want int64
+	}{
+		"exact":       {140_000_000, 0},
+		"equidistant": {120_000_000, 20_000_000},
+		"oldest":      {150_000_000, 10_000_000},
+		"youngest":    {90_000_000, 10_000_000},
+	}
+	for name, test := range tests {
+		if d := tot.StageDistance(test.age); d != test.want {
+			t.Errorf("%s: got %d, want %d", name, d, test.want)
+		}
+	}
+}
+
+func TestTotalNearestStageAge(t *testing.T) {
+	tot := model.NewTotal(makeRecons(t))
+
+	tests := map[string]struct {
+		age  int64
+		want int64
+	}{
+		"exact":       {140_000_000, 140_000_000},
+		"equidistant": {120_000_000, 100_000_000},
+		"older":       {125_000_000, 140_000_000},
+		"younger":     {115_000_000, 100_000_000},
+		"oldest":      {150_000_000, 140_000_000},
+		"youngest":    {90_000_000, 100_000_000},
+	}
+	for name, test := range tests {
+		if a := tot.NearestStageAge(test.age); a != test.want {
+			t.Errorf("%s: got %d, want %d", name, a, test.want)
+		}
+	}
+}
+
+func TestTotalEnsurePresent(t *testing.T) {
+	identity := map[int][]int{
+		17051: {17051},
+		17055: {17055},
+		17409: {17409},
+		17766: {17766},
+		18122: {18122},
+		18479: {18479},
+	}
+
+	tot := model.NewTotal(makeRecons(t))
+	tot.EnsurePresent()
+	tot.EnsurePresent()
+	if got, want := tot.Stages(), []int64{0, 100_000_000, 140_000_000}; !reflect.DeepEqual(got, want) {
+		t.Errorf("stages: got %v, want %v", got, want)
+	}
+	if got := tot.Rotation(0); !reflect.DeepEqual(got, identity) {
+		t.Errorf("present stage: got %v, want %v", got, identity)
+	}
+
+	inv := model.NewTotal(makeRecons(t)).Inverse()
+	inv.EnsurePresent()
+	if got := inv.Rotation(0); !reflect.DeepEqual(got, identity) {
+		t.Errorf("inverse: present stage: got %v, want %v", got, identity)
+	}
+
+	// an already defined present stage
+	rec := makeRecons(t)
+	rec.Add(59_999, map[int][]int{17051: {17052}}, 0)
+	tot = model.NewTotal(rec)
+	tot.EnsurePresent()
+	want := map[int][]int{17051: {17052}}
+	if got := tot.Rotation(0); !reflect.DeepEqual(got, want) {
+		t.Errorf("defined present stage: got %v, want %v", got, want)
+	}
+}
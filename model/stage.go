@@ -5,8 +5,15 @@
 package model
 
 import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
 	"io"
 	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/js-arias/earth"
 )
@@ -147,6 +154,173 @@ func ReadStageRot(r io.Reader, pix *earth.Pixelation) (*StageRot, error) {
 	return NewStageRot(rec), nil
 }
 
+// Valid values for the direction field
+// of a stage rotation TSV file.
+const (
+	youngToOldDir = "young-to-old"
+	oldToYoungDir = "old-to-young"
+)
+
+var stageHeader = []string{
+	"equator",
+	"direction",
+	"from",
+	"to",
+	"pixel",
+	"stage-pixel",
+}
+
+// ReadStageRotTSV reads a collection of stage rotations
+// from a TSV file
+// as the one produced by the TSV method.
+//
+// The TSV file must contains the following columns:
+//
+//   - equator, for the number of pixels at the equator
+//   - direction, either "young-to-old" or "old-to-young"
+//   - from, the age of the source time stage (in years)
+//   - to, the age of the destination time stage (in years)
+//   - pixel, the pixel ID at the source time stage
+//   - stage-pixel, the pixel ID at the destination time stage
+//
+// Here is an example file:
+//
+//	equator	direction	from	to	pixel	stage-pixel
+//	360	young-to-old	100000000	140000000	19055	20055
+//	360	young-to-old	100000000	140000000	19055	20056
+//	360	old-to-young	140000000	100000000	20055	19055
+//	360	old-to-young	140000000	100000000	20056	19055
+//
+// If no pixelation is given,
+// a new pixelation will be created.
+func ReadStageRotTSV(r io.Reader, pix *earth.Pixelation) (*StageRot, error) {
+	tab := csv.NewReader(r)
+	tab.Comma = '\t'
+	tab.Comment = '#'
+
+	head, err := tab.Read()
+	if err != nil {
+		return nil, fmt.Errorf("while reading header: %v", err)
+	}
+	fields := make(map[string]int, len(head))
+	for i, h := range head {
+		h = strings.ToLower(h)
+		fields[h] = i
+	}
+	for _, h := range stageHeader {
+		if _, ok := fields[h]; !ok {
+			return nil, fmt.Errorf("expecting field %q", h)
+		}
+	}
+
+	var s *StageRot
+	for {
+		row, err := tab.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		ln, _ := tab.FieldPos(0)
+		if err != nil {
+			return nil, fmt.Errorf("on row %d: %v", ln, err)
+		}
+
+		f := "equator"
+		eq, err := strconv.Atoi(row[fields[f]])
+		if err != nil {
+			return nil, fmt.Errorf("on row %d: field %q: %v", ln, f, err)
+		}
+		if pix == nil {
+			pix, err = earth.NewPixelationErr(eq)
+			if err != nil {
+				return nil, fmt.Errorf("on row %d: field %q: %v", ln, f, err)
+			}
+		}
+		if pix.Equator() != eq {
+			return nil, fmt.Errorf("on row %d: field %q: got %d, want %d value", ln, f, eq, pix.Equator())
+		}
+		if s == nil {
+			s = &StageRot{
+				pix:        pix,
+				youngToOld: make(map[int64]*Rotation),
+				oldToYoung: make(map[int64]*Rotation),
+			}
+		}
+
+		f = "from"
+		from, err := strconv.ParseInt(row[fields[f]], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("on row %d: field %q: %v", ln, f, err)
+		}
+		f = "to"
+		to, err := strconv.ParseInt(row[fields[f]], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("on row %d: field %q: %v", ln, f, err)
+		}
+
+		f = "direction"
+		var rots map[int64]*Rotation
+		switch d := strings.ToLower(row[fields[f]]); d {
+		case youngToOldDir:
+			if from >= to {
+				return nil, fmt.Errorf("on row %d: field %q: %q rotation from %d to %d", ln, f, d, from, to)
+			}
+			rots = s.youngToOld
+		case oldToYoungDir:
+			if from <= to {
+				return nil, fmt.Errorf("on row %d: field %q: %q rotation from %d to %d", ln, f, d, from, to)
+			}
+			rots = s.oldToYoung
+		default:
+			return nil, fmt.Errorf("on row %d: field %q: unknown direction %q", ln, f, d)
+		}
+
+		rot, ok := rots[from]
+		if !ok {
+			rot = &Rotation{
+				From: from,
+				To:   to,
+				Rot:  make(map[int][]int),
+			}
+			rots[from] = rot
+		}
+		if rot.To != to {
+			return nil, fmt.Errorf("on row %d: field %q: got %d, want %d", ln, "to", to, rot.To)
+		}
+
+		f = "pixel"
+		id, err := strconv.Atoi(row[fields[f]])
+		if err != nil {
+			return nil, fmt.Errorf("on row %d: field %q: %v", ln, f, err)
+		}
+		if !pix.Valid(id) {
+			return nil, fmt.Errorf("on row %d: field %q: invalid pixel value %d", ln, f, id)
+		}
+		f = "stage-pixel"
+		sID, err := strconv.Atoi(row[fields[f]])
+		if err != nil {
+			return nil, fmt.Errorf("on row %d: field %q: %v", ln, f, err)
+		}
+		if !pix.Valid(sID) {
+			return nil, fmt.Errorf("on row %d: field %q: invalid pixel value %d", ln, f, sID)
+		}
+		rot.Rot[id] = append(rot.Rot[id], sID)
+	}
+	if s == nil {
+		return nil, fmt.Errorf("while reading data: %v", io.EOF)
+	}
+
+	// Remove duplicated pixels
+	// if any
+	for _, rot := range s.youngToOld {
+		rot.removeDuplicates()
+	}
+	for _, rot := range s.oldToYoung {
+		rot.removeDuplicates()
+	}
+
+	return s, nil
+}
+
 // ClosestStageAge returns the closest stage age
 // for a given time
 // i.e. the age of the first time stage younger than the given age.
@@ -158,6 +332,14 @@ func (s *StageRot) ClosestStageAge(age int64) int64 {
 	return age
 }
 
+// NearestStageAge returns the age of the time stage
+// closest in absolute years to a given age
+// (either younger or older).
+// Ties are resolved in favor of the younger stage.
+func (s *StageRot) NearestStageAge(age int64) int64 {
+	return nearestStageAge(s.Stages(), age)
+}
+
 // OldToYoung returns an stage rotation from an older stage
 // to it most immediate younger stage.
 // If there is no younger stage,
@@ -189,6 +371,79 @@ func (s *StageRot) Stages() []int64 {
 	return st
 }
 
+// TSV encodes a collection of stage rotations
+// as a TSV file.
+// The file can be read with ReadStageRotTSV.
+// Additional metadata can be given
+// and it will be written as comments
+// in the header of the file.
+func (s *StageRot) TSV(w io.Writer, meta ...Metadata) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "# stage rotations\n")
+	fmt.Fprintf(bw, "# data save on: %s\n", time.Now().Format(time.RFC3339))
+	for _, m := range meta {
+		m.write(bw)
+	}
+	tab := csv.NewWriter(bw)
+	tab.Comma = '\t'
+	tab.UseCRLF = true
+
+	if err := tab.Write(stageHeader); err != nil {
+		return fmt.Errorf("while writing header: %v", err)
+	}
+
+	eq := strconv.Itoa(s.pix.Equator())
+	for _, a := range s.Stages() {
+		if err := writeStageRot(tab, eq, youngToOldDir, s.youngToOld[a]); err != nil {
+			return err
+		}
+		if err := writeStageRot(tab, eq, oldToYoungDir, s.oldToYoung[a]); err != nil {
+			return err
+		}
+	}
+
+	tab.Flush()
+	if err := tab.Error(); err != nil {
+		return fmt.Errorf("while writing data: %v", err)
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("while writing data: %v", err)
+	}
+	return nil
+}
+
+func writeStageRot(tab *csv.Writer, eq, dir string, rot *Rotation) error {
+	if rot == nil {
+		return nil
+	}
+
+	from := strconv.FormatInt(rot.From, 10)
+	to := strconv.FormatInt(rot.To, 10)
+
+	pxs := make([]int, 0, len(rot.Rot))
+	for id := range rot.Rot {
+		pxs = append(pxs, id)
+	}
+	slices.Sort(pxs)
+
+	for _, id := range pxs {
+		for _, sID := range rot.Rot[id] {
+			row := []string{
+				eq,
+				dir,
+				from,
+				to,
+				strconv.Itoa(id),
+				strconv.Itoa(sID),
+			}
+			if err := tab.Write(row); err != nil {
+				return fmt.Errorf("while writing data: %v", err)
+			}
+		}
+	}
+	return nil
+}
+
 // YoungToOld returns an stage rotation from a younger stage
 // to it most immediate older stage.
 // If there is no older stage,
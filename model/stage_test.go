@@ -28,6 +28,22 @@ func TestReadStageRot(t *testing.T) {
 	testStageRot(t, stg)
 }
 
+func TestStageRotTSV(t *testing.T) {
+	data := makeRecons(t)
+	stg := model.NewStageRot(data)
+
+	var buf bytes.Buffer
+	if err := stg.TSV(&buf); err != nil {
+		t.Fatalf("while writing data: %v", err)
+	}
+
+	np, err := model.ReadStageRotTSV(strings.NewReader(buf.String()), nil)
+	if err != nil {
+		t.Fatalf("while reading data: %v", err)
+	}
+	testStageRot(t, np)
+}
+
 func testStageRot(t testing.TB, stg *model.StageRot) {
 	t.Helper()
 
@@ -77,4 +93,10 @@ func testStageRot(t testing.TB, stg *model.StageRot) {
 	if c := stg.ClosestStageAge(125_000_000); c != 100_000_000 {
 		t.Errorf("closest stage age: got %d, want %d", c, 100_000_000)
 	}
+	if c := stg.NearestStageAge(125_000_000); c != 140_000_000 {
+		t.Errorf("nearest stage age: got %d, want %d", c, 140_000_000)
+	}
+	if c := stg.NearestStageAge(120_000_000); c != 100_000_000 {
+		t.Errorf("nearest stage age: equidistant: got %d, want %d", c, 100_000_000)
+	}
 }
@@ -0,0 +1,288 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package model
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/js-arias/earth"
+)
+
+// A TimeFloat is a pixelated set of continuous values
+// (for example,
+// the paleo-temperature or the elevation)
+// at different time stages.
+//
+// It is equivalent to a TimePix,
+// but the values are stored as floats.
+type TimeFloat struct {
+	pix *earth.Pixelation
+
+	// Pixel values at different time stages
+	stages map[int64]map[int]float64
+}
+
+// NewTimeFloat returns a new time pixelation
+// of float values
+// based on an equal area pixelation.
+func NewTimeFloat(pix *earth.Pixelation) *TimeFloat {
+	return &TimeFloat{
+		pix:    pix,
+		stages: make(map[int64]map[int]float64),
+	}
+}
+
+// At returns the value for a pixel at a time
+// in a time pixelation.
+// If the pixel was never defined,
+// it will return the default value
+// (i.e. 0).
+//
+// If the time stage is not defined for the time pixelation
+// if will return 0 and false.
+// If a pixel value in the closer time stage is wanted,
+// use AtClosest.
+func (tf *TimeFloat) At(age int64, pixel int) (float64, bool) {
+	st, ok := tf.stages[age]
+	if !ok {
+		return 0, false
+	}
+
+	return st[pixel], true
+}
+
+// AtClosest returns the value for a pixel at the closest time stage
+// (i.e. the age of the oldest stage
+// younger than the indicated age).
+// If the pixel was never defined,
+// it will return the default value
+// (i.e. 0).
+func (tf *TimeFloat) AtClosest(age int64, pixel int) float64 {
+	age = tf.ClosestStageAge(age)
+	v, _ := tf.At(age, pixel)
+	return v
+}
+
+// Bounds return the age bounds for the stage of the given age
+// in million years.
+func (tf *TimeFloat) Bounds(age int64) (old, young int64) {
+	return stageBounds(tf.Stages(), age)
+}
+
+// ClosestStageAge returns the closest stage age
+// for a time
+// (i.e. the age of the oldest stage
+// younger than the indicated age).
+func (tf *TimeFloat) ClosestStageAge(age int64) int64 {
+	return closestStageAge(tf.Stages(), age)
+}
+
+// Del removes a pixel value at a time
+// in a time pixelation.
+func (tf *TimeFloat) Del(age int64, pixel int) {
+	st, ok := tf.stages[age]
+	if !ok {
+		return
+	}
+	delete(st, pixel)
+}
+
+// FirstStage returns the age of the youngest time stage
+// of a time pixelation.
+// It returns false if there are no stages defined.
+func (tf *TimeFloat) FirstStage() (int64, bool) {
+	return firstStage(tf.Stages())
+}
+
+// LastStage returns the age of the oldest time stage
+// of a time pixelation.
+// It returns false if there are no stages defined.
+func (tf *TimeFloat) LastStage() (int64, bool) {
+	return lastStage(tf.Stages())
+}
+
+// Pixelation returns the underlying equal area pixelation.
+func (tf *TimeFloat) Pixelation() *earth.Pixelation {
+	return tf.pix
+}
+
+// Set sets a value for a pixel at a time
+// in a time pixelation.
+func (tf *TimeFloat) Set(age int64, pixel int, value float64) {
+	tf.pix.MustID(pixel)
+
+	st := tf.stages[age]
+	if st == nil {
+		st = make(map[int]float64)
+		tf.stages[age] = st
+	}
+	st[pixel] = value
+}
+
+// Stage returns the values for all pixels
+// at a given age
+// (in years).
+func (tf *TimeFloat) Stage(age int64) map[int]float64 {
+	return tf.stages[age]
+}
+
+// Stages returns the time stages defined
+// for a time pixelation.
+func (tf *TimeFloat) Stages() []int64 {
+	return stageAges(tf.stages)
+}
+
+// ReadTimeFloat reads values of a time pixelation
+// of float values
+// from a TSV file.
+//
+// The TSV must contain the following columns:
+//
+//   - equator, for the number of pixels at the equator
+//   - age, the age of the time stage (in years)
+//   - stage-pixel, the pixel ID at the time stage
+//   - value, a float value
+//
+// Here is an example file:
+//
+//	equator	age	stage-pixel	value
+//	360	100000000	19051	12.5
+//	360	100000000	19055	-3.25
+//	360	140000000	20051	0.001
+//
+// If no pixelation is given,
+// a new pixelation will be created.
+func ReadTimeFloat(r io.Reader, pix *earth.Pixelation) (*TimeFloat, error) {
+	tab := csv.NewReader(r)
+	tab.Comma = '\t'
+	tab.Comment = '#'
+
+	head, err := tab.Read()
+	if err != nil {
+		return nil, fmt.Errorf("while reading header: %v", err)
+	}
+	fields := make(map[string]int, len(head))
+	for i, h := range head {
+		h = strings.ToLower(h)
+		fields[h] = i
+	}
+	for _, h := range tpHeader {
+		if _, ok := fields[h]; !ok {
+			return nil, fmt.Errorf("expecting field %q", h)
+		}
+	}
+
+	var tf *TimeFloat
+	for {
+		row, err := tab.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		ln, _ := tab.FieldPos(0)
+		if err != nil {
+			return nil, fmt.Errorf("on row %d: %v", ln, err)
+		}
+
+		f := "equator"
+		eq, err := strconv.Atoi(row[fields[f]])
+		if err != nil {
+			return nil, fmt.Errorf("on row %d: field %q: %v", ln, f, err)
+		}
+		if pix == nil {
+			pix, err = earth.NewPixelationErr(eq)
+			if err != nil {
+				return nil, fmt.Errorf("on row %d: field %q: %v", ln, f, err)
+			}
+		}
+		if pix.Equator() != eq {
+			return nil, fmt.Errorf("on row %d: field %q: got %d, want %d value", ln, f, eq, pix.Equator())
+		}
+		if tf == nil {
+			tf = NewTimeFloat(pix)
+		}
+
+		f = "age"
+		age, err := strconv.ParseInt(row[fields[f]], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("on row %d: field %q: %v", ln, f, err)
+		}
+
+		f = "stage-pixel"
+		px, err := strconv.Atoi(row[fields[f]])
+		if err != nil {
+			return nil, fmt.Errorf("on row %d: field %q: %v", ln, f, err)
+		}
+		if !pix.Valid(px) {
+			return nil, fmt.Errorf("on row %d: field %q: invalid pixel value %d", ln, f, px)
+		}
+
+		f = "value"
+		v, err := strconv.ParseFloat(row[fields[f]], 64)
+		if err != nil {
+			return nil, fmt.Errorf("on row %d: field %q: %v", ln, f, err)
+		}
+		tf.Set(age, px, v)
+	}
+
+	if tf == nil {
+		return nil, fmt.Errorf("while reading data: %v", io.EOF)
+	}
+	return tf, nil
+}
+
+// TSV encodes a time pixelation of float values
+// as a TSV file.
+// Additional metadata can be given
+// and it will be written as comments
+// in the header of the file.
+func (tf *TimeFloat) TSV(w io.Writer, meta ...Metadata) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "# time pixelation values\n")
+	fmt.Fprintf(bw, "# data save on: %s\n", time.Now().Format(time.RFC3339))
+	for _, m := range meta {
+		m.write(bw)
+	}
+	tab := csv.NewWriter(bw)
+	tab.Comma = '\t'
+	tab.UseCRLF = true
+
+	if err := tab.Write(tpHeader); err != nil {
+		return fmt.Errorf("while writing header: %v", err)
+	}
+
+	eq := strconv.Itoa(tf.pix.Equator())
+
+	for _, a := range tf.Stages() {
+		age := strconv.FormatInt(a, 10)
+		st := tf.stages[a]
+		for _, id := range stagePixels(st) {
+			row := []string{
+				eq,
+				age,
+				strconv.Itoa(id),
+				strconv.FormatFloat(st[id], 'f', -1, 64),
+			}
+			if err := tab.Write(row); err != nil {
+				return fmt.Errorf("while writing data: %v", err)
+			}
+		}
+	}
+
+	tab.Flush()
+	if err := tab.Error(); err != nil {
+		return fmt.Errorf("while writing data: %v", err)
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("while writing data: %v", err)
+	}
+	return nil
+}
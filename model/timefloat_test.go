@@ -0,0 +1,73 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package model_test
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/js-arias/earth"
+	"github.com/js-arias/earth/model"
+)
+
+func TestTimeFloat(t *testing.T) {
+	tf := model.NewTimeFloat(earth.NewPixelation(360))
+	tf.Set(100_000_000, 19051, 12.5)
+	tf.Set(100_000_000, 19055, -3.25)
+	tf.Set(140_000_000, 20051, 0.001)
+	tf.Set(140_000_000, 20055, 1e-9)
+
+	testTimeFloat(t, tf)
+
+	var buf bytes.Buffer
+	if err := tf.TSV(&buf); err != nil {
+		t.Fatalf("while writing data: %v", err)
+	}
+
+	nf, err := model.ReadTimeFloat(strings.NewReader(buf.String()), nil)
+	if err != nil {
+		t.Fatalf("while reading data: %v", err)
+	}
+	testTimeFloat(t, nf)
+}
+
+func testTimeFloat(t testing.TB, tf *model.TimeFloat) {
+	t.Helper()
+
+	stages := []int64{100_000_000, 140_000_000}
+	if st := tf.Stages(); !reflect.DeepEqual(st, stages) {
+		t.Errorf("stages: got %v, want %v", st, stages)
+	}
+	if o, y := tf.Bounds(120_000_000); o != 140_000_000 || y != 100_000_000 {
+		t.Errorf("bounds: got %d-%d, want %d-%d", o, y, 140_000_000, 100_000_000)
+	}
+	if a := tf.ClosestStageAge(120_000_000); a != 100_000_000 {
+		t.Errorf("closest stage: got %d, want %d", a, 100_000_000)
+	}
+
+	vals100 := map[int]float64{
+		19051: 12.5,
+		19055: -3.25,
+	}
+	if st := tf.Stage(100_000_000); !reflect.DeepEqual(st, vals100) {
+		t.Errorf("stage %d: got %v, want %v", 100_000_000, st, vals100)
+	}
+
+	vals140 := map[int]float64{
+		20051: 0.001,
+		20055: 1e-9,
+	}
+	for px, want := range vals140 {
+		if v := tf.AtClosest(150_000_000, px); v != want {
+			t.Errorf("pixel %d: got %v, want %v", px, v, want)
+		}
+	}
+
+	if _, ok := tf.At(50_000_000, 19051); ok {
+		t.Errorf("stage %d: should be undefined", 50_000_000)
+	}
+}
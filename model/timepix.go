@@ -6,10 +6,13 @@ package model
 
 import (
 	"bufio"
+	"cmp"
 	"encoding/csv"
 	"errors"
 	"fmt"
 	"io"
+	"maps"
+	"math"
 	"slices"
 	"strconv"
 	"strings"
@@ -41,6 +44,32 @@ func NewTimePix(pix *earth.Pixelation) *TimePix {
 	}
 }
 
+// Apply sets the value of each defined pixel
+// at the given time stages
+// (in years)
+// to the value returned by fn.
+// If fn returns 0,
+// the pixel will be deleted.
+// Pixels without a defined value
+// and undefined time stages
+// are not visited.
+func (tp *TimePix) Apply(ages []int64, fn func(age int64, pixel, value int) int) {
+	for _, a := range ages {
+		st, ok := tp.stages[a]
+		if !ok {
+			continue
+		}
+		for px, v := range st.values {
+			nv := fn(a, px, v)
+			if nv == 0 {
+				delete(st.values, px)
+				continue
+			}
+			st.values[px] = nv
+		}
+	}
+}
+
 // At returns the value for a pixel at a time
 // in a time pixelation.
 // If the pixel was never defined,
@@ -73,18 +102,29 @@ func (tp *TimePix) AtClosest(age int64, pixel int) int {
 	return v
 }
 
+// AtNearest returns the value for a pixel
+// at the nearest time stage
+// (i.e. the stage closest in absolute years
+// to the indicated age,
+// either younger or older),
+// and the age of that stage.
+// Ties are resolved in favor of the younger stage.
+// If the pixel was never defined,
+// it will return the default value
+// (i.e. 0).
+//
+// To use the oldest stage younger than the age,
+// use AtClosest.
+func (tp *TimePix) AtNearest(age int64, pixel int) (int, int64) {
+	age = tp.NearestStageAge(age)
+	v, _ := tp.At(age, pixel)
+	return v, age
+}
+
 // Bounds return the age bounds for the stage of the given age
 // in million years.
 func (tp *TimePix) Bounds(age int64) (old, young int64) {
-	st := tp.Stages()
-	i, ok := slices.BinarySearch(st, age)
-	if !ok {
-		i = i - 1
-	}
-	if i+1 >= len(st) {
-		return earth.Age, st[i]
-	}
-	return st[i+1], st[i]
+	return stageBounds(tp.Stages(), age)
 }
 
 // ClosestStageAge returns the closest stage age
@@ -92,17 +132,180 @@ func (tp *TimePix) Bounds(age int64) (old, young int64) {
 // (i.e. the age of the oldest stage
 // younger than the indicated age).
 func (tp *TimePix) ClosestStageAge(age int64) int64 {
-	st := tp.Stages()
-	if i, ok := slices.BinarySearch(st, age); !ok {
-		age = st[i-1]
+	return closestStageAge(tp.Stages(), age)
+}
+
+// NearestStageAge returns the age of the stage
+// closest in absolute years to an age
+// (either younger or older).
+// Ties are resolved in favor of the younger stage.
+func (tp *TimePix) NearestStageAge(age int64) int64 {
+	return nearestStageAge(tp.Stages(), age)
+}
+
+// BoundaryLength returns an approximation
+// of the length of the boundary
+// between the pixels with a value in a set
+// (e.g. land)
+// and the pixels with any other value
+// (e.g. ocean)
+// at the time stage of the given age
+// (in years).
+// The length is in radians,
+// and it is the sum of the great circle distances
+// between the centers of each pair of adjacent pixels
+// that are on different sides of the boundary.
+//
+// As the length is measured using pixel adjacencies,
+// it depends on the pixelation resolution,
+// and it overestimates the length of smooth boundaries
+// (as each boundary pixel can have several neighbors
+// on the other side of the boundary,
+// the length of a circle
+// can be more than twice its perimeter).
+// Then,
+// it is only useful to compare boundaries
+// in pixelations of the same resolution.
+//
+// It returns 0 if the stage is not defined.
+func (tp *TimePix) BoundaryLength(age int64, values map[int]bool) float64 {
+	st := tp.Stage(age)
+	if st == nil {
+		return 0
+	}
+
+	var length float64
+	for id := 0; id < tp.pix.Len(); id++ {
+		if !values[st[id]] {
+			continue
+		}
+		pt := tp.pix.ID(id).Point()
+		for _, nb := range tp.pix.Neighbors(id) {
+			if values[st[nb]] {
+				continue
+			}
+			length += earth.Distance(pt, tp.pix.ID(nb).Point())
+		}
+	}
+	return length
+}
+
+// Clone returns a copy of a time pixelation.
+// Changes on the copy do not modify the original
+// time pixelation.
+func (tp *TimePix) Clone() *TimePix {
+	np := NewTimePix(tp.pix)
+	for a, st := range tp.stages {
+		np.stages[a] = &timePix{
+			age:    a,
+			values: maps.Clone(st.values),
+		}
+	}
+	return np
+}
+
+// Compact removes all pixels with the default value
+// (i.e. 0)
+// in all time stages.
+// Time stages without pixels
+// will be removed.
+func (tp *TimePix) Compact() {
+	for a, st := range tp.stages {
+		for px, v := range st.values {
+			if v == 0 {
+				delete(st.values, px)
+			}
+		}
+		if len(st.values) == 0 {
+			delete(tp.stages, a)
+		}
 	}
-	return age
+}
+
+// CopyStage copies all pixel values
+// from the time stage src
+// to the time stage dst
+// (both in years).
+// If dst already exists,
+// its values will be replaced.
+// If src is not defined,
+// it does nothing.
+func (tp *TimePix) CopyStage(src, dst int64) {
+	st, ok := tp.stages[src]
+	if !ok || src == dst {
+		return
+	}
+
+	tp.stages[dst] = &timePix{
+		age:    dst,
+		values: maps.Clone(st.values),
+	}
+}
+
+// Coarsen returns a new time pixelation
+// in which the time stages are grouped
+// in bins of the given size
+// (in years).
+// Each bin includes the time stages
+// from its age
+// (a multiple of the bin size)
+// up to,
+// but not including,
+// the age of the next bin,
+// and the resulting time stage
+// is set at the age of the bin.
+// For example,
+// with a bin size of 10 million years,
+// the stages at 10, 11, and 19.9 million years
+// are merged into a single stage
+// at 10 million years.
+//
+// The values of the time stages of a bin
+// are combined from the youngest to the oldest stage
+// using the indicated rule.
+// For example,
+// with CombineOverwrite,
+// a pixel will have the value of the oldest stage
+// in which it is defined,
+// and with CombineKeep,
+// the value of the youngest stage.
+// Pixels with a value of 0 are ignored.
+//
+// It panics if the bin size is not positive.
+func (tp *TimePix) Coarsen(step int64, rule CombineRule) *TimePix {
+	if step <= 0 {
+		msg := fmt.Sprintf("invalid bin size %d", step)
+		panic(msg)
+	}
+
+	np := NewTimePix(tp.pix)
+	for _, a := range tp.Stages() {
+		bin := a / step * step
+		dst, ok := np.stages[bin]
+		if !ok {
+			dst = &timePix{
+				age:    bin,
+				values: make(map[int]int),
+			}
+			np.stages[bin] = dst
+		}
+
+		for px, v := range tp.stages[a].values {
+			if v == 0 {
+				continue
+			}
+			if nv, ok := rule.Combine(dst.values[px], v); ok {
+				dst.values[px] = nv
+			}
+		}
+	}
+	return np
 }
 
 // Del removes a pixel value at a time
 // in a time pixelation.
 func (tp *TimePix) Del(age int64, pixel int) {
-	if pixel >= tp.pix.Len() {
+	if !tp.pix.Valid(pixel) {
 		return
 	}
 
@@ -113,18 +316,400 @@ func (tp *TimePix) Del(age int64, pixel int) {
 	delete(st.values, pixel)
 }
 
+// A CombineRule is a rule used to combine
+// a new value with the value already stored
+// in a pixel of a time pixelation.
+// In all rules,
+// a pixel with a value of 0
+// is taken as a pixel without value,
+// so it will always receive the new value.
+type CombineRule int
+
+// Valid combine rules.
+const (
+	// CombineMax keeps the largest value.
+	CombineMax CombineRule = iota
+
+	// CombineMin keeps the smallest value.
+	CombineMin
+
+	// CombineOverwrite always uses the new value.
+	CombineOverwrite
+
+	// CombineKeep keeps the old value.
+	CombineKeep
+)
+
+// ParseCombineRule returns a combine rule
+// from its name:
+// "max",
+// "min",
+// "overwrite",
+// or "keep".
+func ParseCombineRule(name string) (CombineRule, error) {
+	switch strings.ToLower(name) {
+	case "max":
+		return CombineMax, nil
+	case "min":
+		return CombineMin, nil
+	case "overwrite":
+		return CombineOverwrite, nil
+	case "keep":
+		return CombineKeep, nil
+	}
+	return 0, fmt.Errorf("unknown combine rule %q", name)
+}
+
+// Combine returns the value
+// that results from combining an old value
+// with a new value.
+// It returns false if the old value
+// should not be modified.
+func (r CombineRule) Combine(old, value int) (int, bool) {
+	if old == 0 {
+		return value, true
+	}
+	switch r {
+	case CombineMin:
+		if value < old {
+			return value, true
+		}
+	case CombineOverwrite:
+		return value, true
+	case CombineKeep:
+	default:
+		if value > old {
+			return value, true
+		}
+	}
+	return old, false
+}
+
+// A PixChange is a change of the value of a pixel
+// at a time stage.
+type PixChange struct {
+	Age   int64
+	Pixel int
+	Old   int
+	New   int
+}
+
+// Diff returns the pixel values
+// that are different between the time pixelation
+// and another time pixelation
+// (the new values).
+// Undefined pixels and pixels in undefined time stages
+// are taken as having the default value
+// (i.e. 0).
+// Changes are sorted by age
+// and then by pixel.
+func (tp *TimePix) Diff(other *TimePix) []PixChange {
+	ages := make(map[int64]bool, len(tp.stages))
+	for a := range tp.stages {
+		ages[a] = true
+	}
+	for a := range other.stages {
+		ages[a] = true
+	}
+
+	var changes []PixChange
+	for _, a := range stageAges(ages) {
+		var oldV, newV map[int]int
+		if st, ok := tp.stages[a]; ok {
+			oldV = st.values
+		}
+		if st, ok := other.stages[a]; ok {
+			newV = st.values
+		}
+
+		for px, v := range oldV {
+			if nv := newV[px]; nv != v {
+				changes = append(changes, PixChange{Age: a, Pixel: px, Old: v, New: nv})
+			}
+		}
+		for px, v := range newV {
+			if _, ok := oldV[px]; ok {
+				continue
+			}
+			if v != 0 {
+				changes = append(changes, PixChange{Age: a, Pixel: px, New: v})
+			}
+		}
+	}
+
+	slices.SortFunc(changes, func(a, b PixChange) int {
+		if c := cmp.Compare(a.Age, b.Age); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Pixel, b.Pixel)
+	})
+	return changes
+}
+
+// IntersectOccupied returns a new time pixelation
+// in which the pixels occupied
+// (i.e. with a value different from 0)
+// in both time pixelations
+// at a time stage
+// have the marker value.
+// Time stages are aligned by their exact age,
+// and it returns an error
+// if the pixelations or the time stages are different.
+func (tp *TimePix) IntersectOccupied(other *TimePix, marker int) (*TimePix, error) {
+	return tp.occupied(other, marker, func(a, b bool) bool { return a && b })
+}
+
+// UnionOccupied returns a new time pixelation
+// in which the pixels occupied
+// (i.e. with a value different from 0)
+// in any of the time pixelations
+// at a time stage
+// have the marker value.
+// Time stages are aligned by their exact age,
+// and it returns an error
+// if the pixelations or the time stages are different.
+func (tp *TimePix) UnionOccupied(other *TimePix, marker int) (*TimePix, error) {
+	return tp.occupied(other, marker, func(a, b bool) bool { return a || b })
+}
+
+// Occupied returns a new time pixelation
+// with the marker value in the pixels
+// in which the occupancy of both time pixelations
+// is accepted by the keep function.
+func (tp *TimePix) occupied(other *TimePix, marker int, keep func(a, b bool) bool) (*TimePix, error) {
+	if tp.pix.Equator() != other.pix.Equator() {
+		return nil, fmt.Errorf("pixelation: got %d pixels at equator, want %d", other.pix.Equator(), tp.pix.Equator())
+	}
+	if marker == 0 {
+		return nil, fmt.Errorf("invalid marker value %d", marker)
+	}
+	for a := range tp.stages {
+		if _, ok := other.stages[a]; !ok {
+			return nil, fmt.Errorf("time stage %d: undefined in other time pixelation", a)
+		}
+	}
+	for a := range other.stages {
+		if _, ok := tp.stages[a]; !ok {
+			return nil, fmt.Errorf("time stage %d: undefined in time pixelation", a)
+		}
+	}
+
+	np := NewTimePix(tp.pix)
+	for a, st := range tp.stages {
+		ost := other.stages[a]
+		values := make(map[int]int)
+		for px, v := range st.values {
+			if keep(v != 0, ost.values[px] != 0) {
+				values[px] = marker
+			}
+		}
+		for px, v := range ost.values {
+			if _, ok := st.values[px]; ok {
+				continue
+			}
+			if keep(false, v != 0) {
+				values[px] = marker
+			}
+		}
+		np.stages[a] = &timePix{
+			age:    a,
+			values: values,
+		}
+	}
+	return np, nil
+}
+
+// InterpolateIDW sets the values of all pixels
+// of a time stage
+// using an inverse distance weighted interpolation
+// from a set of sample values.
+//
+// Samples is a map of pixel IDs to the sampled value.
+// The value of each pixel is the weighted average
+// of the k closest samples,
+// using as weight the inverse of the great circle distance
+// raised to the given power.
+// Larger powers give more influence to the closest samples,
+// and with power 0
+// the value is the mean of the k closest samples.
+// If k is zero or larger than the number of samples,
+// all samples will be used.
+// If a pixel is a sample,
+// its value will be the sampled value.
+//
+// As values in a time pixelation are integers,
+// the interpolated value will be rounded.
+func InterpolateIDW(tp *TimePix, age int64, samples map[int]float64, power float64, k int) {
+	if len(samples) == 0 {
+		return
+	}
+
+	type sample struct {
+		pt    earth.Point
+		value float64
+		dist  float64
+	}
+	smp := make([]sample, 0, len(samples))
+	for id, v := range samples {
+		smp = append(smp, sample{
+			pt:    tp.pix.ID(id).Point(),
+			value: v,
+		})
+	}
+	if k <= 0 || k > len(smp) {
+		k = len(smp)
+	}
+
+	for id := 0; id < tp.pix.Len(); id++ {
+		if v, ok := samples[id]; ok {
+			tp.Set(age, id, int(math.Round(v)))
+			continue
+		}
+
+		pt := tp.pix.ID(id).Point()
+		for i := range smp {
+			smp[i].dist = earth.Distance(pt, smp[i].pt)
+		}
+		slices.SortFunc(smp, func(a, b sample) int {
+			return cmp.Compare(a.dist, b.dist)
+		})
+
+		var sum, wSum float64
+		for _, s := range smp[:k] {
+			w := 1 / math.Pow(s.dist, power)
+			sum += w * s.value
+			wSum += w
+		}
+		tp.Set(age, id, int(math.Round(sum/wSum)))
+	}
+}
+
+// NearestWithValue returns the pixel closest to a point
+// whose value,
+// at the time stage of the given age
+// (in years),
+// is one of the indicated values.
+// For example,
+// it can be used to snap a locality
+// that falls in an ocean pixel
+// to the closest land pixel.
+//
+// The search starts at the pixel of the point
+// and expands outward by rings of neighboring pixels.
+// If more than one pixel with a valid value
+// is found in the same ring,
+// the pixel closest to the point
+// will be returned.
+//
+// It returns false if the stage is not defined,
+// or if no pixel is found
+// in the maximum number of rings
+// (the pixel of the point is ring 0).
+func NearestWithValue(tp *TimePix, age int64, p earth.Point, values map[int]bool, maxRings int) (int, bool) {
+	st := tp.Stage(age)
+	if st == nil {
+		return 0, false
+	}
+
+	start := tp.pix.Pixel(p.Latitude(), p.Longitude()).ID()
+	visited := map[int]bool{start: true}
+	ring := []int{start}
+	for r := 0; r <= maxRings && len(ring) > 0; r++ {
+		best := -1
+		var bestDist float64
+		for _, id := range ring {
+			v, ok := st[id]
+			if !ok || !values[v] {
+				continue
+			}
+			d := earth.Distance(p, tp.pix.ID(id).Point())
+			if best < 0 || d < bestDist || (d == bestDist && id < best) {
+				best = id
+				bestDist = d
+			}
+		}
+		if best >= 0 {
+			return best, true
+		}
+
+		var next []int
+		for _, id := range ring {
+			for _, nb := range tp.pix.Neighbors(id) {
+				if visited[nb] {
+					continue
+				}
+				visited[nb] = true
+				next = append(next, nb)
+			}
+		}
+		ring = next
+	}
+	return 0, false
+}
+
+// FirstStage returns the age of the youngest time stage
+// of a time pixelation.
+// It returns false if there are no stages defined.
+func (tp *TimePix) FirstStage() (int64, bool) {
+	return firstStage(tp.Stages())
+}
+
+// LastStage returns the age of the oldest time stage
+// of a time pixelation.
+// It returns false if there are no stages defined.
+func (tp *TimePix) LastStage() (int64, bool) {
+	return lastStage(tp.Stages())
+}
+
 // Pixelation returns the underlying equal area pixelation.
 func (tp *TimePix) Pixelation() *earth.Pixelation {
 	return tp.pix
 }
 
+// PixelSeries returns the values of a pixel
+// at each time stage
+// in which the pixel is defined.
+// The key of the map is the age of the time stage
+// (in years).
+// If the pixel is not defined in any time stage,
+// it returns an empty map.
+func (tp *TimePix) PixelSeries(pixel int) map[int64]int {
+	series := make(map[int64]int)
+	for a, st := range tp.stages {
+		v, ok := st.values[pixel]
+		if !ok {
+			continue
+		}
+		series[a] = v
+	}
+	return series
+}
+
+// RenameStage moves a time stage
+// to a new age
+// (in years).
+// It returns an error
+// if the time stage is not defined,
+// or if the new age is already defined.
+func (tp *TimePix) RenameStage(from, to int64) error {
+	st, ok := tp.stages[from]
+	if !ok {
+		return fmt.Errorf("time stage %d: undefined", from)
+	}
+	if _, ok := tp.stages[to]; ok {
+		return fmt.Errorf("time stage %d: already defined", to)
+	}
+
+	delete(tp.stages, from)
+	st.age = to
+	tp.stages[to] = st
+	return nil
+}
+
 // Set sets a value for a pixel at a time
 // in a time pixelation.
 func (tp *TimePix) Set(age int64, pixel, value int) {
-	if pixel >= tp.pix.Len() {
-		msg := fmt.Sprintf("pixel ID %d is invalid", pixel)
-		panic(msg)
-	}
+	tp.pix.MustID(pixel)
 
 	st := tp.stages[age]
 	if st == nil {
@@ -149,18 +734,166 @@ func (tp *TimePix) Stage(age int64) map[int]int {
 	return st.values
 }
 
+// A PixValue is the value of a pixel
+// in a time stage.
+type PixValue struct {
+	Pixel int
+	Value int
+}
+
+// StageSlice returns the values of all defined pixels
+// at a given age
+// (in years)
+// sorted by pixel ID.
+// If the stage is not defined,
+// it will return nil.
+func (tp *TimePix) StageSlice(age int64) []PixValue {
+	st, ok := tp.stages[age]
+	if !ok {
+		return nil
+	}
+
+	ids := stagePixels(st.values)
+	pv := make([]PixValue, len(ids))
+	for i, id := range ids {
+		pv[i] = PixValue{Pixel: id, Value: st.values[id]}
+	}
+	return pv
+}
+
+// StageDistance returns the difference
+// (in years)
+// between an age
+// and the age of the time stage
+// returned by ClosestStageAge.
+// If the age is younger than all time stages
+// (for which ClosestStageAge is undefined),
+// it returns the difference with the youngest stage.
+// If there are no time stages,
+// it returns -1.
+func (tp *TimePix) StageDistance(age int64) int64 {
+	return stageDistance(tp.Stages(), age)
+}
+
 // Stages returns the time stages defined
 // for a time pixelation.
 func (tp *TimePix) Stages() []int64 {
-	st := make([]int64, 0, len(tp.stages))
-	for _, a := range tp.stages {
-		st = append(st, a.age)
+	return stageAges(tp.stages)
+}
+
+// StageAges returns the sorted ages
+// of a map of time stages.
+func stageAges[V any](stages map[int64]V) []int64 {
+	st := make([]int64, 0, len(stages))
+	for a := range stages {
+		st = append(st, a)
 	}
 	slices.Sort(st)
 
 	return st
 }
 
+// StagePixels returns the sorted IDs
+// of the pixels defined in a time stage.
+func stagePixels[V any](st map[int]V) []int {
+	ids := make([]int, 0, len(st))
+	for id := range st {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	return ids
+}
+
+// FirstStage returns the first (youngest) age
+// from a sorted list of stage ages.
+// It returns false if the list is empty.
+func firstStage(st []int64) (int64, bool) {
+	if len(st) == 0 {
+		return 0, false
+	}
+	return st[0], true
+}
+
+// LastStage returns the last (oldest) age
+// from a sorted list of stage ages.
+// It returns false if the list is empty.
+func lastStage(st []int64) (int64, bool) {
+	if len(st) == 0 {
+		return 0, false
+	}
+	return st[len(st)-1], true
+}
+
+// StageBounds returns the age bounds
+// for the stage of the given age
+// from a sorted list of stage ages.
+func stageBounds(st []int64, age int64) (old, young int64) {
+	i, ok := slices.BinarySearch(st, age)
+	if !ok {
+		i = i - 1
+	}
+	if i+1 >= len(st) {
+		return earth.Age, st[i]
+	}
+	return st[i+1], st[i]
+}
+
+// ClosestStageAge returns the age of the oldest stage
+// younger than the indicated age
+// from a sorted list of stage ages.
+func closestStageAge(st []int64, age int64) int64 {
+	if i, ok := slices.BinarySearch(st, age); !ok {
+		age = st[i-1]
+	}
+	return age
+}
+
+// NearestStageAge returns the age of the stage
+// closest in absolute years to an age
+// (either younger or older)
+// from a sorted list of stage ages.
+// Ties are resolved in favor of the younger stage.
+// If the list is empty,
+// it returns the given age.
+func nearestStageAge(st []int64, age int64) int64 {
+	if len(st) == 0 {
+		return age
+	}
+	i, ok := slices.BinarySearch(st, age)
+	if ok {
+		return age
+	}
+	if i == 0 {
+		return st[0]
+	}
+	if i == len(st) {
+		return st[len(st)-1]
+	}
+	if st[i]-age < age-st[i-1] {
+		return st[i]
+	}
+	return st[i-1]
+}
+
+// StageDistance returns the difference
+// between an age
+// and the age of the closest stage
+// from a sorted list of stage ages.
+func stageDistance(st []int64, age int64) int64 {
+	if len(st) == 0 {
+		return -1
+	}
+	i, ok := slices.BinarySearch(st, age)
+	if ok {
+		return 0
+	}
+	if i == 0 {
+		return st[0] - age
+	}
+	return age - st[i-1]
+}
+
 type timePix struct {
 	// Age of the pixelation
 	age int64
@@ -236,7 +969,10 @@ func ReadTimePix(r io.Reader, pix *earth.Pixelation) (*TimePix, error) {
 			return nil, fmt.Errorf("on row %d: field %q: %v", ln, f, err)
 		}
 		if pix == nil {
-			pix = earth.NewPixelation(eq)
+			pix, err = earth.NewPixelationErr(eq)
+			if err != nil {
+				return nil, fmt.Errorf("on row %d: field %q: %v", ln, f, err)
+			}
 		}
 		if tp == nil {
 			tp = NewTimePix(pix)
@@ -261,7 +997,7 @@ func ReadTimePix(r io.Reader, pix *earth.Pixelation) (*TimePix, error) {
 		if err != nil {
 			return nil, fmt.Errorf("on row %d: field %q: %v", ln, f, err)
 		}
-		if px >= pix.Len() {
+		if !pix.Valid(px) {
 			return nil, fmt.Errorf("on row %d: field %q: invalid pixel value %d", ln, f, px)
 		}
 
@@ -281,10 +1017,16 @@ func ReadTimePix(r io.Reader, pix *earth.Pixelation) (*TimePix, error) {
 
 // TSV encodes a time pixelation
 // as a TSV file.
-func (tp *TimePix) TSV(w io.Writer) error {
+// Additional metadata can be given
+// and it will be written as comments
+// in the header of the file.
+func (tp *TimePix) TSV(w io.Writer, meta ...Metadata) error {
 	bw := bufio.NewWriter(w)
 	fmt.Fprintf(bw, "# time pixelation values\n")
 	fmt.Fprintf(bw, "# data save on: %s\n", time.Now().Format(time.RFC3339))
+	for _, m := range meta {
+		m.write(bw)
+	}
 	tab := csv.NewWriter(bw)
 	tab.Comma = '\t'
 	tab.UseCRLF = true
@@ -298,20 +1040,12 @@ func (tp *TimePix) TSV(w io.Writer) error {
 	ages := tp.Stages()
 	for _, a := range ages {
 		age := strconv.FormatInt(a, 10)
-		st := tp.stages[a]
-
-		pxs := make([]int, 0, len(st.values))
-		for id := range st.values {
-			pxs = append(pxs, id)
-		}
-		slices.Sort(pxs)
-
-		for _, id := range pxs {
+		for _, pv := range tp.StageSlice(a) {
 			row := []string{
 				eq,
 				age,
-				strconv.Itoa(id),
-				strconv.Itoa(st.values[id]),
+				strconv.Itoa(pv.Pixel),
+				strconv.Itoa(pv.Value),
 			}
 			if err := tab.Write(row); err != nil {
 				return fmt.Errorf("while writing data: %v", err)
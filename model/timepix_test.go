@@ -6,7 +6,9 @@ package model_test
 
 import (
 	"bytes"
+	"math"
 	"reflect"
+	"slices"
 	"strings"
 	"testing"
 
@@ -188,3 +190,644 @@ func testTimePix(t testing.TB, tp *model.TimePix) {
 		t.Errorf("stage at 100_000_000: got %v, want %v", st, st100)
 	}
 }
+
+func TestInterpolateIDW(t *testing.T) {
+	pix := earth.NewPixelation(360)
+	tp := model.NewTimePix(pix)
+
+	west := pix.Pixel(0, -20).ID()
+	east := pix.Pixel(0, 20).ID()
+	samples := map[int]float64{
+		west: 0,
+		east: 100,
+	}
+	age := int64(10_000_000)
+	model.InterpolateIDW(tp, age, samples, 2, 2)
+
+	if v, _ := tp.At(age, west); v != 0 {
+		t.Errorf("pixel %d: got %d, want %d", west, v, 0)
+	}
+	if v, _ := tp.At(age, east); v != 100 {
+		t.Errorf("pixel %d: got %d, want %d", east, v, 100)
+	}
+	if len(tp.Stage(age)) != pix.Len() {
+		t.Errorf("got %d pixels, want %d", len(tp.Stage(age)), pix.Len())
+	}
+
+	prev := -1
+	for lon := -20.0; lon <= 20; lon++ {
+		id := pix.Pixel(0, lon).ID()
+		v, _ := tp.At(age, id)
+		if v < prev {
+			t.Errorf("lon %.0f [pixel %d]: got %d, want >= %d", lon, id, v, prev)
+		}
+		prev = v
+	}
+	if v, _ := tp.At(age, pix.Pixel(0, 0).ID()); v != 50 {
+		t.Errorf("midpoint: got %d, want %d", v, 50)
+	}
+}
+
+func TestTimePixAtNearest(t *testing.T) {
+	pix := earth.NewPixelation(360)
+	tp := model.NewTimePix(pix)
+
+	px := 19051
+	tp.Set(100_000_000, px, 1)
+	tp.Set(140_000_000, px, 2)
+
+	tests := map[string]struct {
+		age   int64
+		value int
+		stage int64
+	}{
+		"exact":        {140_000_000, 2, 140_000_000},
+		"midpoint":     {120_000_000, 1, 100_000_000},
+		"older":        {121_000_000, 2, 140_000_000},
+		"younger":      {119_000_000, 1, 100_000_000},
+		"after last":   {200_000_000, 2, 140_000_000},
+		"before first": {50_000_000, 1, 100_000_000},
+	}
+	for name, test := range tests {
+		v, a := tp.AtNearest(test.age, px)
+		if a != test.stage {
+			t.Errorf("%s: stage: got %d, want %d", name, a, test.stage)
+		}
+		if v != test.value {
+			t.Errorf("%s: value: got %d, want %d", name, v, test.value)
+		}
+	}
+}
+
+func TestNearestWithValue(t *testing.T) {
+	pix := earth.NewPixelation(360)
+	tp := model.NewTimePix(pix)
+
+	// a coastline at the meridian 0,
+	// with land to the west
+	age := int64(10_000_000)
+	const ocean, land = 1, 2
+	for id := 0; id < pix.Len(); id++ {
+		pt := pix.ID(id).Point()
+		v := ocean
+		if pt.Longitude() < 0 && math.Abs(pt.Latitude()) < 30 {
+			v = land
+		}
+		tp.Set(age, id, v)
+	}
+	values := map[int]bool{land: true}
+
+	offshore := earth.NewPoint(10, 5)
+	id, ok := model.NearestWithValue(tp, age, offshore, values, 20)
+	if !ok {
+		t.Fatalf("offshore: no pixel found")
+	}
+	if v, _ := tp.At(age, id); v != land {
+		t.Errorf("offshore: pixel %d: got value %d, want %d", id, v, land)
+	}
+
+	// closest land pixel
+	// by brute force
+	want := math.Inf(1)
+	for px, v := range tp.Stage(age) {
+		if v != land {
+			continue
+		}
+		want = math.Min(want, earth.Distance(offshore, pix.ID(px).Point()))
+	}
+	got := earth.Distance(offshore, pix.ID(id).Point())
+	if got-want > pix.StepRad() {
+		t.Errorf("offshore: distance: got %.6f, want %.6f", got, want)
+	}
+
+	onLand := earth.NewPoint(10, -20)
+	if id, _ := model.NearestWithValue(tp, age, onLand, values, 0); id != pix.Pixel(10, -20).ID() {
+		t.Errorf("on land: got pixel %d, want %d", id, pix.Pixel(10, -20).ID())
+	}
+
+	if _, ok := model.NearestWithValue(tp, age, offshore, values, 2); ok {
+		t.Errorf("offshore: found a pixel with 2 rings")
+	}
+	if _, ok := model.NearestWithValue(tp, 20_000_000, offshore, values, 20); ok {
+		t.Errorf("undefined stage: found a pixel")
+	}
+}
+
+func TestTimePixStageSlice(t *testing.T) {
+	data := makeRecons(t)
+	tot := model.NewTotal(data)
+
+	tp := model.NewTimePix(tot.Pixelation())
+	setStage(tp, tot, 100_000_000)
+	tp.Set(100_000_000, 15000, 3)
+
+	want := []model.PixValue{
+		{Pixel: 15000, Value: 3},
+		{Pixel: 19051, Value: 1},
+		{Pixel: 19055, Value: 1},
+		{Pixel: 19409, Value: 1},
+		{Pixel: 19766, Value: 1},
+		{Pixel: 20122, Value: 1},
+		{Pixel: 20479, Value: 1},
+		{Pixel: 20480, Value: 1},
+	}
+	if got := tp.StageSlice(100_000_000); !reflect.DeepEqual(got, want) {
+		t.Errorf("stage slice: got %v, want %v", got, want)
+	}
+
+	if got := tp.StageSlice(50_000_000); got != nil {
+		t.Errorf("undefined stage: got %v, want nil", got)
+	}
+}
+
+func benchTimePix() *model.TimePix {
+	pix := earth.NewPixelation(360)
+	tp := model.NewTimePix(pix)
+	for id := 0; id < pix.Len(); id += 2 {
+		tp.Set(0, id, id%7)
+	}
+	return tp
+}
+
+func BenchmarkTimePixStageSlice(b *testing.B) {
+	tp := benchTimePix()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, pv := range tp.StageSlice(0) {
+			_ = pv.Value
+		}
+	}
+}
+
+func BenchmarkTimePixStageMap(b *testing.B) {
+	tp := benchTimePix()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		st := tp.Stage(0)
+		ids := make([]int, 0, len(st))
+		for id := range st {
+			ids = append(ids, id)
+		}
+		slices.Sort(ids)
+		for _, id := range ids {
+			_ = st[id]
+		}
+	}
+}
+
+func TestReadTimePixInvalidPixel(t *testing.T) {
+	for _, id := range []string{"-1", "41258"} {
+		data := "equator\tage\tstage-pixel\tvalue\n360\t100000000\t" + id + "\t1\n"
+		if _, err := model.ReadTimePix(strings.NewReader(data), nil); err == nil {
+			t.Errorf("pixel %s: expecting error", id)
+		}
+	}
+}
+
+func TestReadTimePixInvalidEquator(t *testing.T) {
+	for _, eq := range []string{"0", "-4"} {
+		data := "equator\tage\tstage-pixel\tvalue\n" + eq + "\t100000000\t100\t1\n"
+		if _, err := model.ReadTimePix(strings.NewReader(data), nil); err == nil {
+			t.Errorf("equator %s: expecting error", eq)
+		}
+	}
+}
+
+func TestTimePixFirstLastStage(t *testing.T) {
+	tp := model.NewTimePix(earth.NewPixelation(360))
+	if _, ok := tp.FirstStage(); ok {
+		t.Errorf("first stage: empty time pixelation with stages")
+	}
+	if _, ok := tp.LastStage(); ok {
+		t.Errorf("last stage: empty time pixelation with stages")
+	}
+
+	tp.Set(140_000_000, 20051, 1)
+	tp.Set(100_000_000, 19051, 1)
+	tp.Set(120_000_000, 19051, 1)
+	if f, ok := tp.FirstStage(); !ok || f != 100_000_000 {
+		t.Errorf("first stage: got %d, want %d", f, 100_000_000)
+	}
+	if l, ok := tp.LastStage(); !ok || l != 140_000_000 {
+		t.Errorf("last stage: got %d, want %d", l, 140_000_000)
+	}
+}
+
+func TestTimePixApply(t *testing.T) {
+	data := makeRecons(t)
+	tot := model.NewTotal(data)
+
+	tp := model.NewTimePix(tot.Pixelation())
+	setStage(tp, tot, 100_000_000)
+	setStage(tp, tot, 140_000_000)
+	tp.Set(100_000_000, 15000, 3)
+	tp.Set(140_000_000, 15000, 3)
+
+	// change value 1 to 2,
+	// and remove value 3
+	// only at 100 Ma
+	tp.Apply([]int64{100_000_000, 50_000_000}, func(_ int64, _, v int) int {
+		switch v {
+		case 1:
+			return 2
+		case 3:
+			return 0
+		}
+		return v
+	})
+
+	vals100 := map[int]int{
+		19051: 2,
+		19055: 2,
+		19409: 2,
+		19766: 2,
+		20122: 2,
+		20479: 2,
+		20480: 2,
+	}
+	if st := tp.Stage(100_000_000); !reflect.DeepEqual(st, vals100) {
+		t.Errorf("stage %d: got %v, want %v", 100_000_000, st, vals100)
+	}
+	if v, _ := tp.At(140_000_000, 15000); v != 3 {
+		t.Errorf("stage %d: pixel %d: got %d, want %d", 140_000_000, 15000, v, 3)
+	}
+	if v, _ := tp.At(140_000_000, 20051); v != 1 {
+		t.Errorf("stage %d: pixel %d: got %d, want %d", 140_000_000, 20051, v, 1)
+	}
+	if _, ok := tp.At(50_000_000, 15000); ok {
+		t.Errorf("stage %d: should be undefined", 50_000_000)
+	}
+}
+
+func TestTimePixCompact(t *testing.T) {
+	pix := earth.NewPixelation(360)
+	tp := model.NewTimePix(pix)
+	tp.Set(100_000_000, 19051, 1)
+	tp.Set(100_000_000, 19055, 0)
+	tp.Set(100_000_000, 19409, 2)
+	tp.Set(140_000_000, 20051, 0)
+	tp.Set(140_000_000, 20055, 0)
+
+	var before bytes.Buffer
+	if err := tp.TSV(&before); err != nil {
+		t.Fatalf("while writing data: %v", err)
+	}
+
+	tp.Compact()
+
+	var after bytes.Buffer
+	if err := tp.TSV(&after); err != nil {
+		t.Fatalf("while writing data: %v", err)
+	}
+	if after.Len() >= before.Len() {
+		t.Errorf("compact: got %d bytes, want less than %d", after.Len(), before.Len())
+	}
+
+	stages := []int64{100_000_000}
+	if st := tp.Stages(); !reflect.DeepEqual(st, stages) {
+		t.Errorf("stages: got %v, want %v", st, stages)
+	}
+	vals := map[int]int{
+		19051: 1,
+		19409: 2,
+	}
+	if st := tp.Stage(100_000_000); !reflect.DeepEqual(st, vals) {
+		t.Errorf("stage %d: got %v, want %v", 100_000_000, st, vals)
+	}
+}
+
+func TestTimePixCopyRenameStage(t *testing.T) {
+	pix := earth.NewPixelation(360)
+	tp := model.NewTimePix(pix)
+	tp.Set(100_000_000, 19051, 1)
+	tp.Set(100_000_000, 19055, 2)
+
+	tp.CopyStage(100_000_000, 120_000_000)
+	tp.Set(120_000_000, 19051, 3)
+	tp.Del(120_000_000, 19055)
+
+	src := map[int]int{
+		19051: 1,
+		19055: 2,
+	}
+	if st := tp.Stage(100_000_000); !reflect.DeepEqual(st, src) {
+		t.Errorf("stage %d: got %v, want %v", 100_000_000, st, src)
+	}
+	dst := map[int]int{
+		19051: 3,
+	}
+	if st := tp.Stage(120_000_000); !reflect.DeepEqual(st, dst) {
+		t.Errorf("stage %d: got %v, want %v", 120_000_000, st, dst)
+	}
+
+	if err := tp.RenameStage(120_000_000, 100_000_000); err == nil {
+		t.Errorf("rename to defined stage: expecting error")
+	}
+	if err := tp.RenameStage(50_000_000, 60_000_000); err == nil {
+		t.Errorf("rename undefined stage: expecting error")
+	}
+	if err := tp.RenameStage(120_000_000, 80_000_000); err != nil {
+		t.Fatalf("rename: unexpected error: %v", err)
+	}
+
+	stages := []int64{80_000_000, 100_000_000}
+	if st := tp.Stages(); !reflect.DeepEqual(st, stages) {
+		t.Errorf("stages: got %v, want %v", st, stages)
+	}
+	if st := tp.Stage(80_000_000); !reflect.DeepEqual(st, dst) {
+		t.Errorf("stage %d: got %v, want %v", 80_000_000, st, dst)
+	}
+	if _, ok := tp.At(120_000_000, 19051); ok {
+		t.Errorf("stage %d: should be undefined", 120_000_000)
+	}
+}
+
+func TestTimePixBoundaryLength(t *testing.T) {
+	pix := earth.NewPixelation(360)
+	tp := model.NewTimePix(pix)
+	const ocean, land = 1, 2
+	values := map[int]bool{land: true}
+
+	// a single pixel island
+	age := int64(10_000_000)
+	for id := 0; id < pix.Len(); id++ {
+		tp.Set(age, id, ocean)
+	}
+	island := pix.Pixel(0, 0).ID()
+	tp.Set(age, island, land)
+
+	var want float64
+	for _, nb := range pix.Neighbors(island) {
+		want += earth.Distance(pix.ID(island).Point(), pix.ID(nb).Point())
+	}
+	if got := tp.BoundaryLength(age, values); math.Abs(got-want) > 1e-12 {
+		t.Errorf("pixel island: got %.6f, want %.6f", got, want)
+	}
+
+	// circular islands:
+	// the length is overestimated,
+	// but it should be proportional to the perimeter
+	center := earth.NewPoint(10, 20)
+	small, large := earth.ToRad(10), earth.ToRad(20)
+	for id := 0; id < pix.Len(); id++ {
+		d := earth.Distance(center, pix.ID(id).Point())
+		v := ocean
+		if d < small {
+			v = land
+		}
+		tp.Set(20_000_000, id, v)
+
+		v = ocean
+		if d < large {
+			v = land
+		}
+		tp.Set(40_000_000, id, v)
+	}
+	age = 20_000_000
+	got := tp.BoundaryLength(age, values)
+	if perimeter := 2 * math.Pi * math.Sin(small); got < perimeter || got > 3*perimeter {
+		t.Errorf("circular island: got %.6f, want about %.6f", got, perimeter)
+	}
+	ratio := tp.BoundaryLength(40_000_000, values) / got
+	if want := math.Sin(large) / math.Sin(small); math.Abs(ratio-want) > 0.1*want {
+		t.Errorf("circular island: ratio: got %.6f, want %.6f", ratio, want)
+	}
+
+	// ocean is the complement of land
+	if o := tp.BoundaryLength(age, map[int]bool{ocean: true}); math.Abs(o-got) > 1e-9 {
+		t.Errorf("circular island: ocean: got %.6f, want %.6f", o, got)
+	}
+
+	if l := tp.BoundaryLength(30_000_000, values); l != 0 {
+		t.Errorf("undefined stage: got %.6f, want 0", l)
+	}
+}
+
+func TestTimePixPixelSeries(t *testing.T) {
+	pix := earth.NewPixelation(360)
+	tp := model.NewTimePix(pix)
+	tp.Set(100_000_000, 19051, 1)
+	tp.Set(120_000_000, 19051, 3)
+	tp.Set(120_000_000, 19055, 2)
+	tp.Set(140_000_000, 19055, 2)
+
+	want := map[int64]int{
+		100_000_000: 1,
+		120_000_000: 3,
+	}
+	if got := tp.PixelSeries(19051); !reflect.DeepEqual(got, want) {
+		t.Errorf("pixel %d: got %v, want %v", 19051, got, want)
+	}
+
+	if got := tp.PixelSeries(100); got == nil || len(got) != 0 {
+		t.Errorf("pixel %d: got %v, want an empty map", 100, got)
+	}
+}
+
+func TestTimePixDiff(t *testing.T) {
+	pix := earth.NewPixelation(360)
+	old := model.NewTimePix(pix)
+	old.Set(100_000_000, 19051, 1)
+	old.Set(100_000_000, 19055, 2)
+	old.Set(100_000_000, 19409, 0)
+	old.Set(140_000_000, 20051, 3)
+
+	tp := model.NewTimePix(pix)
+	tp.Set(100_000_000, 19051, 1)
+	tp.Set(100_000_000, 19055, 4)
+	tp.Set(120_000_000, 20055, 5)
+	tp.Set(120_000_000, 20057, 0)
+
+	want := []model.PixChange{
+		{Age: 100_000_000, Pixel: 19055, Old: 2, New: 4},
+		{Age: 120_000_000, Pixel: 20055, Old: 0, New: 5},
+		{Age: 140_000_000, Pixel: 20051, Old: 3, New: 0},
+	}
+	if got := old.Diff(tp); !reflect.DeepEqual(got, want) {
+		t.Errorf("diff: got %v, want %v", got, want)
+	}
+
+	if got := tp.Diff(tp); len(got) != 0 {
+		t.Errorf("diff with itself: got %v, want no changes", got)
+	}
+}
+
+func TestTimePixClone(t *testing.T) {
+	pix := earth.NewPixelation(360)
+	tp := model.NewTimePix(pix)
+	tp.Set(100_000_000, 19051, 1)
+	tp.Set(140_000_000, 20051, 3)
+
+	c := tp.Clone()
+	if got := tp.Diff(c); len(got) != 0 {
+		t.Errorf("clone: got changes %v, want no changes", got)
+	}
+
+	c.Set(100_000_000, 19051, 2)
+	c.Set(120_000_000, 20055, 5)
+	if v, _ := tp.At(100_000_000, 19051); v != 1 {
+		t.Errorf("original: got value %d, want %d", v, 1)
+	}
+	if _, ok := tp.At(120_000_000, 20055); ok {
+		t.Errorf("original: unexpected stage %d", 120_000_000)
+	}
+}
+
+func TestTimePixOccupied(t *testing.T) {
+	pix := earth.NewPixelation(360)
+	a := model.NewTimePix(pix)
+	a.Set(100_000_000, 19051, 1)
+	a.Set(100_000_000, 19055, 2)
+	a.Set(100_000_000, 19409, 0)
+	a.Set(140_000_000, 20051, 3)
+
+	b := model.NewTimePix(pix)
+	b.Set(100_000_000, 19051, 4)
+	b.Set(100_000_000, 19409, 5)
+	b.Set(100_000_000, 19766, 6)
+	b.Set(140_000_000, 20055, 7)
+
+	inter, err := a.IntersectOccupied(b, 9)
+	if err != nil {
+		t.Fatalf("intersect: unexpected error: %v", err)
+	}
+	wantInter := map[int64]map[int]int{
+		100_000_000: {19051: 9},
+		140_000_000: {},
+	}
+	for age, want := range wantInter {
+		if got := inter.Stage(age); !reflect.DeepEqual(got, want) {
+			t.Errorf("intersect: stage %d: got %v, want %v", age, got, want)
+		}
+	}
+
+	union, err := a.UnionOccupied(b, 9)
+	if err != nil {
+		t.Fatalf("union: unexpected error: %v", err)
+	}
+	wantUnion := map[int64]map[int]int{
+		100_000_000: {19051: 9, 19055: 9, 19409: 9, 19766: 9},
+		140_000_000: {20051: 9, 20055: 9},
+	}
+	for age, want := range wantUnion {
+		if got := union.Stage(age); !reflect.DeepEqual(got, want) {
+			t.Errorf("union: stage %d: got %v, want %v", age, got, want)
+		}
+	}
+
+	// stage mismatch
+	b.Set(120_000_000, 20055, 7)
+	if _, err := a.IntersectOccupied(b, 9); err == nil {
+		t.Errorf("intersect: expecting stage mismatch error")
+	}
+	if _, err := a.UnionOccupied(b, 9); err == nil {
+		t.Errorf("union: expecting stage mismatch error")
+	}
+
+	// pixelation mismatch
+	c := model.NewTimePix(earth.NewPixelation(120))
+	if _, err := a.UnionOccupied(c, 9); err == nil {
+		t.Errorf("union: expecting pixelation mismatch error")
+	}
+}
+
+func TestCombineRule(t *testing.T) {
+	tests := map[string]struct {
+		old   int
+		value int
+		want  int
+		ok    bool
+	}{
+		"max:larger":          {old: 3, value: 5, want: 5, ok: true},
+		"max:smaller":         {old: 3, value: 1, want: 3},
+		"max:undefined":       {old: 0, value: 1, want: 1, ok: true},
+		"min:larger":          {old: 3, value: 5, want: 3},
+		"min:smaller":         {old: 3, value: 1, want: 1, ok: true},
+		"min:undefined":       {old: 0, value: 5, want: 5, ok: true},
+		"overwrite:larger":    {old: 3, value: 5, want: 5, ok: true},
+		"overwrite:smaller":   {old: 3, value: 1, want: 1, ok: true},
+		"keep:larger":         {old: 3, value: 5, want: 3},
+		"keep:smaller":        {old: 3, value: 1, want: 3},
+		"keep:undefined":      {old: 0, value: 5, want: 5, ok: true},
+		"overwrite:undefined": {old: 0, value: 5, want: 5, ok: true},
+	}
+	for name, test := range tests {
+		rule, err := model.ParseCombineRule(strings.Split(name, ":")[0])
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		v, ok := rule.Combine(test.old, test.value)
+		if v != test.want || ok != test.ok {
+			t.Errorf("%s: got %d %v, want %d %v", name, v, ok, test.want, test.ok)
+		}
+	}
+
+	if _, err := model.ParseCombineRule("sum"); err == nil {
+		t.Errorf("unknown rule: expecting error")
+	}
+}
+
+func TestTimePixCoarsen(t *testing.T) {
+	pix := earth.NewPixelation(360)
+	tp := model.NewTimePix(pix)
+	tp.Set(10_000_000, 19051, 1)
+	tp.Set(10_000_000, 19055, 2)
+	tp.Set(11_000_000, 19051, 3)
+	tp.Set(11_000_000, 19409, 0)
+	tp.Set(12_000_000, 19051, 2)
+	tp.Set(12_000_000, 19766, 4)
+	tp.Set(20_000_000, 20051, 5)
+
+	tests := map[model.CombineRule]map[int]int{
+		model.CombineMax:       {19051: 3, 19055: 2, 19766: 4},
+		model.CombineMin:       {19051: 1, 19055: 2, 19766: 4},
+		model.CombineOverwrite: {19051: 2, 19055: 2, 19766: 4},
+		model.CombineKeep:      {19051: 1, 19055: 2, 19766: 4},
+	}
+	for rule, want := range tests {
+		ct := tp.Coarsen(10_000_000, rule)
+		if st := ct.Stages(); !reflect.DeepEqual(st, []int64{10_000_000, 20_000_000}) {
+			t.Errorf("rule %d: stages: got %v, want %v", rule, st, []int64{10_000_000, 20_000_000})
+		}
+		if got := ct.Stage(10_000_000); !reflect.DeepEqual(got, want) {
+			t.Errorf("rule %d: stage %d: got %v, want %v", rule, 10_000_000, got, want)
+		}
+		if got, w := ct.Stage(20_000_000), map[int]int{20051: 5}; !reflect.DeepEqual(got, w) {
+			t.Errorf("rule %d: stage %d: got %v, want %v", rule, 20_000_000, got, w)
+		}
+	}
+}
+
+func TestTimePixStageDistance(t *testing.T) {
+	tp := model.NewTimePix(earth.NewPixelation(360))
+	if d := tp.StageDistance(100_000_000); d != -1 {
+		t.Errorf("empty time pixelation: got %d, want %d", d, -1)
+	}
+
+	tp.Set(100_000_000, 19051, 1)
+	tp.Set(140_000_000, 20051, 1)
+
+	tests := map[string]struct {
+		age  int64
+		want int64
+	}{
+		"exact":       {100_000_000, 0},
+		"equidistant": {120_000_000, 20_000_000},
+		"near older":  {139_000_000, 39_000_000},
+		"oldest":      {150_000_000, 10_000_000},
+		"youngest":    {90_000_000, 10_000_000},
+	}
+	for name, test := range tests {
+		if d := tp.StageDistance(test.age); d != test.want {
+			t.Errorf("%s: got %d, want %d", name, d, test.want)
+		}
+		if test.age < 100_000_000 {
+			continue
+		}
+		if d := test.age - tp.ClosestStageAge(test.age); d != test.want {
+			t.Errorf("%s: closest stage: got %d, want %d", name, d, test.want)
+		}
+	}
+}
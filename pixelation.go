@@ -7,7 +7,9 @@ package earth
 import (
 	"fmt"
 	"math"
+	"math/bits"
 	"math/rand"
+	"slices"
 	"sync"
 
 	"gonum.org/v1/gonum/spatial/r3"
@@ -30,17 +32,92 @@ type Pixelation struct {
 	perRing []int // number of pixels in each ring
 
 	// Index allows a quick retrieval of pixels
-	// using an equirectangular projection
+	// using an equirectangular projection.
+	// If nil,
+	// pixels are searched directly.
 	mu    sync.RWMutex
 	cols  int
-	iStep float64
 	index []int
 }
 
 // NewPixelation returns a new pixelation
 // with a given number of pixels
 // at the equatorial parallel.
+// It panics if the number of pixels is not valid
+// (see NewPixelationErr).
+//
+// The pixelation uses an index
+// to speed up the search of pixels by coordinates.
+// The index is filled lazily,
+// but its size grows with the square
+// of the number of pixels at the equator
+// (see IndexBytes).
 func NewPixelation(eq int) *Pixelation {
+	pix, err := NewPixelationErr(eq)
+	if err != nil {
+		panic(err)
+	}
+	return pix
+}
+
+// NewPixelationErr returns a new pixelation
+// with a given number of pixels
+// at the equatorial parallel,
+// or an error if the number of pixels
+// is zero or negative.
+// As the number of pixels at the equator
+// must be even,
+// an odd value will be rounded
+// to the next even number
+// (e.g. 361 will produce a pixelation
+// with 362 pixels at the equator).
+//
+// Use it when the number of pixels
+// comes from an external source,
+// for example,
+// when reading a file.
+func NewPixelationErr(eq int) (*Pixelation, error) {
+	pix, err := newPixelation(eq)
+	if err != nil {
+		return nil, err
+	}
+
+	// The index has a resolution
+	// 1o times greater than the pixelation
+	pix.cols = pix.eq * 10
+	pix.index = make([]int, pix.cols*pix.cols/2)
+	for i := range pix.index {
+		pix.index[i] = -1
+	}
+
+	return pix, nil
+}
+
+// NewPixelationNoIndex returns a new pixelation
+// with a given number of pixels
+// at the equatorial parallel,
+// without an index.
+//
+// Searching pixels by coordinates
+// (for example, with Pixel or FromVector)
+// is slower than in a pixelation with an index,
+// but the pixelation requires much less memory.
+// Use it for pixelations with a high resolution,
+// or when only a few searches will be done.
+// It panics if the number of pixels is not valid
+// (see NewPixelationErr).
+func NewPixelationNoIndex(eq int) *Pixelation {
+	pix, err := newPixelation(eq)
+	if err != nil {
+		panic(err)
+	}
+	return pix
+}
+
+func newPixelation(eq int) (*Pixelation, error) {
+	if eq <= 0 {
+		return nil, fmt.Errorf("invalid number of pixels at the equator: %d", eq)
+	}
 	if eq%2 != 0 {
 		eq++
 	}
@@ -61,16 +138,45 @@ func NewPixelation(eq int) *Pixelation {
 		pix.perRing[r] = len(pix.pixels) - pix.rings[r]
 	}
 
-	// The index has a resolution
-	// 1o times greater than the pixelation
-	pix.cols = pix.eq * 10
-	pix.iStep = 360 / float64(pix.cols)
-	pix.index = make([]int, pix.cols*pix.cols/2)
-	for i := range pix.index {
-		pix.index[i] = -1
-	}
+	return pix, nil
+}
 
-	return pix
+// PixelationInfo is a summary description
+// of a pixelation.
+type PixelationInfo struct {
+	// Number of pixels at the equator
+	Equator int `json:"equator"`
+
+	// Number of pixels
+	Pixels int `json:"pixels"`
+
+	// Number of rings
+	Rings int `json:"rings"`
+
+	// Size of a pixel at the equator,
+	// in degrees
+	Step float64 `json:"step"`
+
+	// Average area of a pixel,
+	// in steradians
+	// and in square kilometers
+	PixelArea    float64 `json:"pixelArea"`
+	PixelAreaKm2 float64 `json:"pixelAreaKm2"`
+}
+
+// Describe returns a summary description
+// of the pixelation.
+func (pix *Pixelation) Describe() PixelationInfo {
+	area := 4 * math.Pi / float64(pix.Len())
+	km := float64(Radius) / 1000
+	return PixelationInfo{
+		Equator:      pix.Equator(),
+		Pixels:       pix.Len(),
+		Rings:        pix.Rings(),
+		Step:         pix.Step(),
+		PixelArea:    area,
+		PixelAreaKm2: area * km * km,
+	}
 }
 
 // Equator returns the number of pixels
@@ -107,17 +213,117 @@ func (pix *Pixelation) FromVector(v r3.Vec) Pixel {
 
 // ID returns a pixel
 // by its ID.
+// It is the fast path for IDs known to be valid,
+// an invalid ID will produce an index out of range panic.
+// Use IDOk for IDs read from external sources.
 func (pix *Pixelation) ID(id int) Pixel {
 	return pix.pixels[id]
 }
 
+// IDOk returns a pixel
+// by its ID.
+// It returns false if the ID is not valid,
+// so it can be used with IDs read from files
+// or calculated by the caller.
+func (pix *Pixelation) IDOk(id int) (Pixel, bool) {
+	if !pix.Valid(id) {
+		return Pixel{}, false
+	}
+	return pix.pixels[id], true
+}
+
+// IndexBytes returns the size
+// (in bytes)
+// of the index used to search pixels by coordinates.
+// The memory is allocated when the pixelation is created.
+// A pixelation without an index returns 0.
+func (pix *Pixelation) IndexBytes() int {
+	return len(pix.index) * bits.UintSize / 8
+}
+
 // Len returns the number of pixels in the pixelation.
 func (pix *Pixelation) Len() int {
 	return len(pix.pixels)
 }
 
+// MustID returns a pixel
+// by its ID.
+// It panics if the ID is not valid.
+func (pix *Pixelation) MustID(id int) Pixel {
+	if !pix.Valid(id) {
+		msg := fmt.Sprintf("invalid pixel ID: %d", id)
+		panic(msg)
+	}
+	return pix.pixels[id]
+}
+
+// Neighbors returns the IDs of the pixels
+// that share an edge with a pixel,
+// sorted by ID.
+// The neighbors are the adjacent pixels in the same ring,
+// and the pixels of the rings to the north and south
+// whose longitude range overlaps the longitude range
+// of the pixel.
+func (pix *Pixelation) Neighbors(id int) []int {
+	px := pix.MustID(id)
+	r := px.ring
+
+	var nb []int
+	first, count := pix.Ring(r)
+	if count > 1 {
+		pos := id - first
+		nb = append(nb, first+(pos+count-1)%count)
+		if next := first + (pos+1)%count; next != nb[0] {
+			nb = append(nb, next)
+		}
+	}
+
+	w := 360 / float64(count)
+	for _, nr := range []int{r - 1, r + 1} {
+		if nr < 0 || nr >= len(pix.rings) {
+			continue
+		}
+		nf, nc := pix.Ring(nr)
+		nw := 360 / float64(nc)
+		half := (w + nw) / 2
+
+		// pixels in a ring are evenly spaced,
+		// starting from the first pixel of the ring,
+		// so only the pixels around the longitude
+		// of the pixel are checked.
+		start := pix.pixels[nf].point.lon
+		lo := int(math.Floor((px.point.lon - half - start) / nw))
+		hi := int(math.Ceil((px.point.lon + half - start) / nw))
+		if hi-lo >= nc {
+			lo, hi = 0, nc-1
+		}
+		for k := lo; k <= hi; k++ {
+			i := nf + (k%nc+nc)%nc
+			d := math.Abs(px.point.lon - pix.pixels[i].point.lon)
+			d = math.Min(d, 360-d)
+			if d < half-1e-9 {
+				nb = append(nb, i)
+			}
+		}
+	}
+
+	slices.Sort(nb)
+	return nb
+}
+
 // Pixel returns a pixel
 // from a latitude and longitude coordinate pair.
+// In a pixelation without an index
+// (see NewPixelationNoIndex),
+// if the coordinates are at the same distance
+// of two or more pixels,
+// the pixel with the lowest ID is returned.
+// In a pixelation with an index,
+// the pixel returned for a point
+// near the border between pixels
+// is the pixel stored in the index
+// by the first point searched in the same index cell,
+// so it depends on the order of the queries.
 // It panics if the coordinates are not valid.
 func (pix *Pixelation) Pixel(lat, lon float64) Pixel {
 	if lat < -90 || lat > 90 {
@@ -132,11 +338,165 @@ func (pix *Pixelation) Pixel(lat, lon float64) Pixel {
 	return pix.getPixel(lat, lon)
 }
 
+// PixelSeq returns the pixel IDs
+// of a sequence of points,
+// in the same order.
+//
+// It returns the same pixels as calling Pixel
+// for each point,
+// but as the index is read,
+// and updated,
+// only once for the whole sequence,
+// it is faster for large sets of nearby points
+// (for example,
+// the vertices of a dense polygon).
+func (pix *Pixelation) PixelSeq(pts []Point) []int {
+	ids := make([]int, len(pts))
+	if pix.index == nil {
+		for i, pt := range pts {
+			ids[i] = pix.search(pt.lat, pt.lon)
+		}
+		return ids
+	}
+
+	pos := make([]int, len(pts))
+	for i, pt := range pts {
+		pos[i] = pix.indexPos(pt.lat, pt.lon)
+	}
+
+	pix.mu.RLock()
+	for i, p := range pos {
+		ids[i] = pix.index[p]
+	}
+	pix.mu.RUnlock()
+
+	// points in the same index cell
+	// share the pixel of the first point
+	// as happens with individual lookups.
+	var missing map[int]int
+	for i, id := range ids {
+		if id != -1 {
+			continue
+		}
+		if missing == nil {
+			missing = make(map[int]int)
+		}
+		if id, ok := missing[pos[i]]; ok {
+			ids[i] = id
+			continue
+		}
+		ids[i] = pix.search(pts[i].lat, pts[i].lon)
+		missing[pos[i]] = ids[i]
+	}
+	if len(missing) == 0 {
+		return ids
+	}
+
+	pix.mu.Lock()
+	for p, id := range missing {
+		pix.index[p] = id
+	}
+	pix.mu.Unlock()
+
+	return ids
+}
+
+// PixelBox returns the bounding box
+// of the cell covered by a pixel.
+// If the cell crosses the antimeridian,
+// the West longitude of the box
+// will be greater than the East longitude.
+// The cells of the polar pixels
+// include all longitudes.
+func (pix *Pixelation) PixelBox(id int) Box {
+	px := pix.MustID(id)
+	lat := px.point.lat
+	b := Box{
+		North: math.Min(lat+pix.dStep/2, 90),
+		South: math.Max(lat-pix.dStep/2, -90),
+		West:  -180,
+		East:  180,
+	}
+
+	count := pix.perRing[px.ring]
+	if count == 1 {
+		return b
+	}
+	w := 360 / float64(count)
+	b.West = NormalizeLon(px.point.lon - w/2)
+	b.East = NormalizeLon(px.point.lon + w/2)
+	return b
+}
+
+// PixelArea returns the area of a pixel
+// at a given ring,
+// in steradians
+// (i.e. the area on the unit sphere).
+// Multiply by the square of the Earth radius
+// to get the area in square meters.
+func (pix *Pixelation) PixelArea(ring int) float64 {
+	return pix.RingArea(ring) / float64(pix.perRing[ring])
+}
+
 // PixPerRing returns the number of pixels in a ring.
 func (pix *Pixelation) PixPerRing(ring int) int {
 	return pix.perRing[ring]
 }
 
+// PixelsInCap returns the IDs of the pixels
+// that are at most at the given distance
+// (in radians)
+// from a point
+// (i.e. the pixels inside a spherical cap).
+// The IDs are sorted.
+func (pix *Pixelation) PixelsInCap(center Point, dist float64) []int {
+	// rings inside the latitude band of the cap
+	lat := center.Latitude()
+	north := math.Min(90, lat+ToDegree(dist)+pix.dStep)
+	south := math.Max(-90, lat-ToDegree(dist)-pix.dStep)
+	first := pix.Pixel(north, 0).Ring()
+	last := pix.Pixel(south, 0).Ring()
+
+	var ids []int
+	for r := first; r <= last; r++ {
+		fp := pix.rings[r]
+		for id := fp; id < fp+pix.perRing[r]; id++ {
+			if Distance(center, pix.pixels[id].point) > dist {
+				continue
+			}
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// RandInCap returns a random pixel
+// from the pixels that are at most at the given distance
+// (in radians)
+// from a point
+// (see PixelsInCap).
+// As the pixelation is an equal area pixelation,
+// the pixel is sampled uniformly by area
+// inside the cap.
+// It returns false if there are no pixels in the cap.
+//
+// Rnd is used as the source of random numbers,
+// so a sample can be reproduced.
+// If rnd is nil,
+// the default source of the math/rand package
+// will be used.
+func (pix *Pixelation) RandInCap(center Point, dist float64, rnd *rand.Rand) (Pixel, bool) {
+	ids := pix.PixelsInCap(center, dist)
+	if len(ids) == 0 {
+		return Pixel{}, false
+	}
+	intn := rand.Intn
+	if rnd != nil {
+		intn = rnd.Intn
+	}
+	return pix.pixels[ids[intn(len(ids))]], true
+}
+
 // Random returns a random pixel from the pixelation.
 func (pix *Pixelation) Random() Pixel {
 	id := rand.Intn(len(pix.pixels))
@@ -149,17 +509,105 @@ func (pix *Pixelation) RandInRing(ring int) Pixel {
 	return pix.pixels[id]
 }
 
+// Ring returns the ID of the first pixel of a ring
+// and the number of pixels in the ring.
+// The pixels of the ring
+// are the IDs in the range [first, first+count).
+func (pix *Pixelation) Ring(ring int) (first, count int) {
+	return pix.rings[ring], pix.perRing[ring]
+}
+
+// RingArea returns the area of a ring,
+// in steradians
+// (i.e. the area on the unit sphere).
+//
+// The ring is the band between the latitudes
+// at half a step above and below the latitude of the ring,
+// so the sum of the area of all rings
+// is the area of the sphere.
+func (pix *Pixelation) RingArea(ring int) float64 {
+	lat := pix.RingLat(ring)
+	north := math.Min(lat+pix.dStep/2, 90)
+	south := math.Max(lat-pix.dStep/2, -90)
+	return 2 * math.Pi * (math.Sin(ToRad(north)) - math.Sin(ToRad(south)))
+}
+
 // RingLat returns the latitude of a ring.
 func (pix *Pixelation) RingLat(ring int) float64 {
 	px := pix.pixels[pix.rings[ring]]
 	return px.point.lat
 }
 
+// RingLats returns the latitude of each ring,
+// from the north pole
+// to the south pole.
+func (pix *Pixelation) RingLats() []float64 {
+	lats := make([]float64, len(pix.rings))
+	for r := range lats {
+		lats[r] = pix.RingLat(r)
+	}
+	return lats
+}
+
+// RingPixels returns the IDs of the pixels in a ring.
+func (pix *Pixelation) RingPixels(ring int) []int {
+	first, count := pix.Ring(ring)
+	ids := make([]int, 0, count)
+	for id := first; id < first+count; id++ {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
 // Rings returns the number of rings in the pixelation.
 func (pix *Pixelation) Rings() int {
 	return len(pix.rings)
 }
 
+// Sample returns the IDs of n distinct random pixels
+// from the pixelation
+// (i.e. a sample without replacement).
+// If n is larger than the number of pixels,
+// all the pixels will be returned
+// in a random order.
+//
+// Rnd is used as the source of random numbers,
+// so a sample can be reproduced.
+// If rnd is nil,
+// the default source of the math/rand package
+// will be used.
+func (pix *Pixelation) Sample(n int, rnd *rand.Rand) []int {
+	if n > len(pix.pixels) {
+		n = len(pix.pixels)
+	}
+	if n <= 0 {
+		return nil
+	}
+	intn := rand.Intn
+	if rnd != nil {
+		intn = rnd.Intn
+	}
+
+	// A partial Fisher-Yates shuffle
+	// in which only the swapped positions
+	// are stored.
+	swapped := make(map[int]int, n)
+	at := func(i int) int {
+		if v, ok := swapped[i]; ok {
+			return v
+		}
+		return i
+	}
+
+	ids := make([]int, n)
+	for i := range ids {
+		j := i + intn(len(pix.pixels)-i)
+		ids[i] = at(j)
+		swapped[j] = at(i)
+	}
+	return ids
+}
+
 // Step returns the size of a pixel in degrees
 // at equator
 // or its latitude size.
@@ -167,6 +615,90 @@ func (pix *Pixelation) Step() float64 {
 	return pix.dStep
 }
 
+// StepRad returns the size of a pixel in radians
+// at equator
+// or its latitude size.
+func (pix *Pixelation) StepRad() float64 {
+	return ToRad(pix.dStep)
+}
+
+// Subdivide returns the IDs of the pixels
+// of a finer pixelation
+// that are inside each pixel of the pixelation.
+// The key of the map is the pixel ID
+// in the pixelation,
+// and the value are the IDs of the pixels
+// in the fine pixelation,
+// sorted by ID.
+//
+// Each fine pixel is assigned to the pixel
+// that contains its center,
+// so each fine pixel is found in a single list.
+// If the number of pixels at the equator
+// of the fine pixelation
+// is not an integer multiple
+// of the pixelation,
+// the number of fine pixels
+// assigned to each pixel will be more variable,
+// and if the resolutions are close,
+// some pixels might not have any fine pixel
+// (and they will be absent from the map).
+//
+// It panics if the number of pixels at the equator
+// of the fine pixelation
+// is not larger than the pixelation.
+func (pix *Pixelation) Subdivide(fine *Pixelation) map[int][]int {
+	if fine.Equator() <= pix.Equator() {
+		msg := fmt.Sprintf("fine pixelation equator %d: want larger than %d", fine.Equator(), pix.Equator())
+		panic(msg)
+	}
+
+	pts := make([]Point, fine.Len())
+	for i, px := range fine.pixels {
+		pts[i] = px.point
+	}
+
+	sub := make(map[int][]int)
+	for id, c := range pix.PixelSeq(pts) {
+		sub[c] = append(sub[c], id)
+	}
+	return sub
+}
+
+// TracePixels returns the IDs of the pixels
+// crossed by the great circle arc
+// between p and q,
+// in order from p to q.
+// Each pixel is returned only once,
+// and both the pixels of p and q
+// are included.
+//
+// The arc is sampled at a quarter of the pixel size,
+// so consecutive pixels are usually neighbors,
+// but a pixel that is only touched at a corner
+// might be skipped.
+func (pix *Pixelation) TracePixels(p, q Point) []int {
+	step := ToRad(pix.dStep) / 4
+
+	var ids []int
+	seen := make(map[int]bool)
+	for _, pt := range Waypoints(p, q, step) {
+		id := pix.getPixel(pt.lat, pt.lon).ID()
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// Valid returns true if an ID
+// is a valid pixel ID in the pixelation.
+func (pix *Pixelation) Valid(id int) bool {
+	return id >= 0 && id < len(pix.pixels)
+}
+
 // AddPixels adds pixels to a pixelation ring.
 func (pix *Pixelation) addPixels(r int) {
 	lat := 90 - float64(r)*pix.dStep
@@ -196,6 +728,10 @@ func (pix *Pixelation) addPixels(r int) {
 
 // Closest returns the closest pixel of a point
 // from a ring.
+// As pixels are visited by ID,
+// if the point is at the same distance
+// of two or more pixels,
+// the pixel with the lowest ID wins.
 func (pix *Pixelation) closest(ring int, pt Point) int {
 	if ring > 0 {
 		ring--
@@ -218,6 +754,10 @@ func (pix *Pixelation) closest(ring int, pt Point) int {
 
 // GetPixel returns a pixel from a latitude longitude pair.
 func (pix *Pixelation) getPixel(lat, lon float64) Pixel {
+	if pix.index == nil {
+		return pix.pixels[pix.search(lat, lon)]
+	}
+
 	pos := pix.indexPos(lat, lon)
 
 	pix.mu.RLock()
@@ -228,9 +768,7 @@ func (pix *Pixelation) getPixel(lat, lon float64) Pixel {
 		return pix.pixels[id]
 	}
 
-	pt := NewPoint(lat, lon)
-	ring := int(math.Round((90 - lat) / pix.dStep))
-	id = pix.closest(ring, pt)
+	id = pix.search(lat, lon)
 
 	pix.mu.Lock()
 	pix.index[pos] = id
@@ -239,22 +777,18 @@ func (pix *Pixelation) getPixel(lat, lon float64) Pixel {
 	return pix.pixels[id]
 }
 
+// Search returns the ID of the closest pixel
+// of a latitude longitude pair.
+func (pix *Pixelation) search(lat, lon float64) int {
+	pt := NewPoint(lat, lon)
+	ring := int(math.Round((90 - lat) / pix.dStep))
+	return pix.closest(ring, pt)
+}
+
 // IndexPos returns the position of a coordinate pair
 // in an index.
 func (pix *Pixelation) indexPos(lat, lon float64) int {
-	x := int((lon + 180) / pix.iStep)
-	if x == pix.cols {
-		// points at 180 longitude
-		// will set as -180 longitude
-		x = 0
-	}
-
-	y := int((90 - lat) / pix.iStep)
-	if y == pix.cols/2 {
-		// points at -90 latitude
-		// set to be a bit less than -90
-		y = pix.cols/2 - 1
-	}
+	x, y := PlateCarreeXY(lat, lon, pix.cols, pix.cols/2)
 	return y*pix.cols + x
 }
 
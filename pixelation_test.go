@@ -5,7 +5,10 @@
 package earth_test
 
 import (
+	"encoding/json"
 	"math"
+	"math/rand"
+	"slices"
 	"sync"
 	"testing"
 
@@ -40,6 +43,44 @@ func TestNewPixelation(t *testing.T) {
 	}
 }
 
+func TestNewPixelationErr(t *testing.T) {
+	tests := map[string]struct {
+		eq   int
+		want int
+		err  bool
+	}{
+		"zero":     {eq: 0, err: true},
+		"negative": {eq: -4, err: true},
+		"odd":      {eq: 361, want: 362},
+		"even":     {eq: 36, want: 36},
+	}
+
+	for name, test := range tests {
+		pix, err := earth.NewPixelationErr(test.eq)
+		if test.err {
+			if err == nil {
+				t.Errorf("%s: expecting error", name)
+			}
+			func() {
+				defer func() {
+					if r := recover(); r == nil {
+						t.Errorf("%s: NewPixelation: expecting panic", name)
+					}
+				}()
+				earth.NewPixelation(test.eq)
+			}()
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+			continue
+		}
+		if eq := pix.Equator(); eq != test.want {
+			t.Errorf("%s: got %d pixels at equator, want %d", name, eq, test.want)
+		}
+	}
+}
+
 func TestPixelationPixel(t *testing.T) {
 	tests := map[string]struct {
 		lat, lon float64
@@ -64,6 +105,132 @@ func TestPixelationPixel(t *testing.T) {
 	}
 }
 
+func TestPixelationNoIndex(t *testing.T) {
+	tests := map[string]struct {
+		lat, lon float64
+		id       int
+		ring     int
+	}{
+		"Tucumán":    {lat: -26, lon: -65, id: 29611, ring: 116},
+		"North pole": {lat: 90, lon: 180},
+		"South pole": {lat: -90, lon: -180, id: 41257, ring: 180},
+		"Quito":      {lat: 0, lon: -78, id: 20551, ring: 90},
+		"London":     {lat: 51, lon: 0, id: 4597, ring: 39},
+		"Tokyo":      {lat: 35, lon: 139, id: 8912, ring: 55},
+		"Anchorage":  {lat: 61, lon: -149, id: 2514, ring: 29},
+	}
+
+	eq := 360
+	pix := earth.NewPixelationNoIndex(eq)
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			pixHelper(t, pix, test.lat, test.lon, test.id, test.ring)
+		})
+	}
+
+	// pixel centers
+	for id := 0; id < pix.Len(); id += 97 {
+		pt := pix.ID(id).Point()
+		if got := pix.Pixel(pt.Latitude(), pt.Longitude()).ID(); got != id {
+			t.Errorf("pixel %d: got %d", id, got)
+		}
+	}
+
+	if b := pix.IndexBytes(); b != 0 {
+		t.Errorf("index bytes: got %d, want 0", b)
+	}
+	if b := earth.NewPixelation(eq).IndexBytes(); b < 3600*1800 {
+		t.Errorf("index bytes: got %d, want at least %d", b, 3600*1800)
+	}
+}
+
+func TestPixelationPixelTie(t *testing.T) {
+	pix := earth.NewPixelationNoIndex(360)
+	idx := earth.NewPixelation(360)
+
+	// points at longitude 0
+	// in rings without a pixel at the meridian,
+	// so the closest pixels are at the same distance
+	var ties int
+	for r := 1; r < pix.Rings()-1; r++ {
+		lons := make(map[float64]int)
+		for _, id := range pix.RingPixels(r) {
+			lons[pix.ID(id).Point().Longitude()] = id
+		}
+		if _, ok := lons[0]; ok {
+			continue
+		}
+
+		west, east := -1, -1
+		closest := 360.0
+		for lon, id := range lons {
+			e, ok := lons[-lon]
+			if lon >= 0 || !ok || -lon > closest {
+				continue
+			}
+			west, east = id, e
+			closest = -lon
+		}
+		if west < 0 {
+			continue
+		}
+
+		pt := earth.NewPoint(pix.ID(west).Point().Latitude(), 0)
+		c2 := earth.Chord2(pt, pix.ID(west).Point())
+		if c2 != earth.Chord2(pt, pix.ID(east).Point()) {
+			continue
+		}
+
+		// near the poles,
+		// a pixel in a neighbor ring
+		// can be closer
+		closer := false
+		for _, nr := range []int{r - 1, r + 1} {
+			for _, id := range pix.RingPixels(nr) {
+				if earth.Chord2(pt, pix.ID(id).Point()) <= c2 {
+					closer = true
+				}
+			}
+		}
+		if closer {
+			continue
+		}
+		ties++
+
+		want := min(west, east)
+		for i := 0; i < 3; i++ {
+			if got := pix.Pixel(pt.Latitude(), pt.Longitude()).ID(); got != want {
+				t.Errorf("ring %d: got pixel %d, want %d", r, got, want)
+			}
+		}
+		if got := idx.Pixel(pt.Latitude(), pt.Longitude()).ID(); got != want {
+			t.Errorf("ring %d: index: got pixel %d, want %d", r, got, want)
+		}
+
+		// a point closer to the east pixel
+		// in the same index cell
+		// (index cells are a tenth of a pixel wide)
+		// is searched before the tie point
+		near := 180 / float64(10*pix.Equator())
+		cell := earth.NewPixelation(360)
+		if got := cell.Pixel(pt.Latitude(), near).ID(); got != east {
+			t.Fatalf("ring %d: point at %.6f: got pixel %d, want %d", r, near, got, east)
+		}
+		if got := cell.Pixel(pt.Latitude(), pt.Longitude()).ID(); got != west && got != east {
+			t.Errorf("ring %d: populated index: got pixel %d, want %d or %d", r, got, west, east)
+		}
+		if got := pix.Pixel(pt.Latitude(), near).ID(); got != east {
+			t.Fatalf("ring %d: no index: point at %.6f: got pixel %d, want %d", r, near, got, east)
+		}
+		if got := pix.Pixel(pt.Latitude(), pt.Longitude()).ID(); got != want {
+			t.Errorf("ring %d: no index: after a near point: got pixel %d, want %d", r, got, want)
+		}
+	}
+	if ties == 0 {
+		t.Fatalf("no equidistant points found")
+	}
+}
+
 func TestPixelationRandom(t *testing.T) {
 	eq := 360
 	pix := earth.NewPixelation(eq)
@@ -149,6 +316,122 @@ func vecHelper(t testing.TB, pix *earth.Pixelation, pt earth.Point, id int) {
 	}
 }
 
+func TestPixelationDescribe(t *testing.T) {
+	for _, eq := range []int{36, 360} {
+		pix := earth.NewPixelation(eq)
+		info := pix.Describe()
+		if info.Equator != eq {
+			t.Errorf("equator %d: equator: got %d, want %d", eq, info.Equator, eq)
+		}
+		if info.Pixels != pix.Len() {
+			t.Errorf("equator %d: pixels: got %d, want %d", eq, info.Pixels, pix.Len())
+		}
+		if info.Rings != pix.Rings() {
+			t.Errorf("equator %d: rings: got %d, want %d", eq, info.Rings, pix.Rings())
+		}
+		if info.Step != pix.Step() {
+			t.Errorf("equator %d: step: got %.6f, want %.6f", eq, info.Step, pix.Step())
+		}
+
+		// pixel areas
+		if a := info.PixelArea * float64(info.Pixels); math.Abs(a-4*math.Pi) > 1e-9 {
+			t.Errorf("equator %d: area: got %.6f, want %.6f", eq, a, 4*math.Pi)
+		}
+		var sum float64
+		for r := 0; r < pix.Rings(); r++ {
+			sum += pix.RingArea(r)
+		}
+		if math.Abs(sum-info.PixelArea*float64(info.Pixels)) > 1e-6 {
+			t.Errorf("equator %d: ring area: got %.6f, want %.6f", eq, sum, info.PixelArea*float64(info.Pixels))
+		}
+
+		b, err := json.Marshal(info)
+		if err != nil {
+			t.Fatalf("equator %d: unable to encode: %v", eq, err)
+		}
+		var got earth.PixelationInfo
+		if err := json.Unmarshal(b, &got); err != nil {
+			t.Fatalf("equator %d: unable to decode: %v", eq, err)
+		}
+		if got != info {
+			t.Errorf("equator %d: json: got %v, want %v", eq, got, info)
+		}
+	}
+}
+
+func TestPixelationPixelSeq(t *testing.T) {
+	pts := densePolygon(earth.NewPoint(-26, -65), 0.3, 5_000)
+	for i := 0; i < 1_000; i++ {
+		pts = append(pts, earth.NewPoint(rand.Float64()*180-90, rand.Float64()*360-180))
+	}
+
+	for _, pix := range []*earth.Pixelation{
+		earth.NewPixelation(360),
+		earth.NewPixelationNoIndex(360),
+	} {
+		// compare with individual lookups
+		// in a fresh pixelation,
+		// so the index is filled by PixelSeq
+		want := make([]int, len(pts))
+		ref := earth.NewPixelation(360)
+		if pix.IndexBytes() == 0 {
+			ref = earth.NewPixelationNoIndex(360)
+		}
+		for i, pt := range pts {
+			want[i] = ref.Pixel(pt.Latitude(), pt.Longitude()).ID()
+		}
+
+		if got := pix.PixelSeq(pts); !slices.Equal(got, want) {
+			t.Errorf("pixel sequence: got %v, want %v", got, want)
+		}
+		// using the filled index
+		if got := pix.PixelSeq(pts); !slices.Equal(got, want) {
+			t.Errorf("pixel sequence (filled index): got %v, want %v", got, want)
+		}
+		for i, pt := range pts {
+			if id := pix.Pixel(pt.Latitude(), pt.Longitude()).ID(); id != want[i] {
+				t.Errorf("point %d: got %d, want %d", i, id, want[i])
+			}
+		}
+	}
+}
+
+// DensePolygon returns the vertices of a dense polygon
+// around a point.
+func densePolygon(center earth.Point, dist float64, n int) []earth.Point {
+	pts := make([]earth.Point, 0, n)
+	for i := 0; i < n; i++ {
+		b := 2 * math.Pi * float64(i) / float64(n)
+		// a wavy border
+		d := dist * (1 + 0.2*math.Sin(17*b))
+		pts = append(pts, earth.Destination(center, d, b))
+	}
+	return pts
+}
+
+func BenchmarkPixelationPixel(b *testing.B) {
+	pix := earth.NewPixelation(360)
+	pts := densePolygon(earth.NewPoint(-26, -65), 0.3, 10_000)
+	ids := make([]int, len(pts))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j, pt := range pts {
+			ids[j] = pix.Pixel(pt.Latitude(), pt.Longitude()).ID()
+		}
+	}
+}
+
+func BenchmarkPixelationPixelSeq(b *testing.B) {
+	pix := earth.NewPixelation(360)
+	pts := densePolygon(earth.NewPoint(-26, -65), 0.3, 10_000)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pix.PixelSeq(pts)
+	}
+}
+
 func TestPixelLocationRace(t *testing.T) {
 	eq := 360
 	pix := earth.NewPixelation(eq)
@@ -230,6 +513,62 @@ func TestPixelationRings(t *testing.T) {
 	}
 }
 
+func TestPixelationRingLats(t *testing.T) {
+	pix := earth.NewPixelation(360)
+
+	lats := pix.RingLats()
+	if len(lats) != pix.Rings() {
+		t.Fatalf("got %d rings, want %d", len(lats), pix.Rings())
+	}
+	if math.Abs(lats[0]-90) > 1e-6 {
+		t.Errorf("first ring: got %.6f, want %.6f", lats[0], 90.0)
+	}
+	if last := lats[len(lats)-1]; math.Abs(last+90) > 1e-6 {
+		t.Errorf("last ring: got %.6f, want %.6f", last, -90.0)
+	}
+	for r, lat := range lats {
+		if lat != pix.RingLat(r) {
+			t.Errorf("ring %d: got %.6f, want %.6f", r, lat, pix.RingLat(r))
+		}
+	}
+
+	if step := pix.StepRad(); math.Abs(step-earth.ToRad(pix.Step())) > 1e-12 {
+		t.Errorf("step: got %.6f, want %.6f", step, earth.ToRad(pix.Step()))
+	}
+}
+
+func TestPixelationRingPixels(t *testing.T) {
+	pix := earth.NewPixelation(360)
+
+	var ids []int
+	for r := 0; r < pix.Rings(); r++ {
+		first, count := pix.Ring(r)
+		if first != pix.FirstPix(r).ID() || count != pix.PixPerRing(r) {
+			t.Errorf("ring %d: got %d, %d, want %d, %d", r, first, count, pix.FirstPix(r).ID(), pix.PixPerRing(r))
+		}
+
+		rp := pix.RingPixels(r)
+		if len(rp) != count {
+			t.Errorf("ring %d: got %d pixels, want %d", r, len(rp), count)
+		}
+		for _, id := range rp {
+			if px := pix.ID(id); px.Ring() != r {
+				t.Errorf("ring %d: pixel %d: got ring %d", r, id, px.Ring())
+			}
+		}
+		ids = append(ids, rp...)
+	}
+
+	if len(ids) != pix.Len() {
+		t.Fatalf("got %d pixels, want %d", len(ids), pix.Len())
+	}
+	for i, id := range ids {
+		if id != i {
+			t.Fatalf("pixel %d: got %d", i, id)
+		}
+	}
+}
+
 func TestRandInRing(t *testing.T) {
 	eq := 360
 	pix := earth.NewPixelation(eq)
@@ -243,3 +582,308 @@ func TestRandInRing(t *testing.T) {
 		}
 	}
 }
+
+func TestPixelationArea(t *testing.T) {
+	pix := earth.NewPixelation(360)
+
+	var sum float64
+	for r := 0; r < pix.Rings(); r++ {
+		ra := pix.RingArea(r)
+		sum += ra
+
+		pa := pix.PixelArea(r)
+		if got := pa * float64(pix.PixPerRing(r)); math.Abs(got-ra) > 1e-12 {
+			t.Errorf("ring %d: pixel area %.6f, ring area %.6f", r, got, ra)
+		}
+	}
+	if math.Abs(sum-4*math.Pi) > 1e-9 {
+		t.Errorf("sphere area: got %.6f, want %.6f", sum, 4*math.Pi)
+	}
+
+	// pixels at the equator
+	// should be close to the ideal area
+	step := earth.ToRad(pix.Step())
+	eqRing := pix.Rings() / 2
+	if pa := pix.PixelArea(eqRing); math.Abs(pa-step*step)/(step*step) > 0.01 {
+		t.Errorf("equatorial pixel area: got %.8f, want %.8f", pa, step*step)
+	}
+}
+
+func TestPixelationValid(t *testing.T) {
+	pix := earth.NewPixelation(36)
+
+	tests := map[int]bool{
+		-1:            false,
+		0:             true,
+		200:           true,
+		pix.Len() - 1: true,
+		pix.Len():     false,
+		pix.Len() + 1: false,
+	}
+	for id, want := range tests {
+		if got := pix.Valid(id); got != want {
+			t.Errorf("valid %d: got %v, want %v", id, got, want)
+		}
+		if px, ok := pix.IDOk(id); ok != want || (ok && px.ID() != id) {
+			t.Errorf("ID ok %d: got %d, %v, want %d, %v", id, px.ID(), ok, id, want)
+		}
+		if want {
+			if px := pix.MustID(id); px.ID() != id {
+				t.Errorf("must ID %d: got %d", id, px.ID())
+			}
+			continue
+		}
+		mustIDPanics(t, pix, id)
+	}
+}
+
+func mustIDPanics(t testing.TB, pix *earth.Pixelation, id int) {
+	t.Helper()
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("must ID %d: expecting panic", id)
+		}
+	}()
+	pix.MustID(id)
+}
+
+func TestPixelationRandInCap(t *testing.T) {
+	pix := earth.NewPixelation(360)
+	center := earth.NewPoint(-26, -65)
+	dist := earth.ToRad(5)
+
+	inCap := make(map[int]bool)
+	for _, id := range pix.PixelsInCap(center, dist) {
+		if d := earth.Distance(center, pix.ID(id).Point()); d > dist {
+			t.Errorf("pixel %d: distance %.6f outside the cap", id, d)
+		}
+		inCap[id] = true
+	}
+	if len(inCap) == 0 {
+		t.Fatalf("cap without pixels")
+	}
+
+	rnd := rand.New(rand.NewSource(1))
+	seen := make(map[int]bool)
+	for i := 0; i < 1000; i++ {
+		px, ok := pix.RandInCap(center, dist, rnd)
+		if !ok {
+			t.Fatalf("draw %d: no pixel", i)
+		}
+		if !inCap[px.ID()] {
+			t.Errorf("draw %d: pixel %d outside the cap", i, px.ID())
+		}
+		seen[px.ID()] = true
+	}
+	if len(seen) < 2 {
+		t.Errorf("got %d different pixels", len(seen))
+	}
+
+	// reproducible draws
+	a, _ := pix.RandInCap(center, dist, rand.New(rand.NewSource(7)))
+	b, _ := pix.RandInCap(center, dist, rand.New(rand.NewSource(7)))
+	if a.ID() != b.ID() {
+		t.Errorf("same source: got %d and %d", a.ID(), b.ID())
+	}
+
+	if _, ok := pix.RandInCap(center, -1, rnd); ok {
+		t.Errorf("empty cap: got a pixel")
+	}
+}
+
+func TestPixelationSample(t *testing.T) {
+	pix := earth.NewPixelation(36)
+
+	for _, n := range []int{0, 1, 10, 200, pix.Len(), pix.Len() + 10} {
+		ids := pix.Sample(n, rand.New(rand.NewSource(int64(n))))
+		want := min(n, pix.Len())
+		if len(ids) != want {
+			t.Errorf("sample %d: got %d pixels, want %d", n, len(ids), want)
+		}
+
+		used := make(map[int]bool, len(ids))
+		for _, id := range ids {
+			if !pix.Valid(id) {
+				t.Errorf("sample %d: invalid pixel %d", n, id)
+			}
+			if used[id] {
+				t.Errorf("sample %d: duplicated pixel %d", n, id)
+			}
+			used[id] = true
+		}
+	}
+
+	// same source, same sample
+	s1 := pix.Sample(50, rand.New(rand.NewSource(1)))
+	s2 := pix.Sample(50, rand.New(rand.NewSource(1)))
+	if !slices.Equal(s1, s2) {
+		t.Errorf("sample: got %v, want %v", s2, s1)
+	}
+}
+
+func TestPixelationSubdivide(t *testing.T) {
+	pix := earth.NewPixelation(120)
+	for _, eq := range []int{240, 300} {
+		fine := earth.NewPixelation(eq)
+		sub := pix.Subdivide(fine)
+
+		var all []int
+		for id, fs := range sub {
+			if !pix.Valid(id) {
+				t.Errorf("equator %d: invalid pixel %d", eq, id)
+			}
+			if !slices.IsSorted(fs) {
+				t.Errorf("equator %d: pixel %d: unsorted fine pixels", eq, id)
+			}
+			for _, f := range fs {
+				pt := fine.ID(f).Point()
+				if c := pix.Pixel(pt.Latitude(), pt.Longitude()).ID(); c != id {
+					t.Errorf("equator %d: fine pixel %d: got pixel %d, want %d", eq, f, id, c)
+				}
+			}
+			all = append(all, fs...)
+		}
+		slices.Sort(all)
+		if len(all) != fine.Len() {
+			t.Fatalf("equator %d: got %d fine pixels, want %d", eq, len(all), fine.Len())
+		}
+		for i, f := range all {
+			if f != i {
+				t.Fatalf("equator %d: got fine pixel %d, want %d", eq, f, i)
+			}
+		}
+	}
+
+	if sub := pix.Subdivide(earth.NewPixelation(240)); len(sub) != pix.Len() {
+		t.Errorf("equator 240: got %d pixels, want %d", len(sub), pix.Len())
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("coarse pixelation: expecting panic")
+		}
+	}()
+	pix.Subdivide(earth.NewPixelation(60))
+}
+
+func TestPixelationTracePixels(t *testing.T) {
+	pix := earth.NewPixelation(360)
+
+	tests := map[string]struct {
+		p, q earth.Point
+	}{
+		"equator": {
+			p: earth.NewPoint(0, -10),
+			q: earth.NewPoint(0, 10),
+		},
+		"polar": {
+			p: earth.NewPoint(80, -30),
+			q: earth.NewPoint(75, 150),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ids := pix.TracePixels(test.p, test.q)
+			first := pix.Pixel(test.p.Latitude(), test.p.Longitude()).ID()
+			last := pix.Pixel(test.q.Latitude(), test.q.Longitude()).ID()
+			if ids[0] != first {
+				t.Errorf("first pixel: got %d, want %d", ids[0], first)
+			}
+			if ids[len(ids)-1] != last {
+				t.Errorf("last pixel: got %d, want %d", ids[len(ids)-1], last)
+			}
+
+			seen := make(map[int]bool)
+			maxDist := 2 * earth.ToRad(pix.Step())
+			for i, id := range ids {
+				if seen[id] {
+					t.Errorf("pixel %d: duplicated", id)
+				}
+				seen[id] = true
+				if i == 0 {
+					continue
+				}
+				if d := earth.Distance(pix.ID(ids[i-1]).Point(), pix.ID(id).Point()); d > maxDist {
+					t.Errorf("pixels %d-%d: distance %.6f, want < %.6f", ids[i-1], id, d, maxDist)
+				}
+			}
+		})
+	}
+
+	// the equator trace goes through the pixels of the equator
+	ids := pix.TracePixels(earth.NewPoint(0, -10), earth.NewPoint(0, 10))
+	if len(ids) != 21 {
+		t.Errorf("equator: got %d pixels, want %d", len(ids), 21)
+	}
+	for _, id := range ids {
+		if r := pix.ID(id).Ring(); r != pix.Rings()/2 {
+			t.Errorf("equator: pixel %d at ring %d, want %d", id, r, pix.Rings()/2)
+		}
+	}
+}
+
+func TestPixelationNeighbors(t *testing.T) {
+	pix := earth.NewPixelation(36)
+
+	// the north pole is neighbor of all pixels of the first ring
+	first, count := pix.Ring(1)
+	if nb := pix.Neighbors(0); len(nb) != count || nb[0] != first {
+		t.Errorf("north pole: got %v, want all pixels of ring 1", nb)
+	}
+
+	for id := 0; id < pix.Len(); id++ {
+		nb := pix.Neighbors(id)
+		if len(nb) < 3 && pix.ID(id).Ring() != 0 && pix.ID(id).Ring() != pix.Rings()-1 {
+			t.Errorf("pixel %d: got %d neighbors %v", id, len(nb), nb)
+		}
+		for _, n := range nb {
+			if n == id {
+				t.Errorf("pixel %d: pixel is its own neighbor", id)
+			}
+			if !slices.Contains(pix.Neighbors(n), id) {
+				t.Errorf("pixel %d: neighbor %d: relation is not symmetric", id, n)
+			}
+			if r := pix.ID(n).Ring() - pix.ID(id).Ring(); r < -1 || r > 1 {
+				t.Errorf("pixel %d: neighbor %d: not in an adjacent ring", id, n)
+			}
+		}
+	}
+
+	// first pixel of a ring
+	// centered at the antimeridian
+	want := []int{119, 152, 154, 187, 188, 223}
+	if nb := pix.Neighbors(153); !slices.Equal(nb, want) {
+		t.Errorf("pixel %d: got %v, want %v", 153, nb, want)
+	}
+}
+
+func TestPixelationPixelBox(t *testing.T) {
+	pix := earth.NewPixelation(36)
+
+	want := earth.Box{North: 90, South: 85, West: -180, East: 180}
+	if b := pix.PixelBox(0); b != want {
+		t.Errorf("north pole: got %v, want %v", b, want)
+	}
+
+	// first pixel of a ring
+	// centered at the antimeridian
+	b := pix.PixelBox(153)
+	if b.North != 15 || b.South != 5 {
+		t.Errorf("pixel %d: got latitudes %.3f, %.3f, want %.3f, %.3f", 153, b.North, b.South, 15.0, 5.0)
+	}
+	if b.West <= b.East {
+		t.Errorf("pixel %d: got %v, want a box crossing the antimeridian", 153, b)
+	}
+	if !b.Contains(10, 180) || !b.Contains(10, -178) || b.Contains(10, -170) {
+		t.Errorf("pixel %d: got %v, want a box from 174.857 to -174.857", 153, b)
+	}
+
+	for id := 0; id < pix.Len(); id++ {
+		pt := pix.ID(id).Point()
+		if !pix.PixelBox(id).Contains(pt.Latitude(), pt.Longitude()) {
+			t.Errorf("pixel %d: center outside of the cell", id)
+		}
+	}
+}
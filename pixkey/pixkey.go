@@ -0,0 +1,264 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package pixkey implements a key
+// to associate pixel values
+// with colors and labels.
+package pixkey
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/js-arias/blind"
+)
+
+// A PixKey is a set of colors and labels
+// associated with the values of a pixel.
+type PixKey struct {
+	color map[int]color.RGBA
+	label map[int]string
+}
+
+// New creates a new empty key.
+func New() *PixKey {
+	return &PixKey{
+		color: make(map[int]color.RGBA),
+		label: make(map[int]string),
+	}
+}
+
+// Color returns the color associated with a pixel value.
+// It returns false if the value has no color defined.
+func (pk *PixKey) Color(v int) (color.RGBA, bool) {
+	c, ok := pk.color[v]
+	return c, ok
+}
+
+// GoldenRatio is the fractional part of the golden ratio.
+const goldenRatio = 0.6180339887498949
+
+// ColorFor returns a color for an integer ID
+// (for example a plate ID or a pixel value).
+// The color is always the same for a given ID,
+// so it can be used to produce reproducible images.
+//
+// Colors are taken from the Iridescent color scheme
+// using a golden ratio rotation,
+// so consecutive IDs will have well separated colors.
+func ColorFor(id int) color.RGBA {
+	t := math.Mod(float64(id)*goldenRatio, 1)
+	if t < 0 {
+		t += 1
+	}
+	return blind.Sequential(blind.Iridescent, t)
+}
+
+// Keys returns the values defined in the key.
+func (pk *PixKey) Keys() []int {
+	keys := make([]int, 0, len(pk.color))
+	for k := range pk.color {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// Label returns the label associated with a pixel value.
+func (pk *PixKey) Label(v int) string {
+	return pk.label[v]
+}
+
+// Nearest returns the pixel value
+// with the color closest to a given color.
+// The distance between colors
+// is the Euclidean distance
+// between their non-premultiplied
+// red, green, and blue values
+// (the alpha value is ignored).
+// If two values have colors at the same distance,
+// the smallest value is returned.
+//
+// It returns false if the key is empty,
+// or if the distance to the closest color
+// is larger than maxDist.
+// If maxDist is negative,
+// any distance is accepted.
+func (pk *PixKey) Nearest(c color.Color, maxDist float64) (int, bool) {
+	nc := color.NRGBAModel.Convert(c).(color.NRGBA)
+	best := 0
+	bestDist := math.Inf(1)
+	for _, k := range pk.Keys() {
+		kc := pk.color[k]
+		dr := float64(nc.R) - float64(kc.R)
+		dg := float64(nc.G) - float64(kc.G)
+		db := float64(nc.B) - float64(kc.B)
+		d := math.Sqrt(dr*dr + dg*dg + db*db)
+		if d < bestDist {
+			best = k
+			bestDist = d
+		}
+	}
+	if math.IsInf(bestDist, 1) {
+		return 0, false
+	}
+	if maxDist >= 0 && bestDist > maxDist {
+		return 0, false
+	}
+	return best, true
+}
+
+// NearestKey returns the pixel value
+// with the color closest to a given color,
+// using the Euclidean distance
+// between their non-premultiplied
+// red, green, and blue values.
+// If two values have colors at the same distance,
+// the smallest value is returned.
+// If the key is empty,
+// it returns 0.
+//
+// To reject colors far from any color in the key,
+// use Nearest.
+func (pk *PixKey) NearestKey(c color.Color) int {
+	v, _ := pk.Nearest(c, math.Inf(1))
+	return v
+}
+
+// SetColor sets the color of a pixel value.
+func (pk *PixKey) SetColor(c color.RGBA, v int) {
+	pk.color[v] = c
+}
+
+// SetLabel sets the label of a pixel value.
+func (pk *PixKey) SetLabel(v int, label string) {
+	pk.label[v] = label
+}
+
+var keyHeader = []string{
+	"key",
+	"color",
+}
+
+// Read reads a key from a TSV file.
+//
+// The TSV file must have the following columns:
+//
+//   - key, the pixel value used as identifier
+//   - color, an RGB value separated by commas,
+//     for example "125,132,148"
+//
+// Optionally,
+// it can include the following fields:
+//
+//   - label, a label for the pixel value
+//
+// Any other column will be ignored.
+// Here is an example file:
+//
+//	key	color	label
+//	0	54, 75, 154	deep ocean
+//	1	74, 123, 183	oceanic plateaus
+//	2	152, 202, 225	continental shelf
+//	3	254, 218, 139	lowlands
+//	4	246, 126, 75	highlands
+//	5	231, 231, 231	ice sheets
+func Read(r io.Reader) (*PixKey, error) {
+	tab := csv.NewReader(r)
+	tab.Comma = '\t'
+	tab.Comment = '#'
+
+	head, err := tab.Read()
+	if err != nil {
+		return nil, fmt.Errorf("while reading header: %v", err)
+	}
+	fields := make(map[string]int, len(head))
+	for i, h := range head {
+		h = strings.ToLower(h)
+		fields[h] = i
+	}
+	for _, h := range keyHeader {
+		if _, ok := fields[h]; !ok {
+			return nil, fmt.Errorf("expecting field %q", h)
+		}
+	}
+
+	pk := New()
+	for {
+		row, err := tab.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		ln, _ := tab.FieldPos(0)
+		if err != nil {
+			return nil, fmt.Errorf("on row %d: %v", ln, err)
+		}
+
+		f := "key"
+		k, err := strconv.Atoi(row[fields[f]])
+		if err != nil {
+			return nil, fmt.Errorf("on row %d: field %q: %v", ln, f, err)
+		}
+
+		f = "color"
+		c, err := ParseColor(row[fields[f]])
+		if err != nil {
+			return nil, fmt.Errorf("on row %d: field %q: %v", ln, f, err)
+		}
+		pk.color[k] = c
+
+		f = "label"
+		if _, ok := fields[f]; ok {
+			pk.label[k] = strings.TrimSpace(row[fields[f]])
+		}
+	}
+	if len(pk.color) == 0 {
+		return nil, fmt.Errorf("while reading data: %v", io.EOF)
+	}
+	return pk, nil
+}
+
+// ParseColor returns a color
+// from a string with RGB values separated by commas,
+// for example "125,132,148".
+// Each value must be between 0 and 255.
+func ParseColor(s string) (color.RGBA, error) {
+	vals := strings.Split(s, ",")
+	if len(vals) != 3 {
+		return color.RGBA{}, fmt.Errorf("found %d values", len(vals))
+	}
+
+	red, err := strconv.Atoi(strings.TrimSpace(vals[0]))
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("red value: %v", err)
+	}
+	if red < 0 || red > 255 {
+		return color.RGBA{}, fmt.Errorf("red value: invalid value %d", red)
+	}
+
+	green, err := strconv.Atoi(strings.TrimSpace(vals[1]))
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("green value: %v", err)
+	}
+	if green < 0 || green > 255 {
+		return color.RGBA{}, fmt.Errorf("green value: invalid value %d", green)
+	}
+
+	blue, err := strconv.Atoi(strings.TrimSpace(vals[2]))
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("blue value: %v", err)
+	}
+	if blue < 0 || blue > 255 {
+		return color.RGBA{}, fmt.Errorf("blue value: invalid value %d", blue)
+	}
+
+	return color.RGBA{uint8(red), uint8(green), uint8(blue), 255}, nil
+}
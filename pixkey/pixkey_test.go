@@ -0,0 +1,194 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package pixkey_test
+
+import (
+	"image/color"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/js-arias/earth/pixkey"
+)
+
+const keyFile = `# pixel key
+key	color	gray	label
+0	54, 75, 154	255	deep ocean
+1	74, 123, 183	235	oceanic plateaus
+2	152, 202, 225	225	continental shelf
+3	254, 218, 139	195	lowlands
+`
+
+func TestRead(t *testing.T) {
+	pk, err := pixkey.Read(strings.NewReader(keyFile))
+	if err != nil {
+		t.Fatalf("while reading data: %v", err)
+	}
+
+	keys := []int{0, 1, 2, 3}
+	if k := pk.Keys(); !reflect.DeepEqual(k, keys) {
+		t.Errorf("keys: got %v, want %v", k, keys)
+	}
+
+	tests := map[int]struct {
+		color color.RGBA
+		label string
+	}{
+		0: {color.RGBA{54, 75, 154, 255}, "deep ocean"},
+		1: {color.RGBA{74, 123, 183, 255}, "oceanic plateaus"},
+		2: {color.RGBA{152, 202, 225, 255}, "continental shelf"},
+		3: {color.RGBA{254, 218, 139, 255}, "lowlands"},
+	}
+	for k, want := range tests {
+		c, ok := pk.Color(k)
+		if !ok {
+			t.Errorf("key %d: color not found", k)
+		}
+		if c != want.color {
+			t.Errorf("key %d: got color %v, want %v", k, c, want.color)
+		}
+		if l := pk.Label(k); l != want.label {
+			t.Errorf("key %d: got label %q, want %q", k, l, want.label)
+		}
+	}
+
+	if _, ok := pk.Color(10); ok {
+		t.Errorf("key %d: color found", 10)
+	}
+}
+
+func TestReadError(t *testing.T) {
+	tests := map[string]string{
+		"no color":    "key\tlabel\n1\tocean\n",
+		"bad color":   "key\tcolor\n1\t25,25\n",
+		"large color": "key\tcolor\n1\t25,256,25\n",
+		"bad key":     "key\tcolor\nx\t25,25,25\n",
+		"empty":       "key\tcolor\n",
+	}
+	for name, data := range tests {
+		if _, err := pixkey.Read(strings.NewReader(data)); err == nil {
+			t.Errorf("%s: expecting error", name)
+		}
+	}
+}
+
+func TestColorFor(t *testing.T) {
+	for id := -5; id < 100; id++ {
+		c := pixkey.ColorFor(id)
+		if c2 := pixkey.ColorFor(id); c != c2 {
+			t.Errorf("id %d: got %v and %v, want same color", id, c, c2)
+		}
+	}
+
+	// nearby IDs should have well separated colors
+	for id := 0; id < 100; id++ {
+		c1 := pixkey.ColorFor(id)
+		c2 := pixkey.ColorFor(id + 1)
+		dr := int(c1.R) - int(c2.R)
+		dg := int(c1.G) - int(c2.G)
+		db := int(c1.B) - int(c2.B)
+		if d := dr*dr + dg*dg + db*db; d < 30*30 {
+			t.Errorf("id %d: color %v too close to %v", id, c1, c2)
+		}
+	}
+}
+
+func TestNearest(t *testing.T) {
+	pk, err := pixkey.Read(strings.NewReader(keyFile))
+	if err != nil {
+		t.Fatalf("while reading data: %v", err)
+	}
+
+	tests := map[string]struct {
+		c       color.Color
+		maxDist float64
+		want    int
+		ok      bool
+	}{
+		"exact":       {color.RGBA{152, 202, 225, 255}, 0, 2, true},
+		"close":       {color.RGBA{250, 220, 130, 255}, 20, 3, true},
+		"no limit":    {color.RGBA{255, 255, 255, 255}, -1, 2, true},
+		"too far":     {color.RGBA{255, 255, 255, 255}, 20, 0, false},
+		"alpha":       {color.NRGBA{54, 75, 154, 0}, 0, 0, true},
+		"dark color":  {color.RGBA{60, 80, 150, 255}, 10, 0, true},
+		"translucent": {color.NRGBA{152, 202, 225, 128}, 0, 2, true},
+		"gray":        {color.Gray{Y: 240}, -1, 2, true},
+	}
+	for name, test := range tests {
+		v, ok := pk.Nearest(test.c, test.maxDist)
+		if ok != test.ok {
+			t.Errorf("%s: got %v, want %v", name, ok, test.ok)
+		}
+		if v != test.want {
+			t.Errorf("%s: got value %d, want %d", name, v, test.want)
+		}
+	}
+
+	if _, ok := pixkey.New().Nearest(color.RGBA{0, 0, 0, 255}, -1); ok {
+		t.Errorf("empty key: got a value")
+	}
+}
+
+func TestNearestKey(t *testing.T) {
+	pk, err := pixkey.Read(strings.NewReader(keyFile))
+	if err != nil {
+		t.Fatalf("while reading data: %v", err)
+	}
+
+	for _, k := range pk.Keys() {
+		c, _ := pk.Color(k)
+		if v := pk.NearestKey(c); v != k {
+			t.Errorf("key %d: got %d", k, v)
+		}
+	}
+
+	if v := pk.NearestKey(color.Gray{Y: 240}); v != 2 {
+		t.Errorf("gray: got %d, want %d", v, 2)
+	}
+	if v := pk.NearestKey(color.NRGBA{152, 202, 225, 128}); v != 2 {
+		t.Errorf("translucent: got %d, want %d", v, 2)
+	}
+	if v := pixkey.New().NearestKey(color.Black); v != 0 {
+		t.Errorf("empty key: got %d, want %d", v, 0)
+	}
+
+	// ties resolve to the lowest key
+	pk.SetColor(color.RGBA{0, 0, 0, 255}, 10)
+	pk.SetColor(color.RGBA{0, 0, 20, 255}, 11)
+	if v := pk.NearestKey(color.RGBA{0, 0, 10, 255}); v != 10 {
+		t.Errorf("tie: got %d, want %d", v, 10)
+	}
+}
+
+func TestParseColor(t *testing.T) {
+	tests := map[string]struct {
+		in   string
+		want color.RGBA
+		err  bool
+	}{
+		"gray":         {in: "153,153,153", want: color.RGBA{153, 153, 153, 255}},
+		"spaces":       {in: "54, 75, 154", want: color.RGBA{54, 75, 154, 255}},
+		"few values":   {in: "54,75", err: true},
+		"invalid":      {in: "54,blue,154", err: true},
+		"out of range": {in: "54,75,256", err: true},
+		"negative":     {in: "-1,75,154", err: true},
+	}
+	for name, test := range tests {
+		c, err := pixkey.ParseColor(test.in)
+		if test.err {
+			if err == nil {
+				t.Errorf("%s: expecting error", name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+			continue
+		}
+		if c != test.want {
+			t.Errorf("%s: got %v, want %v", name, c, test.want)
+		}
+	}
+}
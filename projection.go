@@ -0,0 +1,63 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package earth
+
+// PlateCarreeXY returns the image coordinates
+// of a geographic point
+// in a plate carrée
+// (equirectangular)
+// projection
+// with the indicated number of columns
+// and rows.
+// Images of an equirectangular projection
+// usually have a 2:1 aspect ratio
+// (i.e. rows is cols/2),
+// but any image size is valid,
+// so the number of rows is given explicitly
+// instead of being derived from cols.
+//
+// The point at (0, 0) is the north-west corner
+// of the image.
+// Points at 180 longitude
+// are set at -180 longitude
+// (i.e. the first column),
+// and points at -90 latitude
+// are set in the last row of the image.
+func PlateCarreeXY(lat, lon float64, cols, rows int) (x, y int) {
+	stepX := 360 / float64(cols)
+	stepY := 180 / float64(rows)
+
+	x = int((lon + 180) / stepX)
+	if x >= cols {
+		x = 0
+	}
+	if x < 0 {
+		x = 0
+	}
+
+	y = int((90 - lat) / stepY)
+	if y >= rows {
+		y = rows - 1
+	}
+	if y < 0 {
+		y = 0
+	}
+	return x, y
+}
+
+// PlateCarreeLatLon returns the geographic coordinates
+// of the north-west corner of an image pixel
+// in a plate carrée
+// (equirectangular)
+// projection
+// with the indicated number of columns
+// and rows.
+//
+// It is the inverse of PlateCarreeXY.
+func PlateCarreeLatLon(x, y, cols, rows int) (lat, lon float64) {
+	lat = 90 - float64(y)*180/float64(rows)
+	lon = float64(x)*360/float64(cols) - 180
+	return lat, lon
+}
@@ -0,0 +1,84 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package earth_test
+
+import (
+	"testing"
+
+	"github.com/js-arias/earth"
+)
+
+func TestPlateCarreeXY(t *testing.T) {
+	cols := 360
+
+	tests := map[string]struct {
+		lat, lon float64
+		x, y     int
+	}{
+		"origin":         {0, 0, 180, 90},
+		"north-west":     {90, -180, 0, 0},
+		"north pole":     {90, 0, 180, 0},
+		"south pole":     {-90, 0, 180, 179},
+		"antimeridian":   {0, 180, 0, 90},
+		"south-east":     {-90, 180, 0, 179},
+		"buenos aires":   {-34.6, -58.4, 121, 124},
+		"inside a pixel": {0.5, 10.5, 190, 89},
+	}
+
+	for name, test := range tests {
+		x, y := earth.PlateCarreeXY(test.lat, test.lon, cols, cols/2)
+		if x != test.x || y != test.y {
+			t.Errorf("%s: got %d,%d, want %d,%d", name, x, y, test.x, test.y)
+		}
+	}
+
+	// an image that is not 2:1
+	square := map[string]struct {
+		lat, lon float64
+		x, y     int
+	}{
+		"origin":       {0, 0, 180, 180},
+		"north pole":   {90, 0, 180, 0},
+		"south pole":   {-90, 0, 180, 359},
+		"buenos aires": {-34.6, -58.4, 121, 249},
+	}
+	for name, test := range square {
+		x, y := earth.PlateCarreeXY(test.lat, test.lon, cols, cols)
+		if x != test.x || y != test.y {
+			t.Errorf("square: %s: got %d,%d, want %d,%d", name, x, y, test.x, test.y)
+		}
+	}
+}
+
+func TestPlateCarreeLatLon(t *testing.T) {
+	cols := 720
+
+	tests := map[string]struct {
+		x, y     int
+		lat, lon float64
+	}{
+		"north-west": {0, 0, 90, -180},
+		"origin":     {360, 180, 0, 0},
+		"last":       {719, 359, -89.5, 179.5},
+	}
+
+	for name, test := range tests {
+		lat, lon := earth.PlateCarreeLatLon(test.x, test.y, cols, cols/2)
+		if lat != test.lat || lon != test.lon {
+			t.Errorf("%s: got %.3f,%.3f, want %.3f,%.3f", name, lat, lon, test.lat, test.lon)
+		}
+
+		// the inverse
+		x, y := earth.PlateCarreeXY(lat, lon, cols, cols/2)
+		if x != test.x || y != test.y {
+			t.Errorf("%s: inverse: got %d,%d, want %d,%d", name, x, y, test.x, test.y)
+		}
+	}
+
+	// an image that is not 2:1
+	if lat, lon := earth.PlateCarreeLatLon(360, 360, cols, cols); lat != 0 || lon != 0 {
+		t.Errorf("square: got %.3f,%.3f, want %.3f,%.3f", lat, lon, 0.0, 0.0)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"slices"
 	"strconv"
 	"strings"
@@ -304,6 +305,58 @@ func (r Rotation) Rotation(plate int, t int64) (r3.Rotation, bool) {
 	return r3.Rotation(qt), true
 }
 
+// RelativeRotation returns the total rotation
+// of a plate
+// relative to a fixed plate
+// (i.e. the rotation of the plate
+// in the reference frame in which the fixed plate
+// does not move)
+// at a particular time
+// (in years).
+// It returns false if the rotation of any of the plates
+// is not defined at the indicated time.
+//
+// If both plates are the same,
+// it returns the identity rotation.
+func (r Rotation) RelativeRotation(plate, fixed int, t int64) (r3.Rotation, bool) {
+	fr, ok := r.Rotation(fixed, t)
+	if !ok {
+		return r3.Rotation{}, false
+	}
+	if plate == fixed {
+		return r3.Rotation{Real: 1}, true
+	}
+	pr, ok := r.Rotation(plate, t)
+	if !ok {
+		return r3.Rotation{}, false
+	}
+
+	// rotate the plate to its past location,
+	// and then undo the motion of the fixed plate
+	q := quat.Mul(quat.Conj(quat.Number(fr)), quat.Number(pr))
+	return r3.Rotation(q), true
+}
+
+// RotatePoint returns the location of a point
+// of a plate at a particular time
+// (in years),
+// using the total rotation of the plate.
+// Latitude and longitude of the returned point
+// are normalized.
+// It returns false if there are no rotation defined
+// at the indicated time.
+func (r Rotation) RotatePoint(plate int, lat, lon float64, t int64) (earth.Point, bool) {
+	rot, ok := r.Rotation(plate, t)
+	if !ok {
+		return earth.Point{}, false
+	}
+
+	v := Rotate(rot, lat, lon)
+	nLat := earth.ToDegree(math.Asin(math.Max(-1, math.Min(1, v.Z))))
+	nLon := earth.ToDegree(math.Atan2(v.Y, v.X))
+	return earth.NewPoint(earth.NormalizeLat(nLat), earth.NormalizeLon(nLon)), true
+}
+
 // Euler returns the list of Euler rotations
 // for a given plate.
 func (r Rotation) Euler(plate int) []Euler {
@@ -327,6 +380,144 @@ func (r Rotation) Plates() []int {
 	return plates
 }
 
+// A StagePole is a stage rotation
+// of a moving plate relative to a fixed plate
+// between two consecutive total rotations.
+type StagePole struct {
+	Young int64       // youngest age of the stage (in years)
+	Old   int64       // oldest age of the stage (in years)
+	E     earth.Point // Euler pole of the stage
+	Angle float64     // angle of the rotation in radians
+	Fix   int         // ID of the fixed plate
+}
+
+// StagePoles returns the stage rotations
+// of a plate
+// for each pair of consecutive total rotations
+// of the plate,
+// sorted by age.
+//
+// A stage rotation moves a point of the plate
+// from its location at the oldest age
+// to its location at the youngest age
+// (i.e. forward in time),
+// relative to the fixed plate
+// in the present day frame.
+// The angle of the rotation is always positive,
+// and if the angle is zero,
+// the pole is the North Pole.
+// Pairs of total rotations with different fixed plates,
+// or with the same age,
+// are ignored.
+func (r Rotation) StagePoles(plate int) []StagePole {
+	p, ok := r.p[plate]
+	if !ok {
+		return nil
+	}
+
+	var sp []StagePole
+	for x := 1; x < len(p.rot); x++ {
+		young, old := p.rot[x-1], p.rot[x]
+		if young.Fix != old.Fix || young.T == old.T {
+			continue
+		}
+
+		// stage rotation from the old to the young time
+		qo := quat.Number(r3.NewRotation(-old.Angle, old.E.Vector()))
+		qy := quat.Number(r3.NewRotation(young.Angle, young.E.Vector()))
+		s := quat.Mul(qy, qo)
+		e, angle := AxisAngle(r3.Rotation(s))
+		sp = append(sp, StagePole{
+			Young: young.T,
+			Old:   old.T,
+			E:     e,
+			Angle: angle,
+			Fix:   old.Fix,
+		})
+	}
+	return sp
+}
+
+// VelocityStep is the time interval
+// (in years)
+// used to approximate the instantaneous stage rotation
+// in a velocity calculation.
+const velocityStep = millionYears
+
+// Velocity returns the instantaneous surface velocity
+// of a point of a plate
+// at the indicated time
+// (in years).
+// The point is given by its coordinates
+// at the indicated time
+// (i.e. its paleo-location).
+//
+// The speed is in kilometers per million years
+// (i.e. millimeters per year),
+// and the bearing is the direction of the motion,
+// forward in time,
+// in radians
+// (0 being north, pi/2 east).
+//
+// The velocity is calculated from the stage rotation
+// of a one million year interval
+// that ends at the indicated time
+// (or starts, if the time is younger than a million years),
+// as the cross product of the angular velocity
+// and the position vector of the point.
+// It returns false if the plate has no rotation
+// at the required times.
+func (r Rotation) Velocity(plate int, lat, lon float64, t int64) (speed, bearing float64, ok bool) {
+	old, young := t, t-velocityStep
+	if young < 0 {
+		old, young = t+velocityStep, t
+	}
+
+	ro, ok := r.Rotation(plate, old)
+	if !ok {
+		return 0, 0, false
+	}
+	ry, ok := r.Rotation(plate, young)
+	if !ok {
+		return 0, 0, false
+	}
+
+	// stage rotation from the old to the young time
+	s := quat.Mul(quat.Number(ry), quat.Conj(quat.Number(ro)))
+	if s.Real < 0 {
+		s = quat.Scale(-1, s)
+	}
+	axis := r3.Vec{X: s.Imag, Y: s.Jmag, Z: s.Kmag}
+	sin := r3.Norm(axis)
+	if sin == 0 {
+		return 0, 0, true
+	}
+	angle := 2 * math.Atan2(sin, s.Real)
+
+	// angular velocity in radians per million years
+	dt := float64(old-young) / millionYears
+	omega := r3.Scale(angle/(sin*dt), axis)
+
+	pt := earth.NewPoint(lat, lon)
+	v := r3.Cross(omega, pt.Vector())
+
+	rLat := earth.ToRad(lat)
+	rLon := earth.ToRad(lon)
+	east := r3.Vec{X: -math.Sin(rLon), Y: math.Cos(rLon)}
+	north := r3.Vec{
+		X: -math.Sin(rLat) * math.Cos(rLon),
+		Y: -math.Sin(rLat) * math.Sin(rLon),
+		Z: math.Cos(rLat),
+	}
+
+	speed = r3.Norm(v) * earth.Radius / 1000
+	bearing = math.Atan2(r3.Dot(v, east), r3.Dot(v, north))
+	if bearing < 0 {
+		bearing += 2 * math.Pi
+	}
+	return speed, bearing, true
+}
+
 // A Plate is a collection of rotations
 // for the indicated plate.
 type plate struct {
@@ -370,6 +561,37 @@ type Euler struct {
 	Fix   int         // ID of the fixed plate
 }
 
+// String returns a text representation
+// of an Euler rotation,
+// using the conventions of a rotation file
+// (i.e. time in million years,
+// and the angle in degrees),
+// for example:
+//
+//	t=37.0Ma pole=(70.5,-18.7) angle=-10.4° fix=1
+func (e Euler) String() string {
+	t := formatNum(float64(e.T) / millionYears)
+	lat := formatNum(e.E.Latitude())
+	lon := formatNum(e.E.Longitude())
+	a := formatNum(earth.ToDegree(e.Angle))
+	return fmt.Sprintf("t=%sMa pole=(%s,%s) angle=%s° fix=%d", t, lat, lon, a, e.Fix)
+}
+
+// FormatNum formats a number
+// with at most six decimals
+// and at least one decimal.
+func formatNum(v float64) string {
+	v = math.Round(v*1e6) / 1e6
+	if v == 0 {
+		v = 0
+	}
+	s := strconv.FormatFloat(v, 'f', -1, 64)
+	if !strings.Contains(s, ".") {
+		s += ".0"
+	}
+	return s
+}
+
 // Rotate returns a vector
 // from a given coordinate
 // rotated using the indicated rotation.
@@ -377,6 +599,37 @@ func Rotate(r r3.Rotation, lat, lon float64) r3.Vec {
 	return r.Rotate(earth.NewPoint(lat, lon).Vector())
 }
 
+// AxisAngle returns the Euler pole
+// and the angle,
+// in radians,
+// of a rotation.
+// It is the inverse of r3.NewRotation.
+//
+// The angle is always in the range [0, π],
+// so if the rotation was built with a negative angle,
+// the returned pole is the antipode of the original axis.
+// If the angle is zero
+// (i.e. the identity rotation),
+// the pole is the North Pole.
+func AxisAngle(r r3.Rotation) (pole earth.Point, angleRad float64) {
+	q := quat.Number(r)
+	if q.Real < 0 {
+		q = quat.Scale(-1, q)
+	}
+
+	axis := r3.Vec{X: q.Imag, Y: q.Jmag, Z: q.Kmag}
+	sin := r3.Norm(axis)
+	if sin == 0 {
+		return earth.NorthPole, 0
+	}
+
+	axis = r3.Scale(1/sin, axis)
+	lat := earth.ToDegree(math.Asin(math.Max(-1, math.Min(1, axis.Z))))
+	lon := earth.ToDegree(math.Atan2(axis.Y, axis.X))
+	pole = earth.NewPoint(earth.NormalizeLat(lat), earth.NormalizeLon(lon))
+	return pole, 2 * math.Atan2(sin, q.Real)
+}
+
 // Inverse returns the inverse of a rotation.
 func Inverse(r r3.Rotation) r3.Rotation {
 	return r3.Rotation(quat.Conj(quat.Number(r)))
@@ -46,6 +46,36 @@ func TestSimple(t *testing.T) {
 	testRotation(t, r, newRotation(65, -37, -48), 20, 130)
 }
 
+// This test rotates a point
+// using the box 7-3 of Cox & Hart.
+func TestRotatePoint(t *testing.T) {
+	simple := "1 90.0 0.0 0.0 0\n1 100.0 -37 -48 65 0\n"
+	rots, err := rotation.Read(strings.NewReader(simple))
+	if err != nil {
+		t.Fatalf("when reading rotation: %v", err)
+	}
+
+	pt, ok := rots.RotatePoint(1, 20, 130, 100_000_000)
+	if !ok {
+		t.Fatalf("want rotation at %d\n", 100_000_000)
+	}
+	want := earth.NewPoint(30, 113.2)
+	if isDiff(pt.Vector(), want.Vector()) {
+		t.Errorf("rotation: got %.3f,%.3f, want %.3f,%.3f", pt.Latitude(), pt.Longitude(), want.Latitude(), want.Longitude())
+	}
+	if lon := pt.Longitude(); lon < -180 || lon > 180 {
+		t.Errorf("rotation: longitude %.3f out of range", lon)
+	}
+
+	// undefined rotations
+	if _, ok := rots.RotatePoint(1, 20, 130, 200_000_000); ok {
+		t.Errorf("rotation at %d: unexpected rotation", 200_000_000)
+	}
+	if _, ok := rots.RotatePoint(2, 20, 130, 100_000_000); ok {
+		t.Errorf("plate %d: unexpected rotation", 2)
+	}
+}
+
 // This is a test for an intermediate rotation
 // between two total reconstruction poles.
 // It is based on the example of pag. 246
@@ -110,6 +140,43 @@ func TestCircuit(t *testing.T) {
 	testRotation(t, r, newRotation(-24.34, 17.21, 34.89), 20, 130)
 }
 
+func TestRelativeRotation(t *testing.T) {
+	rots, err := rotation.Read(strings.NewReader("1 0.0 90.0 0.0 0.0 0\n1 100.0 -37 -48 65 0\n2 0.0 90.0 0.0 0.0 1\n2 100.0 10 20 30 1\n"))
+	if err != nil {
+		t.Fatalf("when reading rotations: %v", err)
+	}
+
+	// the relative rotation of a plate
+	// to the plate it is fixed in the model
+	// is the rotation in the model
+	r, ok := rots.RelativeRotation(2, 1, 100_000_000)
+	if !ok {
+		t.Fatalf("want relative rotation at %d", 100_000_000)
+	}
+	testRotation(t, r, newRotation(30, 10, 20), 20, 130)
+
+	// a fixed plate does not move
+	r, ok = rots.RelativeRotation(1, 1, 100_000_000)
+	if !ok {
+		t.Fatalf("want relative rotation at %d", 100_000_000)
+	}
+	testRotation(t, r, r3.NewRotation(0, r3.Vec{Z: 1}), 20, 130)
+
+	// the fixed plate moves in the opposite direction
+	r, ok = rots.RelativeRotation(1, 2, 100_000_000)
+	if !ok {
+		t.Fatalf("want relative rotation at %d", 100_000_000)
+	}
+	testRotation(t, r, newRotation(-30, 10, 20), 20, 130)
+
+	if _, ok := rots.RelativeRotation(1, 3, 100_000_000); ok {
+		t.Errorf("undefined fixed plate: got a rotation")
+	}
+	if _, ok := rots.RelativeRotation(3, 1, 100_000_000); ok {
+		t.Errorf("undefined plate: got a rotation")
+	}
+}
+
 func TestUnordered(t *testing.T) {
 	in := `
 5 83.0  5.6  -4.7  38.6 4
@@ -300,3 +367,162 @@ func isDiff(v, w r3.Vec) bool {
 	}
 	return false
 }
+
+func TestVelocity(t *testing.T) {
+	// a pole at the geographic north pole
+	// one degree per million years
+	in := "1 0.0 90.0 0.0 0.0 0\n1 10.0 90.0 0.0 10.0 0\n"
+	rots, err := rotation.Read(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("when reading rotation: %v", err)
+	}
+
+	tests := map[string]struct {
+		lat, lon float64
+		age      int64
+	}{
+		"equator":  {lat: 0, lon: 30, age: 5_000_000},
+		"present":  {lat: 0, lon: -65, age: 0},
+		"latitude": {lat: 60, lon: 120, age: 8_000_000},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			speed, bearing, ok := rots.Velocity(1, test.lat, test.lon, test.age)
+			if !ok {
+				t.Fatalf("velocity: undefined")
+			}
+
+			want := earth.Radius / 1000 * earth.ToRad(1) * math.Cos(earth.ToRad(test.lat))
+			if math.Abs(speed-want)/want > 1e-5 {
+				t.Errorf("speed: got %.6f, want %.6f", speed, want)
+			}
+
+			// from the past to the present
+			// the plate moves westward
+			if math.Abs(bearing-3*math.Pi/2) > 1e-6 {
+				t.Errorf("bearing: got %.6f, want %.6f", bearing, 3*math.Pi/2)
+			}
+		})
+	}
+
+	if _, _, ok := rots.Velocity(2, 0, 0, 5_000_000); ok {
+		t.Errorf("velocity: undefined plate with velocity")
+	}
+}
+
+func TestStagePoles(t *testing.T) {
+	rots, err := rotation.Read(strings.NewReader(coxHartTable73))
+	if err != nil {
+		t.Fatalf("when reading rotations: %v", err)
+	}
+
+	sp := rots.StagePoles(1)
+	ages := [][2]int64{
+		{0, 37_000_000},
+		{37_000_000, 48_000_000},
+		{48_000_000, 53_000_000},
+		{53_000_000, 83_000_000},
+	}
+	if len(sp) != len(ages) {
+		t.Fatalf("stages: got %d, want %d", len(sp), len(ages))
+	}
+	for i, s := range sp {
+		if s.Young != ages[i][0] || s.Old != ages[i][1] {
+			t.Errorf("stage %d: got %d-%d, want %d-%d", i, s.Young, s.Old, ages[i][0], ages[i][1])
+		}
+		if s.Fix != 0 {
+			t.Errorf("stage %d: fixed plate: got %d, want %d", i, s.Fix, 0)
+		}
+		if s.Angle < 0 {
+			t.Errorf("stage %d: angle: got %.6f, want a positive value", i, s.Angle)
+		}
+
+		// manual computation:
+		// rotate a point back to the old age
+		// and forward to the young age
+		old, _ := rots.Rotation(1, s.Old)
+		young, _ := rots.Rotation(1, s.Young)
+		v := rotation.Rotate(old, 20, 130)
+		want := young.Rotate(earth.NewPoint(20, 130).Vector())
+		got := r3.NewRotation(s.Angle, s.E.Vector()).Rotate(v)
+		if isDiff(got, want) {
+			t.Errorf("stage %d: rotation: got %v, want %v", i, got, want)
+		}
+	}
+
+	// the first stage is the inverse of the total rotation
+	s := sp[0]
+	if isDiff(s.E.Vector(), earth.NewPoint(-68, -50.1).Vector()) {
+		t.Errorf("first stage: pole: got %.3f,%.3f, want %.3f,%.3f", s.E.Latitude(), s.E.Longitude(), -68.0, -50.1)
+	}
+	if math.Abs(earth.ToDegree(s.Angle)-7.8) > 1e-6 {
+		t.Errorf("first stage: angle: got %.6f, want %.6f", earth.ToDegree(s.Angle), 7.8)
+	}
+
+	if sp := rots.StagePoles(99); sp != nil {
+		t.Errorf("undefined plate: got %v, want nil", sp)
+	}
+}
+
+func TestEulerString(t *testing.T) {
+	rots, err := rotation.Read(strings.NewReader(coxHartTable73))
+	if err != nil {
+		t.Fatalf("when reading rotations: %v", err)
+	}
+
+	want := []string{
+		"t=0.0Ma pole=(0.0,0.0) angle=0.0° fix=1",
+		"t=37.0Ma pole=(70.5,-18.7) angle=-10.4° fix=1",
+		"t=66.0Ma pole=(80.8,-8.6) angle=-22.5° fix=1",
+		"t=71.0Ma pole=(80.4,-12.5) angle=-23.9° fix=1",
+	}
+	for i, e := range rots.Euler(2) {
+		if s := e.String(); s != want[i] {
+			t.Errorf("euler %d: got %q, want %q", i, s, want[i])
+		}
+	}
+
+	e := rotation.Euler{T: 780_000, E: earth.NewPoint(-26.25, 65), Angle: earth.ToRad(0.125), Fix: 201}
+	if s, w := e.String(), "t=0.78Ma pole=(-26.25,65.0) angle=0.125° fix=201"; s != w {
+		t.Errorf("euler: got %q, want %q", s, w)
+	}
+}
+
+func TestAxisAngle(t *testing.T) {
+	tests := map[string]struct {
+		pole  earth.Point
+		angle float64
+		want  earth.Point
+	}{
+		"Cox & Hart":     {earth.NewPoint(62, 174), earth.ToRad(71), earth.NewPoint(62, 174)},
+		"negative angle": {earth.NewPoint(62, 174), earth.ToRad(-71), earth.NewPoint(-62, -6)},
+		"south pole":     {earth.SouthPole, earth.ToRad(30), earth.SouthPole},
+		"half turn":      {earth.NewPoint(10, -40), math.Pi, earth.NewPoint(10, -40)},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := r3.NewRotation(test.angle, test.pole.Vector())
+			pole, angle := rotation.AxisAngle(r)
+			if d := earth.Distance(pole, test.want); d > 1e-6 {
+				t.Errorf("pole: got %.6f %.6f, want %.6f %.6f", pole.Latitude(), pole.Longitude(), test.want.Latitude(), test.want.Longitude())
+			}
+			if a := math.Abs(test.angle); math.Abs(angle-a) > 1e-9 {
+				t.Errorf("angle: got %.6f, want %.6f", angle, a)
+			}
+
+			// round trip
+			rt := r3.NewRotation(angle, pole.Vector())
+			pt := earth.NewPoint(-26, -65).Vector()
+			if d := r3.Norm(r3.Sub(rt.Rotate(pt), r.Rotate(pt))); d > 1e-9 {
+				t.Errorf("round trip: got %v, want %v", rt.Rotate(pt), r.Rotate(pt))
+			}
+		})
+	}
+
+	pole, angle := rotation.AxisAngle(r3.NewRotation(0, earth.NewPoint(30, 40).Vector()))
+	if pole != earth.NorthPole || angle != 0 {
+		t.Errorf("identity: got %.6f %.6f, %.6f, want north pole and 0", pole.Latitude(), pole.Longitude(), angle)
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package dist
+
+import (
+	"slices"
+
+	"github.com/js-arias/earth"
+)
+
+// KDECutoff is the cumulative density
+// used to set the maximum distance
+// of the contribution of an observation
+// in a kernel density estimation.
+const KDECutoff = 0.9999
+
+// KDE returns a kernel density estimation
+// over all the pixels of a pixelation,
+// using a spherical normal as the kernel,
+// and a set of observed pixels.
+//
+// Each observation spreads its mass
+// to the pixels within the distance
+// at which the CDF of the normal reaches KDECutoff,
+// so the density beyond that distance is 0.
+// The mass of each observation is normalized to 1,
+// so the sum of the returned densities
+// is equal to the number of observations.
+//
+// The returned slice is indexed by pixel ID.
+func KDE(pix *earth.Pixelation, n Normal, observed []earth.Pixel) []float64 {
+	density := make([]float64, pix.Len())
+
+	r, _ := slices.BinarySearch(n.cdf, KDECutoff)
+	maxDist := (float64(r) + 0.5) * n.step
+
+	raw := make(map[int]float64)
+	for _, o := range observed {
+		clear(raw)
+		pt := pix.ID(o.ID()).Point()
+
+		var sum float64
+		for _, id := range pix.PixelsInCap(pt, maxDist) {
+			d := earth.Distance(pt, pix.ID(id).Point())
+			p := n.Prob(d)
+			if p == 0 {
+				continue
+			}
+			raw[id] = p
+			sum += p
+		}
+		if sum == 0 {
+			continue
+		}
+		for id, p := range raw {
+			density[id] += p / sum
+		}
+	}
+
+	return density
+}
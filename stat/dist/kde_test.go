@@ -0,0 +1,58 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package dist_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/js-arias/earth"
+	"github.com/js-arias/earth/stat/dist"
+)
+
+func TestKDE(t *testing.T) {
+	pix := earth.NewPixelation(360)
+	n := dist.NewNormal(100, pix)
+
+	obs := pix.Pixel(-26, -65)
+	d := dist.KDE(pix, n, []earth.Pixel{obs})
+	if len(d) != pix.Len() {
+		t.Fatalf("got %d pixels, want %d", len(d), pix.Len())
+	}
+
+	var sum float64
+	for _, v := range d {
+		sum += v
+	}
+	if math.Abs(sum-1) > 1e-9 {
+		t.Errorf("sum: got %.6f, want %.6f", sum, 1.0)
+	}
+
+	// the density should match the shape
+	// of the distribution
+	max := d[obs.ID()]
+	maxProb := n.Prob(0)
+	for id, v := range d {
+		if v == 0 {
+			continue
+		}
+		dist := earth.Distance(obs.Point(), pix.ID(id).Point())
+		want := n.Prob(dist) / maxProb
+		if got := v / max; math.Abs(got-want) > 1e-9 {
+			t.Errorf("pixel %d [dist %.6f]: got %.6f, want %.6f", id, dist, got, want)
+		}
+	}
+
+	// two observations
+	other := pix.Pixel(10, 20)
+	d = dist.KDE(pix, n, []earth.Pixel{obs, other})
+	sum = 0
+	for _, v := range d {
+		sum += v
+	}
+	if math.Abs(sum-2) > 1e-9 {
+		t.Errorf("sum: got %.6f, want %.6f", sum, 2.0)
+	}
+}
@@ -6,9 +6,16 @@
 package dist
 
 import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
 	"math"
 	"math/rand"
 	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/js-arias/earth"
 )
@@ -51,7 +58,7 @@ func NewNormal(lambda float64, pix *earth.Pixelation) Normal {
 	ring := make([]float64, rings)
 	scaled := make([]float64, rings)
 
-	rStep := earth.ToRad(pix.Step())
+	rStep := pix.StepRad()
 
 	// get initial values
 	var sum float64
@@ -97,6 +104,169 @@ func NewNormal(lambda float64, pix *earth.Pixelation) Normal {
 	}
 }
 
+var normalHeader = []string{
+	"equator",
+	"lambda",
+	"ring",
+	"pdf",
+	"cdf",
+	"ring-prob",
+	"log-pdf",
+	"scaled-pdf",
+}
+
+// DecodeNormal reads a discretized spherical normal
+// from a TSV file
+// written with the Encode method.
+// The pixelation must have the same number of pixels
+// at the equator,
+// and the same number of rings,
+// as the pixelation used to build the encoded distribution.
+//
+// The decoded distribution
+// returns the same values
+// as a distribution built with NewNormal,
+// without the need to calculate the tables again.
+func DecodeNormal(r io.Reader, pix *earth.Pixelation) (Normal, error) {
+	tab := csv.NewReader(r)
+	tab.Comma = '\t'
+	tab.Comment = '#'
+
+	head, err := tab.Read()
+	if err != nil {
+		return Normal{}, fmt.Errorf("while reading header: %v", err)
+	}
+	fields := make(map[string]int, len(head))
+	for i, h := range head {
+		h = strings.ToLower(h)
+		fields[h] = i
+	}
+	for _, h := range normalHeader {
+		if _, ok := fields[h]; !ok {
+			return Normal{}, fmt.Errorf("expecting field %q", h)
+		}
+	}
+
+	rings := pix.Rings()
+	n := Normal{
+		pix:       pix,
+		step:      pix.StepRad(),
+		pdf:       make([]float64, 0, rings),
+		cdf:       make([]float64, 0, rings),
+		ring:      make([]float64, 0, rings),
+		logPDF:    make([]float64, 0, rings),
+		scaledPDF: make([]float64, 0, rings),
+	}
+	for {
+		row, err := tab.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		ln, _ := tab.FieldPos(0)
+		if err != nil {
+			return Normal{}, fmt.Errorf("on row %d: %v", ln, err)
+		}
+
+		f := "equator"
+		eq, err := strconv.Atoi(row[fields[f]])
+		if err != nil {
+			return Normal{}, fmt.Errorf("on row %d: field %q: %v", ln, f, err)
+		}
+		if eq != pix.Equator() {
+			return Normal{}, fmt.Errorf("on row %d: field %q: got %d, want %d", ln, f, eq, pix.Equator())
+		}
+
+		f = "ring"
+		rg, err := strconv.Atoi(row[fields[f]])
+		if err != nil {
+			return Normal{}, fmt.Errorf("on row %d: field %q: %v", ln, f, err)
+		}
+		if rg != len(n.pdf) {
+			return Normal{}, fmt.Errorf("on row %d: field %q: got %d, want %d", ln, f, rg, len(n.pdf))
+		}
+		if rg >= rings {
+			return Normal{}, fmt.Errorf("on row %d: field %q: invalid ring %d", ln, f, rg)
+		}
+
+		f = "lambda"
+		lambda, err := strconv.ParseFloat(row[fields[f]], 64)
+		if err != nil {
+			return Normal{}, fmt.Errorf("on row %d: field %q: %v", ln, f, err)
+		}
+		if rg == 0 {
+			n.lambda = lambda
+		} else if lambda != n.lambda {
+			return Normal{}, fmt.Errorf("on row %d: field %q: got %g, want %g", ln, f, lambda, n.lambda)
+		}
+
+		var v [5]float64
+		for i, f := range []string{"pdf", "cdf", "ring-prob", "log-pdf", "scaled-pdf"} {
+			v[i], err = strconv.ParseFloat(row[fields[f]], 64)
+			if err != nil {
+				return Normal{}, fmt.Errorf("on row %d: field %q: %v", ln, f, err)
+			}
+		}
+		n.pdf = append(n.pdf, v[0])
+		n.cdf = append(n.cdf, v[1])
+		n.ring = append(n.ring, v[2])
+		n.logPDF = append(n.logPDF, v[3])
+		n.scaledPDF = append(n.scaledPDF, v[4])
+	}
+	if len(n.pdf) != rings {
+		return Normal{}, fmt.Errorf("got %d rings, want %d", len(n.pdf), rings)
+	}
+
+	for i, p := range n.pdf {
+		dist := float64(i) * n.step
+		n.v += dist * dist * p * float64(pix.PixPerRing(i))
+	}
+	return n, nil
+}
+
+// Encode writes the tables of a discretized spherical normal
+// as a TSV file,
+// so they can be read with DecodeNormal.
+// Values are written with the precision required
+// to be decoded without changes.
+func (n Normal) Encode(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "# spherical normal tables\n")
+	tab := csv.NewWriter(bw)
+	tab.Comma = '\t'
+	tab.UseCRLF = true
+
+	if err := tab.Write(normalHeader); err != nil {
+		return fmt.Errorf("while writing header: %v", err)
+	}
+
+	eq := strconv.Itoa(n.pix.Equator())
+	lambda := strconv.FormatFloat(n.lambda, 'g', -1, 64)
+	for i := range n.pdf {
+		row := []string{
+			eq,
+			lambda,
+			strconv.Itoa(i),
+			strconv.FormatFloat(n.pdf[i], 'g', -1, 64),
+			strconv.FormatFloat(n.cdf[i], 'g', -1, 64),
+			strconv.FormatFloat(n.ring[i], 'g', -1, 64),
+			strconv.FormatFloat(n.logPDF[i], 'g', -1, 64),
+			strconv.FormatFloat(n.scaledPDF[i], 'g', -1, 64),
+		}
+		if err := tab.Write(row); err != nil {
+			return fmt.Errorf("while writing data: %v", err)
+		}
+	}
+
+	tab.Flush()
+	if err := tab.Error(); err != nil {
+		return fmt.Errorf("while writing data: %v", err)
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("while writing data: %v", err)
+	}
+	return nil
+}
+
 // CDF returns the probability cumulative density function
 // for a pixel at a distance dist
 // (in radians).
@@ -115,6 +285,8 @@ func (n Normal) CDF(dist float64) float64 {
 // This is useful because sometimes we want to know
 // if a given pixel is inside or outside a critical CDF value
 // and then using the great circle distance.
+// Use earth.Chord2ToDistance
+// to get the equivalent great circle distance.
 func (n Normal) QuantileChord2(cd float64) float64 {
 	r, _ := slices.BinarySearch(n.cdf, cd)
 	px := n.pix.FirstPix(r)
@@ -179,18 +351,40 @@ func (n Normal) ProbRingDist(rDist int) float64 {
 // from the underlying pixelation
 // draw from an spherical normal
 // which mean is the pixel u.
+//
+// The ring distance is drawn from the ring probabilities
+// (using inversion sampling),
+// and the pixel is taken at that distance
+// from u,
+// in a random direction.
+// The distance is never larger than π,
+// so a draw at the maximum ring distance
+// is the antipode of u.
 func (n Normal) Rand(u earth.Pixel) earth.Pixel {
-	uPt := u.Point()
-
-	for {
-		// inversion sampling
-		r, _ := slices.BinarySearch(n.cdf, rand.Float64())
-		dist := (float64(r) + n.step/2) * n.step
+	return n.RandWith(u, nil)
+}
 
-		b := rand.Float64() * 2 * math.Pi
-		pt := earth.Destination(uPt, dist, b)
-		return n.pix.Pixel(pt.Latitude(), pt.Longitude())
+// RandWith is like Rand,
+// but it uses rnd as the source of random numbers,
+// so a sample can be reproduced.
+// If rnd is nil,
+// the default source of the math/rand package
+// will be used.
+func (n Normal) RandWith(u earth.Pixel, rnd *rand.Rand) earth.Pixel {
+	random := rand.Float64
+	if rnd != nil {
+		random = rnd.Float64
 	}
+	r, _ := slices.BinarySearch(n.cdf, random())
+
+	// rounding errors in the CDF
+	// can produce a value beyond the last ring
+	r = min(r, len(n.cdf)-1)
+	dist := min(float64(r)*n.step, math.Pi)
+
+	b := random() * 2 * math.Pi
+	pt := earth.Destination(u.Point(), dist, b)
+	return n.pix.Pixel(pt.Latitude(), pt.Longitude())
 }
 
 // Ring returns the value of the probability density function
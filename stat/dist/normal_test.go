@@ -5,7 +5,10 @@
 package dist_test
 
 import (
+	"bytes"
 	"math"
+	"math/rand"
+	"strings"
 	"testing"
 
 	"github.com/js-arias/earth"
@@ -192,6 +195,95 @@ func TestScaledProb(t *testing.T) {
 	}
 }
 
+func TestNormalRand(t *testing.T) {
+	pix := earth.NewPixelation(360)
+
+	// a wide kernel
+	// so the antipode has a non-zero probability
+	// (although too small to be sampled
+	// reliably in a test)
+	n := dist.NewNormal(0.5, pix)
+	if p := n.Ring(math.Pi); p <= 0 {
+		t.Errorf("antipode: got probability %.6g, want > 0", p)
+	}
+
+	// with the mean at the north pole
+	// the ring of the sampled pixel
+	// is the ring distance
+	u := pix.ID(0)
+	samples := 200_000
+	count := make([]int, pix.Rings())
+	for i := 0; i < samples; i++ {
+		px := n.Rand(u)
+		count[px.Ring()]++
+	}
+	for r, c := range count {
+		p := n.Ring(float64(r) * pix.StepRad())
+		got := float64(c) / float64(samples)
+
+		// five standard errors
+		bound := 5*math.Sqrt(p*(1-p)/float64(samples)) + 1e-4
+		if math.Abs(got-p) > bound {
+			t.Errorf("ring %d: got %.6f, want %.6f", r, got, p)
+		}
+	}
+
+	// near antipodal draws
+	// are valid pixels
+	for i := 0; i < 1_000; i++ {
+		u := pix.Random()
+		px := n.Rand(u)
+		if !pix.Valid(px.ID()) {
+			t.Errorf("invalid pixel %d", px.ID())
+		}
+	}
+}
+
+func TestNormalRandFarRings(t *testing.T) {
+	pix := earth.NewPixelation(360)
+
+	// a very high variance,
+	// so the distribution is almost uniform
+	// and the rings near the antipode
+	// are sampled often
+	n := dist.NewNormal(0.01, pix)
+	far := pix.Rings() - 10
+
+	var want float64
+	for r := far; r < pix.Rings(); r++ {
+		want += n.Ring(float64(r) * pix.StepRad())
+	}
+
+	u := pix.ID(0)
+	rnd := rand.New(rand.NewSource(1))
+	samples := 20_000
+	var count int
+	for i := 0; i < samples; i++ {
+		px := n.RandWith(u, rnd)
+		if !pix.Valid(px.ID()) {
+			t.Fatalf("invalid pixel %d", px.ID())
+		}
+		if px.Ring() >= far {
+			count++
+		}
+	}
+	if count == 0 {
+		t.Fatalf("far rings: no samples")
+	}
+	got := float64(count) / float64(samples)
+	bound := 5 * math.Sqrt(want*(1-want)/float64(samples))
+	if math.Abs(got-want) > bound {
+		t.Errorf("far rings: got %.6f, want %.6f", got, want)
+	}
+
+	// the same source produces the same sample
+	a := n.RandWith(u, rand.New(rand.NewSource(7)))
+	b := n.RandWith(u, rand.New(rand.NewSource(7)))
+	if a.ID() != b.ID() {
+		t.Errorf("same source: got pixels %d and %d", a.ID(), b.ID())
+	}
+}
+
 func BenchmarkRandNormalSmall(b *testing.B) {
 	pix := earth.NewPixelation(360)
 	u := pix.Random()
@@ -215,3 +307,49 @@ func BenchmarkRandNormal(b *testing.B) {
 		n.Rand(u)
 	}
 }
+
+func TestNormalEncode(t *testing.T) {
+	pix := earth.NewPixelation(360)
+	n := dist.NewNormal(100, pix)
+
+	var buf bytes.Buffer
+	if err := n.Encode(&buf); err != nil {
+		t.Fatalf("while encoding: %v", err)
+	}
+
+	d, err := dist.DecodeNormal(strings.NewReader(buf.String()), pix)
+	if err != nil {
+		t.Fatalf("while decoding: %v", err)
+	}
+
+	if d.Lambda() != n.Lambda() {
+		t.Errorf("lambda: got %g, want %g", d.Lambda(), n.Lambda())
+	}
+	if d.Variance() != n.Variance() {
+		t.Errorf("variance: got %g, want %g", d.Variance(), n.Variance())
+	}
+	np := pix.Pixel(90, 0)
+	for i := 0; i < pix.Rings(); i++ {
+		dist := earth.Distance(np.Point(), pix.FirstPix(i).Point())
+		if got, want := d.Prob(dist), n.Prob(dist); got != want {
+			t.Errorf("prob: ring %d: got %g, want %g", i, got, want)
+		}
+		if got, want := d.CDF(dist), n.CDF(dist); got != want {
+			t.Errorf("CDF: ring %d: got %g, want %g", i, got, want)
+		}
+		if got, want := d.LogProb(dist), n.LogProb(dist); got != want {
+			t.Errorf("log prob: ring %d: got %g, want %g", i, got, want)
+		}
+		if got, want := d.Ring(dist), n.Ring(dist); got != want {
+			t.Errorf("ring prob: ring %d: got %g, want %g", i, got, want)
+		}
+		if got, want := d.ScaledProb(dist), n.ScaledProb(dist); got != want {
+			t.Errorf("scaled prob: ring %d: got %g, want %g", i, got, want)
+		}
+	}
+
+	// a different pixelation
+	if _, err := dist.DecodeNormal(strings.NewReader(buf.String()), earth.NewPixelation(36)); err == nil {
+		t.Errorf("different pixelation: expecting error")
+	}
+}
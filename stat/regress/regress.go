@@ -0,0 +1,159 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package regress implements the fit of circles
+// (great and small circles)
+// to a set of points on the surface of a sphere.
+package regress
+
+import (
+	"math"
+
+	"github.com/js-arias/earth"
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+// FitGreatCircle returns the pole of the great circle
+// that best fits a set of points,
+// and the root mean square of the cross-track distance
+// of the points to the great circle
+// (in radians).
+//
+// The pole is the eigenvector
+// with the smallest eigenvalue
+// of the orientation matrix of the points
+// (i.e. the normal of the plane,
+// that pass through the center of the sphere,
+// that best fits the points).
+// As both poles of a great circle are equivalent,
+// the returned pole is always in the northern hemisphere
+// (or, if it is on the equator,
+// in the eastern hemisphere).
+//
+// If there are less than two points,
+// it returns the zero Point
+// and NaN.
+func FitGreatCircle(points []earth.Point) (pole earth.Point, rmsRad float64) {
+	if len(points) < 2 {
+		return earth.Point{}, math.NaN()
+	}
+
+	n := smallestEigen(points, r3.Vec{})
+	n = hemisphere(n)
+
+	var sum float64
+	for _, p := range points {
+		d := math.Asin(clamp(r3.Dot(p.Vector(), n)))
+		sum += d * d
+	}
+	return toPoint(n), math.Sqrt(sum / float64(len(points)))
+}
+
+// FitSmallCircle returns the pole
+// and the colatitude
+// (i.e. the angular radius,
+// in radians)
+// of the small circle that best fits a set of points,
+// and the root mean square of the distance
+// of the points to the small circle
+// (in radians).
+//
+// The pole is the eigenvector
+// with the smallest eigenvalue
+// of the covariance matrix of the points
+// (i.e. the normal of the plane
+// that best fits the points).
+// The pole is always the one
+// with a colatitude smaller or equal to π/2.
+//
+// If there are less than three points,
+// it returns the zero Point
+// and NaN.
+func FitSmallCircle(points []earth.Point) (pole earth.Point, colat, rmsRad float64) {
+	if len(points) < 3 {
+		return earth.Point{}, math.NaN(), math.NaN()
+	}
+
+	var mean r3.Vec
+	for _, p := range points {
+		mean = r3.Add(mean, p.Vector())
+	}
+	mean = r3.Scale(1/float64(len(points)), mean)
+
+	n := smallestEigen(points, mean)
+	d := r3.Dot(mean, n)
+	if d < 0 {
+		n = r3.Scale(-1, n)
+		d = -d
+	}
+	colat = math.Acos(clamp(d))
+
+	var sum float64
+	for _, p := range points {
+		r := math.Acos(clamp(r3.Dot(p.Vector(), n))) - colat
+		sum += r * r
+	}
+	return toPoint(n), colat, math.Sqrt(sum / float64(len(points)))
+}
+
+// SmallestEigen returns the eigenvector
+// with the smallest eigenvalue
+// of the scatter matrix of the points
+// around the given center.
+func smallestEigen(points []earth.Point, center r3.Vec) r3.Vec {
+	m := mat.NewSymDense(3, nil)
+	for _, p := range points {
+		v := r3.Sub(p.Vector(), center)
+		c := [3]float64{v.X, v.Y, v.Z}
+		for i := 0; i < 3; i++ {
+			for j := i; j < 3; j++ {
+				m.SetSym(i, j, m.At(i, j)+c[i]*c[j])
+			}
+		}
+	}
+
+	var es mat.EigenSym
+	if !es.Factorize(m, true) {
+		return r3.Vec{Z: 1}
+	}
+	var vecs mat.Dense
+	es.VectorsTo(&vecs)
+
+	// eigenvalues are in ascending order
+	return r3.Unit(r3.Vec{
+		X: vecs.At(0, 0),
+		Y: vecs.At(1, 0),
+		Z: vecs.At(2, 0),
+	})
+}
+
+// Hemisphere returns the vector
+// or its antipode,
+// so the vector is in the northern hemisphere,
+// or in the eastern hemisphere
+// if it is on the equator.
+func hemisphere(v r3.Vec) r3.Vec {
+	const eps = 1e-12
+	if v.Z < -eps {
+		return r3.Scale(-1, v)
+	}
+	if v.Z > eps {
+		return v
+	}
+	if v.Y < 0 || (v.Y == 0 && v.X < 0) {
+		return r3.Scale(-1, v)
+	}
+	return v
+}
+
+func toPoint(v r3.Vec) earth.Point {
+	lat := earth.ToDegree(math.Asin(clamp(v.Z)))
+	lon := earth.ToDegree(math.Atan2(v.Y, v.X))
+	return earth.NewPoint(earth.NormalizeLat(lat), earth.NormalizeLon(lon))
+}
+
+func clamp(x float64) float64 {
+	return math.Max(-1, math.Min(1, x))
+}
@@ -0,0 +1,82 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package regress_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/js-arias/earth"
+	"github.com/js-arias/earth/stat/regress"
+)
+
+func circlePoints(pole earth.Point, dist float64, n int) []earth.Point {
+	pts := make([]earth.Point, 0, n)
+	for i := 0; i < n; i++ {
+		b := 2 * math.Pi * float64(i) / float64(n)
+		pts = append(pts, earth.Destination(pole, dist, b))
+	}
+	return pts
+}
+
+func TestFitGreatCircle(t *testing.T) {
+	tests := map[string]earth.Point{
+		"north pole": earth.NorthPole,
+		"south pole": earth.SouthPole,
+		"Parana":     earth.NewPoint(-26, -65),
+		"oblique":    earth.NewPoint(30, 40),
+	}
+	for name, pole := range tests {
+		pts := circlePoints(pole, math.Pi/2, 36)
+		got, rms := regress.FitGreatCircle(pts)
+
+		// poles are equivalent to their antipodes
+		d := earth.Distance(got, pole)
+		d = math.Min(d, math.Pi-d)
+		if d > 1e-6 {
+			t.Errorf("%s: pole: got %.6f %.6f, want %.6f %.6f", name, got.Latitude(), got.Longitude(), pole.Latitude(), pole.Longitude())
+		}
+		if got.Latitude() < 0 {
+			t.Errorf("%s: pole: got latitude %.6f, want a northern pole", name, got.Latitude())
+		}
+		if rms > 1e-6 {
+			t.Errorf("%s: rms: got %.6f, want %.6f", name, rms, 0.0)
+		}
+	}
+
+	// a short arc
+	pole := earth.NewPoint(30, 40)
+	pts := circlePoints(pole, math.Pi/2, 360)[:20]
+	got, _ := regress.FitGreatCircle(pts)
+	d := earth.Distance(got, pole)
+	if d = math.Min(d, math.Pi-d); d > 1e-6 {
+		t.Errorf("short arc: pole: got %.6f %.6f, want %.6f %.6f", got.Latitude(), got.Longitude(), pole.Latitude(), pole.Longitude())
+	}
+
+	if _, rms := regress.FitGreatCircle(pts[:1]); !math.IsNaN(rms) {
+		t.Errorf("single point: rms: got %.6f, want NaN", rms)
+	}
+}
+
+func TestFitSmallCircle(t *testing.T) {
+	pole := earth.NewPoint(-26, -65)
+	colat := 0.5
+	pts := circlePoints(pole, colat, 36)
+
+	got, c, rms := regress.FitSmallCircle(pts)
+	if d := earth.Distance(got, pole); d > 1e-6 {
+		t.Errorf("pole: got %.6f %.6f, want %.6f %.6f", got.Latitude(), got.Longitude(), pole.Latitude(), pole.Longitude())
+	}
+	if math.Abs(c-colat) > 1e-6 {
+		t.Errorf("colatitude: got %.6f, want %.6f", c, colat)
+	}
+	if rms > 1e-6 {
+		t.Errorf("rms: got %.6f, want %.6f", rms, 0.0)
+	}
+
+	if _, _, rms := regress.FitSmallCircle(pts[:2]); !math.IsNaN(rms) {
+		t.Errorf("two points: rms: got %.6f, want NaN", rms)
+	}
+}
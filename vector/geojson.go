@@ -0,0 +1,217 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package vector
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/js-arias/earth"
+)
+
+// EncodeGeoJSON writes an slice of vector features
+// as a GeoJSON feature collection
+// (<https://datatracker.ietf.org/doc/html/rfc7946>).
+//
+// Each feature is encoded with its point
+// or its polygon
+// as the geometry,
+// and its name, type, plate,
+// and the begin and end ages
+// (in years)
+// as properties.
+// A feature with both a point and a polygon
+// is encoded as a geometry collection.
+// Polygons are encoded with a counter-clockwise orientation.
+func EncodeGeoJSON(w io.Writer, fs []Feature) error {
+	coll := geoCollection{
+		Type:     "FeatureCollection",
+		Features: make([]geoFeature, 0, len(fs)),
+	}
+
+	for _, f := range fs {
+		var geom []geoGeometry
+		if f.Point != nil {
+			geom = append(geom, geoGeometry{
+				Type:        "Point",
+				Coordinates: geoCoord(*f.Point),
+			})
+		}
+		if len(f.Polygon) > 0 {
+			// GeoJSON exterior rings
+			// must be counter-clockwise
+			poly := f.Polygon.EnsureCCW()
+			ring := make([][2]float64, 0, len(poly)+1)
+			for _, p := range poly {
+				ring = append(ring, geoCoord(p))
+			}
+			// GeoJSON rings must be closed
+			if poly[0] != poly[len(poly)-1] {
+				ring = append(ring, geoCoord(poly[0]))
+			}
+			geom = append(geom, geoGeometry{
+				Type:        "Polygon",
+				Coordinates: [][][2]float64{ring},
+			})
+		}
+
+		gf := geoFeature{
+			Type: "Feature",
+			Properties: geoProperties{
+				Name:  f.Name,
+				Type:  string(f.Type),
+				Plate: f.Plate,
+				Begin: f.Begin,
+				End:   f.End,
+			},
+		}
+		switch len(geom) {
+		case 0:
+			continue
+		case 1:
+			gf.Geometry = geom[0]
+		default:
+			gf.Geometry = geoGeometry{
+				Type:       "GeometryCollection",
+				Geometries: geom,
+			}
+		}
+		coll.Features = append(coll.Features, gf)
+	}
+
+	e := json.NewEncoder(w)
+	if err := e.Encode(coll); err != nil {
+		return fmt.Errorf("unable to encode GeoJSON: %v", err)
+	}
+	return nil
+}
+
+// A PixelFeature is a set of pixels
+// of a pixelation
+// to be encoded as a GeoJSON feature.
+type PixelFeature struct {
+	// Pixels is the set of pixel IDs
+	// of the feature.
+	Pixels []int
+
+	// Properties is the value
+	// encoded as the properties of the feature
+	// (usually a struct with JSON tags).
+	Properties any
+}
+
+// EncodePixelGeoJSON writes an slice of pixel features
+// as a GeoJSON feature collection.
+//
+// Each feature is encoded with a MultiPolygon geometry
+// made of the cell outlines of its pixels.
+// The pixels are not dissolved into a single outline:
+// each polygon is the outline of a run of consecutive pixels
+// in the same ring of the pixelation,
+// so the edges of the polygons are at the pixel resolution.
+// Cells that cross the antimeridian
+// are split at the antimeridian.
+func EncodePixelGeoJSON(w io.Writer, pix *earth.Pixelation, fs []PixelFeature) error {
+	coll := geoCollection{
+		Type:     "FeatureCollection",
+		Features: make([]geoFeature, 0, len(fs)),
+	}
+	for _, f := range fs {
+		coll.Features = append(coll.Features, geoFeature{
+			Type: "Feature",
+			Geometry: geoGeometry{
+				Type:        "MultiPolygon",
+				Coordinates: cellPolygons(pix, f.Pixels),
+			},
+			Properties: f.Properties,
+		})
+	}
+
+	e := json.NewEncoder(w)
+	if err := e.Encode(coll); err != nil {
+		return fmt.Errorf("unable to encode GeoJSON: %v", err)
+	}
+	return nil
+}
+
+// CellPolygons returns the GeoJSON coordinates
+// of the cell outlines of a set of pixels,
+// merging consecutive pixels of the same ring.
+func cellPolygons(pix *earth.Pixelation, pixels []int) [][][][2]float64 {
+	if !slices.IsSorted(pixels) {
+		pixels = slices.Clone(pixels)
+		slices.Sort(pixels)
+	}
+
+	polys := [][][][2]float64{}
+	for i := 0; i < len(pixels); {
+		// find a run of consecutive pixels
+		// in the same ring
+		first := pixels[i]
+		ring := pix.ID(first).Ring()
+		j := i + 1
+		for j < len(pixels) && pixels[j] == pixels[j-1]+1 && pix.ID(pixels[j]).Ring() == ring {
+			j++
+		}
+		last := pixels[j-1]
+		i = j
+
+		fb := pix.PixelBox(first)
+		lb := pix.PixelBox(last)
+		if fb.West > fb.East {
+			// the first cell crosses the antimeridian
+			polys = append(polys, rectangle(fb.North, fb.South, fb.West, 180))
+			polys = append(polys, rectangle(fb.North, fb.South, -180, lb.East))
+			continue
+		}
+		polys = append(polys, rectangle(fb.North, fb.South, fb.West, lb.East))
+	}
+	return polys
+}
+
+// Rectangle returns a counter-clockwise GeoJSON polygon
+// of a latitude-longitude rectangle.
+func rectangle(north, south, west, east float64) [][][2]float64 {
+	return [][][2]float64{{
+		{west, south},
+		{east, south},
+		{east, north},
+		{west, north},
+		{west, south},
+	}}
+}
+
+// GeoCoord returns the GeoJSON coordinates of a point
+// (i.e. longitude first).
+func geoCoord(p Point) [2]float64 {
+	return [2]float64{p.Lon, p.Lat}
+}
+
+type geoCollection struct {
+	Type     string       `json:"type"`
+	Features []geoFeature `json:"features"`
+}
+
+type geoFeature struct {
+	Type       string      `json:"type"`
+	Geometry   geoGeometry `json:"geometry"`
+	Properties any         `json:"properties"`
+}
+
+type geoGeometry struct {
+	Type        string        `json:"type"`
+	Coordinates any           `json:"coordinates,omitempty"`
+	Geometries  []geoGeometry `json:"geometries,omitempty"`
+}
+
+type geoProperties struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Plate int    `json:"plate"`
+	Begin int64  `json:"begin"`
+	End   int64  `json:"end"`
+}
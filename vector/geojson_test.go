@@ -0,0 +1,182 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package vector_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/js-arias/earth"
+	"github.com/js-arias/earth/vector"
+)
+
+func TestEncodeGeoJSON(t *testing.T) {
+	fs := []vector.Feature{
+		{
+			Name:  "Erebus",
+			Type:  vector.HotSpot,
+			Plate: 1,
+			Begin: 200_000_000,
+			Point: &vector.Point{Lat: -78, Lon: 167},
+		},
+		{
+			Name:  "triangle",
+			Type:  vector.Craton,
+			Plate: 2,
+			Begin: 100_000_000,
+			End:   10_000_000,
+			Polygon: vector.Polygon{
+				{Lat: 0, Lon: 0},
+				{Lat: 10, Lon: 10},
+				{Lat: -10, Lon: 10},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := vector.EncodeGeoJSON(&buf, fs); err != nil {
+		t.Fatalf("while encoding: %v", err)
+	}
+
+	var coll struct {
+		Type     string
+		Features []struct {
+			Type     string
+			Geometry struct {
+				Type        string
+				Coordinates json.RawMessage
+			}
+			Properties struct {
+				Name  string
+				Type  string
+				Plate int
+				Begin int64
+				End   int64
+			}
+		}
+	}
+	if err := json.Unmarshal(buf.Bytes(), &coll); err != nil {
+		t.Fatalf("while decoding: %v", err)
+	}
+
+	if coll.Type != "FeatureCollection" {
+		t.Errorf("type: got %q, want %q", coll.Type, "FeatureCollection")
+	}
+	if len(coll.Features) != len(fs) {
+		t.Fatalf("features: got %d, want %d", len(coll.Features), len(fs))
+	}
+
+	pt := coll.Features[0]
+	if pt.Geometry.Type != "Point" {
+		t.Errorf("geometry: got %q, want %q", pt.Geometry.Type, "Point")
+	}
+	var coord [2]float64
+	if err := json.Unmarshal(pt.Geometry.Coordinates, &coord); err != nil {
+		t.Fatalf("point coordinates: %v", err)
+	}
+	if want := [2]float64{167, -78}; coord != want {
+		t.Errorf("point coordinates: got %v, want %v", coord, want)
+	}
+	if pt.Properties.Name != "Erebus" || pt.Properties.Type != string(vector.HotSpot) || pt.Properties.Plate != 1 || pt.Properties.Begin != 200_000_000 {
+		t.Errorf("properties: got %+v", pt.Properties)
+	}
+
+	poly := coll.Features[1]
+	if poly.Geometry.Type != "Polygon" {
+		t.Errorf("geometry: got %q, want %q", poly.Geometry.Type, "Polygon")
+	}
+	var rings [][][2]float64
+	if err := json.Unmarshal(poly.Geometry.Coordinates, &rings); err != nil {
+		t.Fatalf("polygon coordinates: %v", err)
+	}
+	// the polygon is clockwise,
+	// so it must be reversed
+	want := [][][2]float64{{{10, -10}, {10, 10}, {0, 0}, {10, -10}}}
+	if !reflect.DeepEqual(rings, want) {
+		t.Errorf("polygon coordinates: got %v, want %v", rings, want)
+	}
+	if poly.Properties.End != 10_000_000 {
+		t.Errorf("end: got %d, want %d", poly.Properties.End, 10_000_000)
+	}
+}
+
+func TestEncodePixelGeoJSON(t *testing.T) {
+	pix := earth.NewPixelation(360)
+
+	// a run of consecutive pixels
+	// in the same ring
+	first, _ := pix.Ring(45)
+	run := []int{first + 12, first + 10, first + 11}
+
+	// a pixel that crosses the antimeridian
+	anti := -1
+	for id := 0; id < pix.Len(); id++ {
+		if b := pix.PixelBox(id); b.West > b.East && pix.ID(id).Ring() > 0 {
+			anti = id
+			break
+		}
+	}
+	if anti < 0 {
+		t.Fatalf("no pixel crossing the antimeridian")
+	}
+
+	type props struct {
+		Name string `json:"name"`
+	}
+	fs := []vector.PixelFeature{
+		{Pixels: run, Properties: props{Name: "run"}},
+		{Pixels: []int{anti}, Properties: props{Name: "antimeridian"}},
+	}
+
+	var buf bytes.Buffer
+	if err := vector.EncodePixelGeoJSON(&buf, pix, fs); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var coll struct {
+		Type     string `json:"type"`
+		Features []struct {
+			Geometry struct {
+				Type        string           `json:"type"`
+				Coordinates [][][][2]float64 `json:"coordinates"`
+			} `json:"geometry"`
+			Properties props `json:"properties"`
+		} `json:"features"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &coll); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if coll.Type != "FeatureCollection" || len(coll.Features) != 2 {
+		t.Fatalf("collection: got %q with %d features, want %q with %d features", coll.Type, len(coll.Features), "FeatureCollection", 2)
+	}
+
+	// the run is merged into a single cell
+	r := coll.Features[0]
+	if r.Properties.Name != "run" {
+		t.Errorf("run: got name %q, want %q", r.Properties.Name, "run")
+	}
+	if r.Geometry.Type != "MultiPolygon" || len(r.Geometry.Coordinates) != 1 {
+		t.Fatalf("run: got %q with %d polygons, want %q with %d polygons", r.Geometry.Type, len(r.Geometry.Coordinates), "MultiPolygon", 1)
+	}
+	fb := pix.PixelBox(first + 10)
+	lb := pix.PixelBox(first + 12)
+	want := [][2]float64{
+		{fb.West, fb.South},
+		{lb.East, fb.South},
+		{lb.East, fb.North},
+		{fb.West, fb.North},
+		{fb.West, fb.South},
+	}
+	if got := r.Geometry.Coordinates[0][0]; !reflect.DeepEqual(got, want) {
+		t.Errorf("run: got %v, want %v", got, want)
+	}
+
+	// the antimeridian cell is split
+	if a := coll.Features[1]; len(a.Geometry.Coordinates) != 2 {
+		t.Errorf("antimeridian: got %d polygons, want %d", len(a.Geometry.Coordinates), 2)
+	}
+}
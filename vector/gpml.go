@@ -61,15 +61,13 @@ func DecodeGPML(r io.Reader) ([]Feature, error) {
 
 			fs = append(fs, f)
 		}
-		if cf.Point != "" {
-			coord := strings.Fields(cf.Point)
-			if len(coord) != 2 {
-				return nil, fmt.Errorf("feature %s [plate %d]: bad point: %s", cf.Name, cf.Plate, cf.Point)
-			}
-			pt, err := ParsePoint(coord[0], coord[1])
-			if err != nil {
-				return nil, fmt.Errorf("feature %s [plate %d]: bad point: %v", cf.Name, cf.Plate, err)
-			}
+
+		pts, err := cf.points()
+		if err != nil {
+			return nil, fmt.Errorf("feature %s [plate %d]: %v", cf.Name, cf.Plate, err)
+		}
+
+		for _, pt := range pts {
 			f := Feature{
 				Name:  cf.Name,
 				Type:  cf.tp,
@@ -175,18 +173,19 @@ func (c collection) features() []feature {
 
 // A feature is a geographic polygon,
 // a boundary,
-// or a point,
+// or a set of points,
 // associated with a tectonic plate.
 type feature struct {
 	Name   string `xml:"name"`
 	Plate  int    `xml:"reconstructionPlateId>ConstantValue>value"`
 	Period period `xml:"validTime>TimePeriod"`
 
-	Point    string    `xml:"position>Point>pos"`
-	Boundary []polygon `xml:"boundary>ConstantValue>value>Polygon"`
-	Outline  []polygon `xml:"outlineOf>ConstantValue>value>Polygon"`
-	Line     []polygon `xml:"centerLineOf>ConstantValue>value>Polygon"`
-	Generic  []polygon `xml:"unclassifiedGeometry>ConstantValue>value>Polygon"`
+	Point      []string  `xml:"position>Point>pos"`
+	MultiPoint []string  `xml:"position>MultiPoint>pointMember>Point>pos"`
+	Boundary   []polygon `xml:"boundary>ConstantValue>value>Polygon"`
+	Outline    []polygon `xml:"outlineOf>ConstantValue>value>Polygon"`
+	Line       []polygon `xml:"centerLineOf>ConstantValue>value>Polygon"`
+	Generic    []polygon `xml:"unclassifiedGeometry>ConstantValue>value>Polygon"`
 
 	tp Type
 }
@@ -254,6 +253,26 @@ func (f feature) polygons() ([]Polygon, error) {
 	return pp, nil
 }
 
+// Points returns the points
+// of a feature.
+func (f feature) points() ([]Point, error) {
+	var pts []Point
+	for _, ps := range [][]string{f.Point, f.MultiPoint} {
+		for _, p := range ps {
+			coord := strings.Fields(p)
+			if len(coord) != 2 {
+				return nil, fmt.Errorf("bad point: %s", p)
+			}
+			pt, err := ParsePoint(coord[0], coord[1])
+			if err != nil {
+				return nil, fmt.Errorf("bad point: %v", err)
+			}
+			pts = append(pts, pt)
+		}
+	}
+	return pts, nil
+}
+
 // A period is the time period
 // for a geological feature.
 type period struct {
@@ -221,3 +221,41 @@ func TestDecodeGPML(t *testing.T) {
 		}
 	}
 }
+
+func TestDecodeGPMLMultiPoint(t *testing.T) {
+	want := []vector.Feature{
+		{
+			Name:  "Tristan",
+			Type:  vector.HotSpot,
+			Plate: 701,
+			Begin: 130_000_000,
+			Point: &vector.Point{Lat: -37.1, Lon: -12.3},
+		},
+		{
+			Name:  "Tristan",
+			Type:  vector.HotSpot,
+			Plate: 701,
+			Begin: 130_000_000,
+			Point: &vector.Point{Lat: -40.3, Lon: -9.9},
+		},
+	}
+
+	f, err := os.Open(filepath.Join(".", "testdata", "multipoint.gpml"))
+	if err != nil {
+		t.Fatalf("unable to open file \"multipoint.gpml\": %v", err)
+	}
+	defer f.Close()
+
+	coll, err := vector.DecodeGPML(f)
+	if err != nil {
+		t.Fatalf("while reading \"multipoint.gpml\": %v", err)
+	}
+	if len(coll) != len(want) {
+		t.Fatalf("invalid decoded data: got %d elements, want %d", len(coll), len(want))
+	}
+	for i, c := range coll {
+		if !reflect.DeepEqual(c, want[i]) {
+			t.Errorf("invalid decoded data: element %d\n\tgot %v\t\nwant %v", i, c, want[i])
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"image/color"
 	"math"
 	"slices"
+	"sync"
 
 	"github.com/js-arias/earth"
 	rasterizer "golang.org/x/image/vector"
@@ -17,9 +18,45 @@ import (
 // Pixels return an slice
 // with the ID of pixels in a pixelation
 // that are part of a feature.
+//
+// It is equivalent to PixelsRes
+// with the default resolution
+// of the intermediate raster
+// (ten times the number of pixels at the equator,
+// but at least 3600 columns).
 func (f Feature) Pixels(pix *earth.Pixelation) []int {
+	return f.PixelsRes(pix, 0)
+}
+
+// PixelsRes return an slice
+// with the ID of pixels in a pixelation
+// that are part of a feature,
+// using an intermediate raster
+// with the given number of columns.
+//
+// To find the pixels,
+// the polygon of the feature is drawn
+// in an azimuthal equidistant raster
+// of cols by cols cells,
+// so the size of a raster cell
+// is about 360/cols degrees
+// (e.g. 0.1 degrees with 3600 columns).
+// A pixel is part of the feature
+// if the raster cell of its center is filled,
+// so features narrower than a raster cell
+// might be reduced to the pixels of its vertices.
+// More columns produce a more accurate rasterization,
+// at the cost of speed and memory
+// (the raster uses cols*cols bytes).
+// If cols is less than 1,
+// the default resolution will be used.
+func (f Feature) PixelsRes(pix *earth.Pixelation, cols int) []int {
+	if cols < 1 {
+		cols = max(3600, pix.Equator()*10)
+	}
 	r := &raster{
 		pix:    pix,
+		cols:   cols,
 		pixels: make(map[int]bool),
 	}
 
@@ -34,6 +71,7 @@ func (f Feature) Pixels(pix *earth.Pixelation) []int {
 
 type raster struct {
 	pix    *earth.Pixelation
+	cols   int
 	pixels map[int]bool
 }
 
@@ -47,23 +85,26 @@ func (r *raster) pixSet() []int {
 }
 
 func (r *raster) doRaster(poly Polygon) {
-	cols := 3600
-	if c := r.pix.Equator() * 10; c > cols {
-		cols = c
+	if len(poly) == 0 {
+		return
 	}
 
+	cols := r.cols
+	buf := getRasterBuffer(cols)
+	defer rasterPool.Put(buf)
+
 	north, south := poly.bounds()
 	img := &azimuthal{
 		hemisphere: hemisphere(north, south),
 		cols:       cols,
-		pixels:     make([]bool, cols*cols),
+		pixels:     buf.pixels,
 		radius:     float64(cols) / (2 * math.Pi),
 		center:     float64(cols) / 2,
 		north:      -90,
 		south:      90,
 	}
 
-	ras := rasterizer.NewRasterizer(cols, cols)
+	ras := buf.ras
 	for i, p := range poly {
 		x, y := img.xy(p.Lat, p.Lon)
 		if i == 0 {
@@ -102,6 +143,34 @@ func (r *raster) doRaster(poly Polygon) {
 	}
 }
 
+// A rasterBuffer is a set of buffers
+// used to rasterize a polygon.
+type rasterBuffer struct {
+	pixels []bool
+	ras    *rasterizer.Rasterizer
+}
+
+// RasterPool is a pool of raster buffers,
+// so the buffers,
+// that are large,
+// are reused between features
+// instead of allocated for each polygon.
+var rasterPool sync.Pool
+
+// GetRasterBuffer returns a clean raster buffer
+// for an image of the given number of columns.
+func getRasterBuffer(cols int) *rasterBuffer {
+	if buf, ok := rasterPool.Get().(*rasterBuffer); ok && len(buf.pixels) == cols*cols {
+		clear(buf.pixels)
+		buf.ras.Reset(cols, cols)
+		return buf
+	}
+	return &rasterBuffer{
+		pixels: make([]bool, cols*cols),
+		ras:    rasterizer.NewRasterizer(cols, cols),
+	}
+}
+
 // Hemisphere returns true for the northern hemisphere
 // and false for the southern hemisphere.
 func hemisphere(north, south float64) bool {
@@ -10,6 +10,7 @@ import (
 	"path/filepath"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/js-arias/earth"
@@ -241,3 +242,76 @@ func TestRasterPoint(t *testing.T) {
 		t.Errorf("point: got %d, want %v [dist = %.3f]", pixel[0], pix.Pixel(f.Point.Lat, f.Point.Lon), dist)
 	}
 }
+
+func BenchmarkFeaturePixels(b *testing.B) {
+	pix := earth.NewPixelation(360)
+
+	// many small features
+	fs := make([]vector.Feature, 0, 20)
+	for i := 0; i < cap(fs); i++ {
+		c := earth.NewPoint(float64(i%17)*10-80, float64(i)*3.5-175)
+		fs = append(fs, vector.Feature{
+			Name:    "circle",
+			Plate:   i,
+			Polygon: vector.Circle(c, earth.ToRad(2), 12),
+		})
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var wg sync.WaitGroup
+		for w := 0; w < 4; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				for i := w; i < len(fs); i += 4 {
+					fs[i].Pixels(pix)
+				}
+			}(w)
+		}
+		wg.Wait()
+	}
+}
+
+func TestPixelsRes(t *testing.T) {
+	f, err := os.Open(filepath.Join(".", "testdata", "basin.gpml"))
+	if err != nil {
+		t.Fatalf("unable to open file %q: %v", "basin.gpml", err)
+	}
+	defer f.Close()
+	fs, err := vector.DecodeGPML(f)
+	if err != nil {
+		t.Fatalf("when reading %q: %v", "basin.gpml", err)
+	}
+	fs = append(fs,
+		vector.Feature{
+			Name:    "circle",
+			Polygon: vector.Circle(earth.NewPoint(-26, -65), earth.ToRad(3), 36),
+		},
+		vector.Feature{
+			Name:    "antimeridian",
+			Polygon: vector.Circle(earth.NewPoint(60, 178), earth.ToRad(5), 36),
+		},
+	)
+
+	pix := earth.NewPixelation(360)
+	for _, ft := range fs {
+		def := ft.Pixels(pix)
+		if got := ft.PixelsRes(pix, 0); !reflect.DeepEqual(got, def) {
+			t.Errorf("%s: default resolution: got %v, want %v", ft.Name, got, def)
+		}
+
+		// a higher resolution
+		// should not lose the pixels
+		// found at the default resolution
+		found := make(map[int]bool)
+		for _, px := range ft.PixelsRes(pix, 7200) {
+			found[px] = true
+		}
+		for _, px := range def {
+			if !found[px] {
+				t.Errorf("%s: pixel %d: not found at a higher resolution", ft.Name, px)
+			}
+		}
+	}
+}
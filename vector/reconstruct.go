@@ -0,0 +1,49 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package vector
+
+import (
+	"math"
+
+	"github.com/js-arias/earth"
+	"github.com/js-arias/earth/rotation"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+// Reconstruct returns a copy of the feature
+// with its coordinates rotated to its location
+// at the given age
+// (in years),
+// using the rotation of the feature plate
+// in a rotation model.
+// It returns false if there is no rotation
+// for the feature plate at the given age.
+func (f Feature) Reconstruct(rot rotation.Rotation, age int64) (Feature, bool) {
+	r, ok := rot.Rotation(f.Plate, age)
+	if !ok {
+		return Feature{}, false
+	}
+
+	nf := f
+	if f.Point != nil {
+		pt := rotatePoint(r, *f.Point)
+		nf.Point = &pt
+	}
+	if f.Polygon != nil {
+		nf.Polygon = make(Polygon, len(f.Polygon))
+		for i, p := range f.Polygon {
+			nf.Polygon[i] = rotatePoint(r, p)
+		}
+	}
+	return nf, true
+}
+
+func rotatePoint(r r3.Rotation, p Point) Point {
+	v := rotation.Rotate(r, p.Lat, p.Lon)
+
+	lat := earth.ToDegree(math.Asin(math.Max(-1, math.Min(1, v.Z))))
+	lon := earth.ToDegree(math.Atan2(v.Y, v.X))
+	return Point{Lat: earth.NormalizeLat(lat), Lon: earth.NormalizeLon(lon)}
+}
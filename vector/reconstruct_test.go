@@ -0,0 +1,79 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package vector_test
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/js-arias/earth/rotation"
+	"github.com/js-arias/earth/vector"
+)
+
+var rotModel = `
+1 0.0 90.0 0.0 0.0 0
+1 10.0 90.0 0.0 90.0 0
+`
+
+func TestFeatureReconstruct(t *testing.T) {
+	rot, err := rotation.Read(strings.NewReader(rotModel))
+	if err != nil {
+		t.Fatalf("unable to read rotation: %v", err)
+	}
+
+	f := vector.Feature{
+		Name:  "test",
+		Type:  vector.Craton,
+		Plate: 1,
+		Begin: 100_000_000,
+		Point: &vector.Point{Lat: 10, Lon: 0},
+		Polygon: vector.Polygon{
+			{Lat: 0, Lon: 0},
+			{Lat: 10, Lon: 10},
+			{Lat: -10, Lon: 10},
+			{Lat: 0, Lon: 0},
+		},
+	}
+
+	nf, ok := f.Reconstruct(rot, 10_000_000)
+	if !ok {
+		t.Fatalf("feature without rotation")
+	}
+	if nf.Name != f.Name || nf.Plate != f.Plate || nf.Begin != f.Begin {
+		t.Errorf("feature: got %v, want %v", nf, f)
+	}
+
+	pointHelper(t, *nf.Point, vector.Point{Lat: 10, Lon: 90})
+	want := vector.Polygon{
+		{Lat: 0, Lon: 90},
+		{Lat: 10, Lon: 100},
+		{Lat: -10, Lon: 100},
+		{Lat: 0, Lon: 90},
+	}
+	if len(nf.Polygon) != len(want) {
+		t.Fatalf("polygon: got %d points, want %d", len(nf.Polygon), len(want))
+	}
+	for i, p := range nf.Polygon {
+		pointHelper(t, p, want[i])
+	}
+
+	// the original feature is not modified
+	pointHelper(t, *f.Point, vector.Point{Lat: 10, Lon: 0})
+	pointHelper(t, f.Polygon[1], vector.Point{Lat: 10, Lon: 10})
+
+	f.Plate = 2
+	if _, ok := f.Reconstruct(rot, 10_000_000); ok {
+		t.Errorf("plate %d: found a rotation", f.Plate)
+	}
+}
+
+func pointHelper(t testing.TB, got, want vector.Point) {
+	t.Helper()
+
+	if math.Abs(got.Lat-want.Lat) > 1e-6 || math.Abs(got.Lon-want.Lon) > 1e-6 {
+		t.Errorf("point: got %v, want %v", got, want)
+	}
+}
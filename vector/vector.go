@@ -8,8 +8,13 @@ package vector
 
 import (
 	"fmt"
+	"math"
+	"slices"
 	"strconv"
 	"strings"
+
+	"github.com/js-arias/earth"
+	"gonum.org/v1/gonum/spatial/r3"
 )
 
 // Type is the type of a tectonic element.
@@ -66,6 +71,45 @@ const (
 	Terrane Type = "terrane"
 )
 
+// Types returns the valid types of tectonic elements.
+func Types() []Type {
+	return []Type{
+		Basin,
+		Boundary,
+		Coastline,
+		Continent,
+		Craton,
+		Fragment,
+		Generic,
+		HotSpot,
+		IslandArc,
+		LIP,
+		PaleoBoundary,
+		Passive,
+		Suture,
+		Terrane,
+	}
+}
+
+// ParseType returns a type of tectonic element
+// from its name
+// (e.g. "coastline" or "craton").
+// The name is case insensitive.
+func ParseType(name string) (Type, error) {
+	n := strings.ToLower(strings.Join(strings.Fields(name), " "))
+	for _, tp := range Types() {
+		if string(tp) == n {
+			return tp, nil
+		}
+	}
+
+	valid := make([]string, 0, len(Types()))
+	for _, tp := range Types() {
+		valid = append(valid, strconv.Quote(string(tp)))
+	}
+	return "", fmt.Errorf("unknown feature type %q: valid types are: %s", name, strings.Join(valid, ", "))
+}
+
 // A Feature is a tectonic feature.
 type Feature struct {
 	Name  string
@@ -82,6 +126,61 @@ type Feature struct {
 	Polygon Polygon
 }
 
+// AliveAt returns true if the feature exists
+// at the given age
+// (in years).
+// Both ends of the time range are inclusive,
+// so a feature is alive at its begin and end ages.
+func (f Feature) AliveAt(age int64) bool {
+	return f.End <= age && age <= f.Begin
+}
+
+// Centroid returns the centroid of the feature.
+// If the feature is a point,
+// it returns the point,
+// if the feature is a polygon,
+// it returns the spherical centroid
+// of the polygon vertices.
+// A feature without coordinates
+// returns the zero Point.
+func (f Feature) Centroid() Point {
+	if f.Point != nil {
+		return *f.Point
+	}
+
+	// ignore the closing vertex
+	n := len(f.Polygon)
+	if n > 1 && f.Polygon[0] == f.Polygon[n-1] {
+		n--
+	}
+	if n == 0 {
+		return Point{}
+	}
+
+	pts := make([]earth.Point, 0, n)
+	for _, p := range f.Polygon[:n] {
+		pts = append(pts, earth.NewPoint(p.Lat, p.Lon))
+	}
+	c := earth.Centroid(pts)
+	return Point{Lat: c.Latitude(), Lon: c.Longitude()}
+}
+
+// AreaKm2 returns the area of the feature
+// in square kilometers.
+// Point features have no area.
+//
+// As features are made of a single polygon,
+// holes are not taken into account.
+// If holes are supported in the future,
+// the area of the holes will be subtracted.
+func (f Feature) AreaKm2() float64 {
+	if f.Point != nil {
+		return 0
+	}
+	r := earth.Radius / 1000.0
+	return f.Polygon.Area() * r * r
+}
+
 // A Point is a geographic point.
 type Point struct {
 	Lat float64
@@ -145,6 +244,452 @@ func ParsePolygon(points string) (Polygon, error) {
 	return poly, nil
 }
 
+// Circle returns a polygon that approximates a spherical cap
+// (i.e. a circle on the surface of the sphere)
+// with a given center
+// and a radius in radians.
+//
+// The vertices of the polygon are at the given distance
+// from the center,
+// at equally spaced bearings,
+// starting from north.
+// As the polygon is made of straight segments
+// between vertices,
+// the area of the polygon is always smaller than the area of the cap.
+// More segments produce a better approximation,
+// but larger polygons.
+// For example,
+// with 36 segments
+// the area of the polygon is about 99.5% of the cap area.
+// If segments is less than 3,
+// 3 segments will be used.
+//
+// If the cap contains a pole,
+// the polygon will encircle that pole,
+// and the longitude of the vertices will wrap
+// at the antimeridian.
+// The returned polygon is closed
+// (i.e. the first and last vertices are the same).
+func Circle(center earth.Point, radius float64, segments int) Polygon {
+	if segments < 3 {
+		segments = 3
+	}
+
+	poly := make(Polygon, 0, segments+1)
+	step := 2 * math.Pi / float64(segments)
+	for i := 0; i < segments; i++ {
+		pt := earth.Destination(center, radius, float64(i)*step)
+		poly = append(poly, Point{Lat: pt.Latitude(), Lon: pt.Longitude()})
+	}
+	poly = append(poly, poly[0])
+
+	return poly
+}
+
+// EnsureCCW returns the polygon
+// with a counter-clockwise orientation
+// (i.e. the enclosed area is at the left
+// when walking along the polygon,
+// as seen from outside the sphere).
+// If the polygon is clockwise,
+// it returns a reversed copy of the polygon,
+// otherwise it returns the same polygon.
+func (poly Polygon) EnsureCCW() Polygon {
+	if !poly.IsClockwise() {
+		return poly
+	}
+
+	rev := make(Polygon, len(poly))
+	for i, p := range poly {
+		rev[len(poly)-1-i] = p
+	}
+	return rev
+}
+
+// IsClockwise returns true if the polygon
+// has a clockwise orientation
+// (as seen from outside the sphere).
+func (poly Polygon) IsClockwise() bool {
+	return poly.signedArea() < 0
+}
+
+// Area returns the area of a polygon
+// on the unit sphere
+// (i.e. in steradians),
+// regardless of its orientation.
+// It assumes that the polygon does not contain a pole.
+// Polygons with less than three vertices
+// have no area.
+func (poly Polygon) Area() float64 {
+	return math.Abs(poly.signedArea())
+}
+
+// SignedArea returns the area of a polygon
+// on the unit sphere
+// using the approximation of
+// Chamberlain & Duquette (2007)
+// JPL Publication 07-03.
+// The area is positive if the polygon is counter-clockwise,
+// and negative if it is clockwise.
+// It assumes that the polygon does not contain a pole.
+func (poly Polygon) signedArea() float64 {
+	// ignore the closing vertex
+	n := len(poly)
+	if n > 1 && poly[0] == poly[n-1] {
+		n--
+	}
+	if n < 3 {
+		return 0
+	}
+
+	var sum float64
+	for i := 0; i < n; i++ {
+		prev := poly[(i+n-1)%n]
+		next := poly[(i+1)%n]
+		dLon := lonDiff(prev.Lon, next.Lon)
+		sum += earth.ToRad(dLon) * math.Sin(earth.ToRad(poly[i].Lat))
+	}
+	return -sum / 2
+}
+
+// MaxTriangleEdge is the maximum length,
+// in radians,
+// of an edge of a triangle
+// returned by Triangulate.
+const maxTriangleEdge = 5 * math.Pi / 180
+
+// Triangulate returns a triangulation of a polygon
+// on the sphere.
+// Triangles are counter-clockwise
+// (as seen from outside the sphere),
+// and their edges are great circle arcs.
+//
+// The polygon is triangulated using ear clipping,
+// and then each triangle is subdivided
+// until no edge is longer than 5 degrees,
+// so the triangles are small enough
+// to be drawn as planar triangles
+// in any projection.
+// The sum of the spherical excesses of the triangles
+// is the area of the polygon
+// with great circle edges.
+//
+// Polygons with less than three vertices
+// return nil.
+// As with Area,
+// it assumes that the polygon does not contain a pole,
+// and the polygon should be smaller than a hemisphere.
+// Polygons that cross the antimeridian are supported.
+// For self-intersecting polygons,
+// or highly non-convex polygons with nearly collinear vertices,
+// the ear clipping might fail
+// and the remaining vertices will be triangulated as a fan,
+// so some triangles might be outside of the polygon.
+func (poly Polygon) Triangulate() [][3]Point {
+	poly = poly.EnsureCCW()
+
+	// open polygon without repeated vertices
+	var ring []earth.Point
+	for _, p := range poly {
+		pt := earth.NewPoint(p.Lat, p.Lon)
+		if len(ring) > 0 && ring[len(ring)-1].Vector() == pt.Vector() {
+			continue
+		}
+		ring = append(ring, pt)
+	}
+	if len(ring) > 1 && ring[0].Vector() == ring[len(ring)-1].Vector() {
+		ring = ring[:len(ring)-1]
+	}
+	if len(ring) < 3 {
+		return nil
+	}
+
+	var tris [][3]earth.Point
+	idx := make([]int, len(ring))
+	for i := range idx {
+		idx[i] = i
+	}
+	for len(idx) > 3 {
+		ear := -1
+		for i := range idx {
+			a := idx[(i+len(idx)-1)%len(idx)]
+			c := idx[(i+1)%len(idx)]
+			if isEar(ring, idx, a, idx[i], c) {
+				ear = i
+				break
+			}
+		}
+		if ear < 0 {
+			break
+		}
+		a := idx[(ear+len(idx)-1)%len(idx)]
+		c := idx[(ear+1)%len(idx)]
+		tris = append(tris, [3]earth.Point{ring[a], ring[idx[ear]], ring[c]})
+		idx = slices.Delete(idx, ear, ear+1)
+	}
+
+	// remaining vertices
+	for i := 1; i+1 < len(idx); i++ {
+		tris = append(tris, [3]earth.Point{ring[idx[0]], ring[idx[i]], ring[idx[i+1]]})
+	}
+
+	var out [][3]Point
+	for _, t := range tris {
+		out = subdivide(out, t)
+	}
+	return out
+}
+
+// IsEar returns true if the vertex b,
+// with the neighbors a and c,
+// is an ear of a counter-clockwise ring
+// (i.e. it is convex,
+// and no other vertex is inside the triangle).
+func isEar(ring []earth.Point, idx []int, a, b, c int) bool {
+	va, vb, vc := ring[a].Vector(), ring[b].Vector(), ring[c].Vector()
+	if det(va, vb, vc) <= 1e-15 {
+		return false
+	}
+	for _, i := range idx {
+		if i == a || i == b || i == c {
+			continue
+		}
+		v := ring[i].Vector()
+		if v == va || v == vb || v == vc {
+			continue
+		}
+		if det(va, vb, v) >= 0 && det(vb, vc, v) >= 0 && det(vc, va, v) >= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Det returns the determinant
+// (i.e. the triple product)
+// of three vectors.
+// It is positive if the vectors are counter-clockwise
+// as seen from outside the sphere.
+func det(a, b, c r3.Vec) float64 {
+	return r3.Dot(a, r3.Cross(b, c))
+}
+
+// Subdivide adds a triangle to a list of triangles,
+// subdividing it
+// until no edge is longer than the maximum edge.
+func subdivide(tris [][3]Point, t [3]earth.Point) [][3]Point {
+	ab := earth.Distance(t[0], t[1])
+	bc := earth.Distance(t[1], t[2])
+	ca := earth.Distance(t[2], t[0])
+	if max(ab, bc, ca) <= maxTriangleEdge {
+		var tri [3]Point
+		for i, p := range t {
+			tri[i] = Point{Lat: p.Latitude(), Lon: p.Longitude()}
+		}
+		return append(tris, tri)
+	}
+
+	mab := earth.Midpoint(t[0], t[1])
+	mbc := earth.Midpoint(t[1], t[2])
+	mca := earth.Midpoint(t[2], t[0])
+	tris = subdivide(tris, [3]earth.Point{t[0], mab, mca})
+	tris = subdivide(tris, [3]earth.Point{mab, t[1], mbc})
+	tris = subdivide(tris, [3]earth.Point{mca, mbc, t[2]})
+	tris = subdivide(tris, [3]earth.Point{mab, mbc, mca})
+	return tris
+}
+
+// Bounds returns the bounding box of a polygon.
+//
+// The longitude range is the smallest range
+// that includes all the vertices of the polygon,
+// so if the polygon crosses the antimeridian,
+// the West longitude of the box
+// will be greater than the East longitude.
+// If the polygon encircles a pole,
+// the box will include all longitudes
+// and will be extended up to that pole.
+//
+// An empty polygon returns an empty box.
+func (poly Polygon) Bounds() earth.Box {
+	if len(poly) == 0 {
+		return earth.Box{}
+	}
+
+	north, south := poly.bounds()
+
+	// check if the polygon winds around a pole
+	var wind float64
+	for i := 1; i < len(poly); i++ {
+		wind += lonDiff(poly[i-1].Lon, poly[i].Lon)
+	}
+	if poly[0] != poly[len(poly)-1] {
+		wind += lonDiff(poly[len(poly)-1].Lon, poly[0].Lon)
+	}
+	if math.Abs(wind) > 180 {
+		if north+south >= 0 {
+			north = 90
+		} else {
+			south = -90
+		}
+		return earth.Box{North: north, South: south, West: -180, East: 180}
+	}
+
+	lons := make([]float64, 0, len(poly))
+	for _, p := range poly {
+		lons = append(lons, p.Lon)
+	}
+	slices.Sort(lons)
+
+	// the box is the complement
+	// of the largest gap between longitudes
+	west, east := lons[0], lons[len(lons)-1]
+	gap := lons[0] + 360 - lons[len(lons)-1]
+	for i := 1; i < len(lons); i++ {
+		if d := lons[i] - lons[i-1]; d > gap {
+			gap = d
+			west, east = lons[i], lons[i-1]
+		}
+	}
+
+	return earth.Box{North: north, South: south, West: west, East: east}
+}
+
+// ClipToBox returns the pieces of a polygon
+// inside a latitude-longitude box.
+// If west is greater than east,
+// the box crosses the antimeridian.
+//
+// The clip is a planar clip
+// (Sutherland-Hodgman)
+// in latitude-longitude space,
+// so the edges of the polygon
+// are taken as straight lines in that space
+// (i.e. not great circle arcs).
+// Polygons that cross the antimeridian
+// are supported,
+// and the returned pieces use longitudes
+// in the range [-180, 180],
+// so a piece might also cross the antimeridian.
+// Polygons that encircle a pole
+// are not supported.
+// If a concave polygon enters the box several times,
+// the parts will be joined by edges
+// along the boundary of the box.
+//
+// The returned pieces are closed
+// (i.e. the first and last vertices are the same).
+// If the polygon is outside of the box,
+// it returns nil.
+func (poly Polygon) ClipToBox(north, south, west, east float64) []Polygon {
+	// open polygon with continuous longitudes
+	n := len(poly)
+	if n > 1 && poly[0] == poly[n-1] {
+		n--
+	}
+	if n < 3 {
+		return nil
+	}
+	ring := make([]Point, n)
+	ring[0] = poly[0]
+	for i := 1; i < n; i++ {
+		ring[i] = Point{
+			Lat: poly[i].Lat,
+			Lon: ring[i-1].Lon + lonDiff(poly[i-1].Lon, poly[i].Lon),
+		}
+	}
+
+	if west > east {
+		east += 360
+	}
+
+	var pieces []Polygon
+	for _, shift := range []float64{-360, 0, 360} {
+		w, e := west+shift, east+shift
+		c := clipEdge(ring, func(p Point) bool { return p.Lat <= north }, func(p, q Point) Point { return atLat(p, q, north) })
+		c = clipEdge(c, func(p Point) bool { return p.Lat >= south }, func(p, q Point) Point { return atLat(p, q, south) })
+		c = clipEdge(c, func(p Point) bool { return p.Lon >= w }, func(p, q Point) Point { return atLon(p, q, w) })
+		c = clipEdge(c, func(p Point) bool { return p.Lon <= e }, func(p, q Point) Point { return atLon(p, q, e) })
+
+		piece := make(Polygon, 0, len(c)+1)
+		for _, p := range c {
+			p = Point{Lat: p.Lat, Lon: earth.NormalizeLon(p.Lon - shift)}
+			if len(piece) > 0 && piece[len(piece)-1] == p {
+				continue
+			}
+			piece = append(piece, p)
+		}
+		if len(piece) > 1 && piece[0] == piece[len(piece)-1] {
+			piece = piece[:len(piece)-1]
+		}
+		if len(piece) < 3 || planarArea(c) == 0 {
+			continue
+		}
+		pieces = append(pieces, append(piece, piece[0]))
+	}
+	return pieces
+}
+
+// ClipEdge clips an open polygon
+// using a single edge
+// (Sutherland-Hodgman).
+// Inside returns true if a point is inside the edge,
+// and cross returns the intersection point
+// of a segment with the edge.
+func clipEdge(ring []Point, inside func(Point) bool, cross func(p, q Point) Point) []Point {
+	if len(ring) == 0 {
+		return nil
+	}
+
+	var out []Point
+	prev := ring[len(ring)-1]
+	for _, p := range ring {
+		if inside(p) {
+			if !inside(prev) {
+				out = append(out, cross(prev, p))
+			}
+			out = append(out, p)
+		} else if inside(prev) {
+			out = append(out, cross(prev, p))
+		}
+		prev = p
+	}
+	return out
+}
+
+// PlanarArea returns the absolute area
+// of an open polygon
+// in latitude-longitude space.
+func planarArea(ring []Point) float64 {
+	var sum float64
+	for i, p := range ring {
+		q := ring[(i+1)%len(ring)]
+		sum += p.Lon*q.Lat - q.Lon*p.Lat
+	}
+	return math.Abs(sum / 2)
+}
+
+// AtLat returns the point of a segment
+// at a given latitude.
+func atLat(p, q Point, lat float64) Point {
+	t := (lat - p.Lat) / (q.Lat - p.Lat)
+	return Point{Lat: lat, Lon: p.Lon + t*(q.Lon-p.Lon)}
+}
+
+// AtLon returns the point of a segment
+// at a given longitude.
+func atLon(p, q Point, lon float64) Point {
+	t := (lon - p.Lon) / (q.Lon - p.Lon)
+	return Point{Lat: p.Lat + t*(q.Lat-p.Lat), Lon: lon}
+}
+
+// LonDiff returns the longitude difference
+// from a to b
+// in the range [-180, 180].
+func lonDiff(a, b float64) float64 {
+	return earth.NormalizeLon(b - a)
+}
+
 // Bounds return the north and south coordinate
 // defined for a polygon.
 func (poly Polygon) bounds() (north, south float64) {
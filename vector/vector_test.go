@@ -0,0 +1,402 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package vector_test
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/js-arias/earth"
+	"github.com/js-arias/earth/vector"
+)
+
+func TestFeatureAliveAt(t *testing.T) {
+	f := vector.Feature{
+		Name:  "Pacific",
+		Type:  vector.Basin,
+		Plate: 901,
+		Begin: 140_000_000,
+		End:   20_000_000,
+	}
+
+	tests := map[int64]bool{
+		0:           false,
+		19_999_999:  false,
+		20_000_000:  true,
+		100_000_000: true,
+		140_000_000: true,
+		140_000_001: false,
+	}
+	for age, want := range tests {
+		if got := f.AliveAt(age); got != want {
+			t.Errorf("age %d: got %v, want %v", age, got, want)
+		}
+	}
+}
+
+func TestFeatureCentroidArea(t *testing.T) {
+	pt := vector.Feature{
+		Name:  "Tucuman",
+		Point: &vector.Point{Lat: -26, Lon: -65},
+	}
+	if c := pt.Centroid(); c != *pt.Point {
+		t.Errorf("point centroid: got %v, want %v", c, *pt.Point)
+	}
+	if a := pt.AreaKm2(); a != 0 {
+		t.Errorf("point area: got %.3f, want 0", a)
+	}
+
+	poly := vector.Feature{
+		Name: "square",
+		Polygon: vector.Polygon{
+			{Lat: 0, Lon: 0},
+			{Lat: 0, Lon: 10},
+			{Lat: 10, Lon: 10},
+			{Lat: 10, Lon: 0},
+			{Lat: 0, Lon: 0},
+		},
+	}
+	c := poly.Centroid()
+	if math.Abs(c.Lat-5) > 0.1 || math.Abs(c.Lon-5) > 1e-9 {
+		t.Errorf("polygon centroid: got %.6f,%.6f, want 5,5", c.Lat, c.Lon)
+	}
+
+	r := earth.Radius / 1000.0
+	want := earth.ToRad(10) * math.Sin(earth.ToRad(10)) * r * r
+	if a := poly.AreaKm2(); math.Abs(a-want) > 1 {
+		t.Errorf("polygon area: got %.3f, want %.3f", a, want)
+	}
+
+	// orientation does not change the area
+	slices.Reverse(poly.Polygon)
+	if a := poly.AreaKm2(); math.Abs(a-want) > 1 {
+		t.Errorf("reversed polygon area: got %.3f, want %.3f", a, want)
+	}
+}
+
+func TestCircle(t *testing.T) {
+	tests := map[string]struct {
+		lat, lon float64
+		radius   float64
+		segments int
+	}{
+		"Tucuman":     {lat: -26, lon: -65, radius: 10, segments: 36},
+		"antimeridan": {lat: 20, lon: 178, radius: 5, segments: 24},
+		"north pole":  {lat: 80, lon: 0, radius: 15, segments: 36},
+		"south pole":  {lat: -85, lon: 120, radius: 10, segments: 36},
+		"few":         {lat: 0, lon: 0, radius: 10, segments: 1},
+	}
+
+	pix := earth.NewPixelation(360)
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := earth.NewPoint(test.lat, test.lon)
+			r := earth.ToRad(test.radius)
+			poly := vector.Circle(c, r, test.segments)
+
+			seg := test.segments
+			if seg < 3 {
+				seg = 3
+			}
+			if len(poly) != seg+1 {
+				t.Errorf("got %d vertices, want %d", len(poly), seg+1)
+			}
+			if poly[0] != poly[len(poly)-1] {
+				t.Errorf("open polygon: first %v, last %v", poly[0], poly[len(poly)-1])
+			}
+
+			for _, p := range poly {
+				d := earth.Distance(c, earth.NewPoint(p.Lat, p.Lon))
+				if math.Abs(d-r) > 1e-6 {
+					t.Errorf("vertex %v: distance %.6f, want %.6f", p, d, r)
+				}
+			}
+
+			// all pixels of the cap
+			// must be inside the radius
+			f := vector.Feature{Polygon: poly}
+			ids := f.Pixels(pix)
+			if len(ids) == 0 {
+				t.Fatalf("no pixels in cap")
+			}
+			center := pix.Pixel(test.lat, test.lon).ID()
+			if !slices.Contains(ids, center) {
+				t.Errorf("center pixel %d not in cap", center)
+			}
+			for _, id := range ids {
+				d := earth.Distance(c, pix.ID(id).Point())
+				if d > r+2*earth.ToRad(pix.Step()) {
+					t.Errorf("pixel %d: distance %.6f, want < %.6f", id, d, r)
+				}
+			}
+		})
+	}
+}
+
+func TestPolygonOrientation(t *testing.T) {
+	cw := vector.Polygon{
+		{Lat: 0, Lon: 0},
+		{Lat: 10, Lon: 0},
+		{Lat: 10, Lon: 10},
+		{Lat: 0, Lon: 10},
+		{Lat: 0, Lon: 0},
+	}
+	ccw := vector.Polygon{
+		{Lat: 0, Lon: 0},
+		{Lat: 0, Lon: 10},
+		{Lat: 10, Lon: 10},
+		{Lat: 10, Lon: 0},
+		{Lat: 0, Lon: 0},
+	}
+	// a counter-clockwise polygon
+	// that crosses the antimeridian
+	anti := vector.Polygon{
+		{Lat: -5, Lon: 175},
+		{Lat: -5, Lon: -175},
+		{Lat: 5, Lon: -175},
+		{Lat: 5, Lon: 175},
+	}
+
+	if !cw.IsClockwise() {
+		t.Errorf("clockwise polygon: got counter-clockwise")
+	}
+	if got := cw.EnsureCCW(); !slices.Equal(got, ccw) {
+		t.Errorf("clockwise polygon: got %v, want %v", got, ccw)
+	}
+
+	if ccw.IsClockwise() {
+		t.Errorf("counter-clockwise polygon: got clockwise")
+	}
+	if got := ccw.EnsureCCW(); !slices.Equal(got, ccw) {
+		t.Errorf("counter-clockwise polygon: got %v, want %v", got, ccw)
+	}
+
+	if anti.IsClockwise() {
+		t.Errorf("antimeridian polygon: got clockwise")
+	}
+
+	c := vector.Circle(earth.NewPoint(-26, -65), earth.ToRad(10), 36)
+	if !c.IsClockwise() {
+		t.Errorf("circle: got counter-clockwise")
+	}
+}
+
+func TestPolygonBounds(t *testing.T) {
+	tests := map[string]struct {
+		poly vector.Polygon
+		want earth.Box
+	}{
+		"simple": {
+			poly: vector.Polygon{
+				{Lat: 0, Lon: 0},
+				{Lat: 0, Lon: 10},
+				{Lat: 12, Lon: 10},
+				{Lat: 10, Lon: -2},
+				{Lat: 0, Lon: 0},
+			},
+			want: earth.Box{North: 12, South: 0, West: -2, East: 10},
+		},
+		"antimeridian": {
+			poly: vector.Polygon{
+				{Lat: -5, Lon: 175},
+				{Lat: -5, Lon: -170},
+				{Lat: 5, Lon: -170},
+				{Lat: 5, Lon: 175},
+			},
+			want: earth.Box{North: 5, South: -5, West: 175, East: -170},
+		},
+		"north pole": {
+			poly: vector.Polygon{
+				{Lat: 80, Lon: 0},
+				{Lat: 80, Lon: 90},
+				{Lat: 75, Lon: 180},
+				{Lat: 80, Lon: -90},
+				{Lat: 80, Lon: 0},
+			},
+			want: earth.Box{North: 90, South: 75, West: -180, East: 180},
+		},
+		"empty": {},
+	}
+
+	for name, test := range tests {
+		if got := test.poly.Bounds(); got != test.want {
+			t.Errorf("%s: got %v, want %v", name, got, test.want)
+		}
+	}
+}
+
+func TestPolygonClipToBox(t *testing.T) {
+	// a polygon that extends beyond all the edges of the box
+	poly := vector.Polygon{
+		{Lat: -20, Lon: -30},
+		{Lat: -20, Lon: 30},
+		{Lat: 20, Lon: 30},
+		{Lat: 20, Lon: -30},
+		{Lat: -20, Lon: -30},
+	}
+	pieces := poly.ClipToBox(10, -10, -15, 15)
+	if len(pieces) != 1 {
+		t.Fatalf("all edges: got %d pieces, want %d", len(pieces), 1)
+	}
+	box := earth.Box{North: 10, South: -10, West: -15, East: 15}
+	if b := pieces[0].Bounds(); b != box {
+		t.Errorf("all edges: bounds: got %v, want %v", b, box)
+	}
+	if len(pieces[0]) != 5 {
+		t.Errorf("all edges: got %d vertices, want %d", len(pieces[0]), 5)
+	}
+	if pieces[0][0] != pieces[0][len(pieces[0])-1] {
+		t.Errorf("all edges: open polygon")
+	}
+
+	// a polygon inside the box
+	in := poly.ClipToBox(30, -30, -40, 40)
+	if len(in) != 1 || in[0].Bounds() != poly.Bounds() {
+		t.Errorf("inside: got %v, want %v", in, poly)
+	}
+
+	// a polygon outside the box
+	if out := poly.ClipToBox(60, 40, -15, 15); out != nil {
+		t.Errorf("outside: got %v, want nil", out)
+	}
+
+	// a polygon and a box that cross the antimeridian
+	anti := vector.Polygon{
+		{Lat: -5, Lon: 170},
+		{Lat: -5, Lon: -170},
+		{Lat: 5, Lon: -170},
+		{Lat: 5, Lon: 170},
+	}
+	pieces = anti.ClipToBox(10, -10, 175, -175)
+	if len(pieces) != 1 {
+		t.Fatalf("antimeridian: got %d pieces, want %d", len(pieces), 1)
+	}
+	box = earth.Box{North: 5, South: -5, West: 175, East: -175}
+	if b := pieces[0].Bounds(); b != box {
+		t.Errorf("antimeridian: bounds: got %v, want %v", b, box)
+	}
+
+	// a box at one side of the antimeridian
+	pieces = anti.ClipToBox(10, -10, -178, -100)
+	if len(pieces) != 1 {
+		t.Fatalf("west of antimeridian: got %d pieces, want %d", len(pieces), 1)
+	}
+	box = earth.Box{North: 5, South: -5, West: -178, East: -170}
+	if b := pieces[0].Bounds(); b != box {
+		t.Errorf("west of antimeridian: bounds: got %v, want %v", b, box)
+	}
+}
+
+func TestPolygonTriangulate(t *testing.T) {
+	tests := map[string]vector.Polygon{
+		"convex": {
+			{Lat: 0, Lon: 0},
+			{Lat: 0, Lon: 10},
+			{Lat: 10, Lon: 10},
+			{Lat: 10, Lon: 0},
+			{Lat: 0, Lon: 0},
+		},
+		"clockwise": {
+			{Lat: 0, Lon: 0},
+			{Lat: 10, Lon: 0},
+			{Lat: 10, Lon: 10},
+			{Lat: 0, Lon: 10},
+		},
+		"L shape": {
+			{Lat: -20, Lon: -20},
+			{Lat: -20, Lon: 20},
+			{Lat: -10, Lon: 20},
+			{Lat: -10, Lon: -10},
+			{Lat: 20, Lon: -10},
+			{Lat: 20, Lon: -20},
+		},
+		"antimeridian": {
+			{Lat: -5, Lon: 175},
+			{Lat: -5, Lon: -175},
+			{Lat: 5, Lon: -175},
+			{Lat: 5, Lon: 175},
+		},
+		"circle": vector.Circle(earth.NewPoint(-26, -65), earth.ToRad(20), 36),
+	}
+
+	for name, poly := range tests {
+		t.Run(name, func(t *testing.T) {
+			tris := poly.Triangulate()
+			if len(tris) == 0 {
+				t.Fatalf("no triangles")
+			}
+
+			var sum float64
+			for _, tr := range tris {
+				a := earth.NewPoint(tr[0].Lat, tr[0].Lon)
+				b := earth.NewPoint(tr[1].Lat, tr[1].Lon)
+				c := earth.NewPoint(tr[2].Lat, tr[2].Lon)
+				if d := earth.Distance(a, b); d > earth.ToRad(5)+1e-9 {
+					t.Errorf("triangle %v: edge length %.6f", tr, earth.ToDegree(d))
+				}
+				if vector.Polygon(tr[:]).IsClockwise() {
+					t.Errorf("triangle %v: clockwise", tr)
+				}
+				_, _, _, area := earth.Triangle(a, b, c)
+				sum += area
+			}
+
+			// the area of the polygon
+			// with great circle edges
+			want := greatCircleEdges(poly).Area()
+			if math.Abs(sum-want)/want > 0.001 {
+				t.Errorf("area: got %.6f, want %.6f", sum, want)
+			}
+		})
+	}
+
+	if tris := (vector.Polygon{{Lat: 0, Lon: 0}, {Lat: 1, Lon: 1}}).Triangulate(); tris != nil {
+		t.Errorf("two vertices: got %v, want nil", tris)
+	}
+}
+
+// GreatCircleEdges returns a polygon
+// in which the edges are replaced by dense great circle arcs.
+func greatCircleEdges(poly vector.Polygon) vector.Polygon {
+	var gc vector.Polygon
+	for i, p := range poly {
+		q := poly[(i+1)%len(poly)]
+		wp := earth.Waypoints(earth.NewPoint(p.Lat, p.Lon), earth.NewPoint(q.Lat, q.Lon), earth.ToRad(0.1))
+		for _, w := range wp[:len(wp)-1] {
+			gc = append(gc, vector.Point{Lat: w.Latitude(), Lon: w.Longitude()})
+		}
+	}
+	return gc
+}
+
+func TestParseType(t *testing.T) {
+	tests := map[string]vector.Type{
+		"coastline":              vector.Coastline,
+		"Craton":                 vector.Craton,
+		"LARGE IGNEOUS PROVINCE": vector.LIP,
+		" island  arc ":          vector.IslandArc,
+	}
+	for name, want := range tests {
+		tp, err := vector.ParseType(name)
+		if err != nil {
+			t.Errorf("type %q: unexpected error: %v", name, err)
+			continue
+		}
+		if tp != want {
+			t.Errorf("type %q: got %q, want %q", name, tp, want)
+		}
+	}
+
+	for _, tp := range vector.Types() {
+		if got, err := vector.ParseType(string(tp)); err != nil || got != tp {
+			t.Errorf("type %q: got %q [%v]", tp, got, err)
+		}
+	}
+
+	if _, err := vector.ParseType("volcano"); err == nil {
+		t.Errorf("type %q: expecting error", "volcano")
+	}
+}